@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gravitas-games/mmorts/internal/bot"
+)
+
+func main() {
+	url := flag.String("url", "ws://localhost:8080/ws", "WebSocket endpoint")
+	bots := flag.Int("bots", 10, "number of concurrent bot connections")
+	rampUp := flag.Duration("ramp", 5*time.Second, "time over which bots connect")
+	duration := flag.Duration("duration", time.Minute, "total run time")
+	scriptPath := flag.String("script", "", "YAML bot script (default: join, ping, chat loop)")
+	keyPath := flag.String("key", "", "PEM EC private key used to sign tokens (default: generate one)")
+	issuer := flag.String("issuer", "login-server", "JWT issuer claim, must match jwt.issuer on the server")
+	userBase := flag.Int64("user-base", 1000000, "first user ID assigned to bots")
+	serveKey := flag.String("serve-key", "", "address to serve the public key on, for jwt.public_key_url (e.g. :9090)")
+	flag.Parse()
+
+	log.Println("Starting MMORTS load bot...")
+
+	// Token issuer
+	var issuerKey *bot.TokenIssuer
+	var err error
+	if *keyPath != "" {
+		issuerKey, err = bot.LoadTokenIssuer(*keyPath, *issuer, *duration+time.Hour)
+	} else {
+		issuerKey, err = bot.NewTokenIssuer(*issuer, *duration+time.Hour)
+	}
+	if err != nil {
+		log.Fatalf("Failed to create token issuer: %v", err)
+	}
+
+	if *serveKey != "" {
+		go func() {
+			log.Printf("Serving public key on http://%s/", *serveKey)
+			if err := http.ListenAndServe(*serveKey, issuerKey); err != nil {
+				log.Fatalf("Public key server error: %v", err)
+			}
+		}()
+	}
+
+	// Bot script
+	script := bot.DefaultScript()
+	if *scriptPath != "" {
+		script, err = bot.LoadScript(*scriptPath)
+		if err != nil {
+			log.Fatalf("Failed to load script: %v", err)
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	log.Printf("Running %d bots against %s for %s (script %q)", *bots, *url, *duration, script.Name)
+
+	stats, err := bot.Run(ctx, bot.RunConfig{
+		URL:        *url,
+		Bots:       *bots,
+		RampUp:     *rampUp,
+		Duration:   *duration,
+		UserIDBase: *userBase,
+		Script:     script,
+		Issuer:     issuerKey,
+	})
+	if err != nil {
+		log.Fatalf("Load test failed: %v", err)
+	}
+
+	stats.WriteReport(os.Stdout)
+}
@@ -2,7 +2,9 @@ package chunk
 
 import (
     "github.com/gravitas-015/hexcore"
+    "github.com/gravitas-015/hexcore/chunk/dense"
     "github.com/gravitas-015/hexcore/hex"
+    "github.com/gravitas-015/hexcore/terrain"
     "github.com/gravitas-015/mapgen/generator"
 )
 
@@ -23,6 +25,12 @@ type HexChunk struct {
 // BuildChunk builds a chunk at center using CA and computes edge signatures.
 func BuildChunk(center hex.Axial, radius int, seed int64, params generator.Params) HexChunk {
     cells := generator.GenerateChunkCells(center, seed, params)
+    sig := edgeSignatures(center, radius, cells)
+    return HexChunk{Coord: center, Radius: radius, Cells: cells, EdgeSig: sig, Seed: seed, Ruleset: "default"}
+}
+
+// edgeSignatures computes the open-cell mask of each chunk side.
+func edgeSignatures(center hex.Axial, radius int, cells map[hex.Axial]hexcore.HexState) map[EdgeDirection]EdgeMask {
     sig := make(map[EdgeDirection]EdgeMask, 6)
     for s := 0; s < 6; s++ {
         var m EdgeMask
@@ -34,7 +42,34 @@ func BuildChunk(center hex.Axial, radius int, seed int64, params generator.Param
         }
         sig[EdgeDirection(s)] = m
     }
-    return HexChunk{Coord: center, Radius: radius, Cells: cells, EdgeSig: sig, Seed: seed, Ruleset: "default"}
+    return sig
+}
+
+// Dense returns the chunk in array form, e.g. for storage or transfer.
+func (c HexChunk) Dense() *dense.Chunk {
+    d := dense.FromMap(c.Coord, c.Radius, c.Cells)
+    d.Seed, d.Ruleset = c.Seed, c.Ruleset
+    return d
+}
+
+// FromDense rebuilds a chunk, including edge signatures, from array form.
+func FromDense(d *dense.Chunk) HexChunk {
+    cells := d.ToMap()
+    return HexChunk{
+        Coord:   d.Center,
+        Radius:  d.Radius(),
+        Cells:   cells,
+        EdgeSig: edgeSignatures(d.Center, d.Radius(), cells),
+        Seed:    d.Seed,
+        Ruleset: d.Ruleset,
+    }
+}
+
+// Terrain converts the generated cells into the shared terrain model.
+func (c HexChunk) Terrain(reg *terrain.Registry) *terrain.Map {
+    m := terrain.NewMap(reg)
+    m.ImportStates(c.Cells)
+    return m
 }
 
 // NeighborChunkCenter returns the neighbor chunk center at side s with offset 2R.
@@ -43,10 +78,7 @@ func NeighborChunkCenter(center hex.Axial, radius int, side int) hex.Axial {
     // Use diagonal step: V = (dir[side] + dir[side-1]) * R ⇒ center distance = 2R.
     // This aligns chunk sides without triangular gaps and matches the user's intent
     // of "18 hexes in each axial direction" for R=9.
-    d := hex.Directions[side]
-    dprev := hex.Directions[(side+5)%6]
-    v := hex.Axial{Q: d.Q + dprev.Q, R: d.R + dprev.R}
-    return center.Add(v.Mul(radius))
+    return hex.NeighborChunkCenter(center, radius, side)
 }
 
 // Pocket represents the union of 7 chunks (center + 6 neighbors) with unified axial cells.
@@ -0,0 +1,123 @@
+package dense
+
+import (
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "hash/crc32"
+
+    "github.com/gravitas-015/hexcore"
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+// Chunk is the dense form of a generated chunk: one generator state per
+// hex plus the parameters it was generated with.
+type Chunk struct {
+    Grid[hexcore.HexState]
+    Seed    int64
+    Ruleset string
+}
+
+// NewChunk returns a chunk with every hex Dead.
+func NewChunk(center hex.Axial, radius int) *Chunk {
+    return &Chunk{Grid: *NewGrid[hexcore.HexState](center, radius)}
+}
+
+// FromMap builds a dense chunk from map-based cells. Hexes missing from
+// cells are Dead; cells outside the disk are ignored.
+func FromMap(center hex.Axial, radius int, cells map[hex.Axial]hexcore.HexState) *Chunk {
+    c := NewChunk(center, radius)
+    for a, st := range cells { c.Set(a, st) }
+    return c
+}
+
+// ToMap returns the cells in map form.
+func (c *Chunk) ToMap() map[hex.Axial]hexcore.HexState {
+    m := make(map[hex.Axial]hexcore.HexState, c.Len())
+    for i, st := range c.Cells { m[c.Axial(i)] = st }
+    return m
+}
+
+// Binary format, little endian:
+//
+//  magic    [4]byte "HXCK"
+//  version  uint8
+//  radius   uint16
+//  center   int32 q, int32 r
+//  seed     int64
+//  ruleset  uint16 length + bytes
+//  cells    one byte per hex, in Layout order
+//  checksum uint32 CRC-32 (IEEE) of everything before it
+const (
+    codecMagic   = "HXCK"
+    codecVersion = 1
+)
+
+var (
+    ErrBadMagic    = errors.New("dense: not a chunk")
+    ErrBadVersion  = errors.New("dense: unsupported version")
+    ErrBadChecksum = errors.New("dense: checksum mismatch")
+    ErrTruncated   = errors.New("dense: truncated data")
+)
+
+// MarshalBinary encodes the chunk. States must fit in a byte.
+func (c *Chunk) MarshalBinary() ([]byte, error) {
+    if c.Radius() < 0 { return nil, errors.New("dense: empty chunk") }
+    if c.Radius() > 0xFFFF { return nil, fmt.Errorf("dense: radius %d too large", c.Radius()) }
+    if len(c.Ruleset) > 0xFFFF { return nil, fmt.Errorf("dense: ruleset name too long") }
+    buf := bytes.NewBuffer(make([]byte, 0, 4+1+2+8+8+2+len(c.Ruleset)+c.Len()+4))
+    buf.WriteString(codecMagic)
+    buf.WriteByte(codecVersion)
+    var tmp [8]byte
+    binary.LittleEndian.PutUint16(tmp[:2], uint16(c.Radius()))
+    buf.Write(tmp[:2])
+    binary.LittleEndian.PutUint32(tmp[:4], uint32(int32(c.Center.Q)))
+    buf.Write(tmp[:4])
+    binary.LittleEndian.PutUint32(tmp[:4], uint32(int32(c.Center.R)))
+    buf.Write(tmp[:4])
+    binary.LittleEndian.PutUint64(tmp[:8], uint64(c.Seed))
+    buf.Write(tmp[:8])
+    binary.LittleEndian.PutUint16(tmp[:2], uint16(len(c.Ruleset)))
+    buf.Write(tmp[:2])
+    buf.WriteString(c.Ruleset)
+    for i, st := range c.Cells {
+        if st < 0 || st > 0xFF { return nil, fmt.Errorf("dense: state %d at %v does not fit in a byte", st, c.Axial(i)) }
+        buf.WriteByte(byte(st))
+    }
+    binary.LittleEndian.PutUint32(tmp[:4], crc32.ChecksumIEEE(buf.Bytes()))
+    buf.Write(tmp[:4])
+    return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes data produced by MarshalBinary, verifying the
+// header and checksum.
+func (c *Chunk) UnmarshalBinary(data []byte) error {
+    const header = 4 + 1 + 2 + 4 + 4 + 8 + 2
+    if len(data) < header+4 { return ErrTruncated }
+    if string(data[:4]) != codecMagic { return ErrBadMagic }
+    if data[4] != codecVersion { return fmt.Errorf("%w: %d", ErrBadVersion, data[4]) }
+    body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+    if crc32.ChecksumIEEE(body) != sum { return ErrBadChecksum }
+
+    radius := int(binary.LittleEndian.Uint16(data[5:]))
+    center := hex.Axial{
+        Q: int(int32(binary.LittleEndian.Uint32(data[7:]))),
+        R: int(int32(binary.LittleEndian.Uint32(data[11:]))),
+    }
+    seed := int64(binary.LittleEndian.Uint64(data[15:]))
+    n := int(binary.LittleEndian.Uint16(data[23:]))
+    rest := body[header:]
+    if len(rest) < n { return ErrTruncated }
+    ruleset := string(rest[:n])
+    cells := rest[n:]
+    // Checked before LayoutFor, which builds and caches the layout: the
+    // radius is untrusted, and a layout never costs more than the data itself
+    if len(cells) != 1+3*radius*(radius+1) { return fmt.Errorf("%w: %d cells for radius %d", ErrTruncated, len(cells), radius) }
+
+    out := NewChunk(center, radius)
+    for i, b := range cells { out.Cells[i] = hexcore.HexState(b) }
+    out.Seed, out.Ruleset = seed, ruleset
+    *c = *out
+    return nil
+}
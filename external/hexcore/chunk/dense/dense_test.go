@@ -0,0 +1,247 @@
+package dense
+
+import (
+    "encoding/binary"
+    "errors"
+    "hash/crc32"
+    "math/rand"
+    "testing"
+
+    "github.com/gravitas-015/hexcore"
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+func TestLayoutRoundTrip(t *testing.T) {
+    for R := 0; R <= 12; R++ {
+        l := LayoutFor(R)
+        if l.Len() != 1+3*R*(R+1) {
+            t.Fatalf("radius %d: %d cells", R, l.Len())
+        }
+        for i := 0; i < l.Len(); i++ {
+            d := l.Offset(i)
+            if hex.DistanceAxial(hex.Axial{}, d) > R {
+                t.Fatalf("radius %d: index %d outside disk", R, i)
+            }
+            if j, ok := l.Index(d); !ok || j != i {
+                t.Fatalf("radius %d: Index(Offset(%d)) = %d, %v", R, i, j, ok)
+            }
+        }
+        for _, d := range hex.Ring(hex.Axial{}, R+1) {
+            if _, ok := l.Index(d); ok {
+                t.Fatalf("radius %d: %v outside disk has an index", R, d)
+            }
+        }
+    }
+    if LayoutFor(9) != LayoutFor(9) {
+        t.Errorf("layouts should be shared")
+    }
+}
+
+func randomCells(center hex.Axial, R int, seed int64) map[hex.Axial]hexcore.HexState {
+    rng := rand.New(rand.NewSource(seed))
+    cells := map[hex.Axial]hexcore.HexState{}
+    for _, a := range hex.Disk(center, R) { cells[a] = hexcore.HexState(rng.Intn(3)) }
+    return cells
+}
+
+func TestGrid(t *testing.T) {
+    center := hex.Axial{Q: 18, R: -9}
+    g := NewGrid[int](center, 3)
+    for _, a := range hex.Disk(center, 3) {
+        if !g.Set(a, a.Q*100+a.R) { t.Fatalf("Set(%v) outside grid", a) }
+    }
+    if g.Set(center.Add(hex.Axial{Q: 4, R: 0}), 1) || g.Ptr(hex.Axial{}) != nil {
+        t.Errorf("hexes outside the disk should be rejected")
+    }
+    n := 0
+    g.Each(func(a hex.Axial, v *int) {
+        n++
+        if *v != a.Q*100+a.R { t.Errorf("%v holds %d", a, *v) }
+    })
+    if n != 37 {
+        t.Errorf("Each visited %d cells", n)
+    }
+    *g.Ptr(center) = -1
+    if v, _ := g.Get(center); v != -1 {
+        t.Errorf("Ptr did not alias the cell")
+    }
+}
+
+func TestEmptyGrid(t *testing.T) {
+    if l := LayoutFor(-3); l.Len() != 0 || l.Radius() != -1 {
+        t.Errorf("negative radius: len %d, radius %d", l.Len(), l.Radius())
+    }
+    for name, g := range map[string]*Grid[int]{"negative": NewGrid[int](hex.Axial{}, -2), "zero value": {}} {
+        if g.Len() != 0 || g.Radius() != -1 || g.Contains(hex.Axial{}) || g.Set(hex.Axial{}, 1) || g.Ptr(hex.Axial{}) != nil {
+            t.Errorf("%s grid is not empty", name)
+        }
+        if _, ok := g.Get(hex.Axial{}); ok { t.Errorf("%s grid has a cell", name) }
+        g.Each(func(hex.Axial, *int) { t.Errorf("%s grid visited a cell", name) })
+    }
+    if _, err := (&Chunk{}).MarshalBinary(); err == nil {
+        t.Error("expected an error encoding an empty chunk")
+    }
+}
+
+func TestChunkMapRoundTrip(t *testing.T) {
+    center := hex.Axial{Q: 9, R: 9}
+    cells := randomCells(center, 9, 1)
+    c := FromMap(center, 9, cells)
+    back := c.ToMap()
+    if len(back) != len(cells) {
+        t.Fatalf("round trip has %d cells, want %d", len(back), len(cells))
+    }
+    for a, st := range cells {
+        if back[a] != st { t.Fatalf("%v: %d != %d", a, back[a], st) }
+    }
+}
+
+func TestChunkBinary(t *testing.T) {
+    c := FromMap(hex.Axial{Q: -18, R: 9}, 9, randomCells(hex.Axial{Q: -18, R: 9}, 9, 2))
+    c.Seed, c.Ruleset = -42, "caves"
+    data, err := c.MarshalBinary()
+    if err != nil {
+        t.Fatalf("marshal: %v", err)
+    }
+    if len(data) != 4+1+2+8+8+2+5+271+4 {
+        t.Errorf("unexpected encoded size %d", len(data))
+    }
+
+    var got Chunk
+    if err := got.UnmarshalBinary(data); err != nil {
+        t.Fatalf("unmarshal: %v", err)
+    }
+    if got.Center != c.Center || got.Radius() != 9 || got.Seed != -42 || got.Ruleset != "caves" {
+        t.Errorf("header mismatch: %+v", got)
+    }
+    for i := range c.Cells {
+        if got.Cells[i] != c.Cells[i] { t.Fatalf("cell %d differs", i) }
+    }
+
+    corrupt := func(f func(b []byte) []byte) []byte {
+        b := append([]byte(nil), data...)
+        return f(b)
+    }
+    tests := []struct {
+        name string
+        data []byte
+        want error
+    }{
+        {"flipped cell", corrupt(func(b []byte) []byte { b[40] ^= 1; return b }), ErrBadChecksum},
+        {"bad magic", corrupt(func(b []byte) []byte { b[0] = 'X'; return b }), ErrBadMagic},
+        {"future version", corrupt(func(b []byte) []byte { b[4] = 9; return b }), ErrBadVersion},
+        {"truncated", data[:10], ErrTruncated},
+    }
+    for _, tt := range tests {
+        var c Chunk
+        if err := c.UnmarshalBinary(tt.data); !errors.Is(err, tt.want) {
+            t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+        }
+    }
+
+    // A huge radius with a valid checksum is rejected before its layout is built
+    huge := corrupt(func(b []byte) []byte {
+        binary.LittleEndian.PutUint16(b[5:], 0xFFFF)
+        binary.LittleEndian.PutUint32(b[len(b)-4:], crc32.ChecksumIEEE(b[:len(b)-4]))
+        return b
+    })
+    var hc Chunk
+    if err := hc.UnmarshalBinary(huge); !errors.Is(err, ErrTruncated) {
+        t.Errorf("huge radius: got %v, want %v", err, ErrTruncated)
+    }
+    layoutsMu.RLock()
+    _, built := layouts[0xFFFF]
+    layoutsMu.RUnlock()
+    if built {
+        t.Error("layout built for an untrusted radius")
+    }
+
+    c.Cells[0] = 300
+    if _, err := c.MarshalBinary(); err == nil {
+        t.Errorf("expected error for state that does not fit in a byte")
+    }
+}
+
+const benchRadius = 9
+
+func BenchmarkBuildMap(b *testing.B) {
+    disk := hex.Disk(hex.Axial{}, benchRadius)
+    b.ReportAllocs()
+    for i := 0; i < b.N; i++ {
+        m := make(map[hex.Axial]hexcore.HexState, len(disk))
+        for _, a := range disk { m[a] = hexcore.Space }
+    }
+}
+
+func BenchmarkBuildDense(b *testing.B) {
+    disk := hex.Disk(hex.Axial{}, benchRadius)
+    b.ReportAllocs()
+    for i := 0; i < b.N; i++ {
+        c := NewChunk(hex.Axial{}, benchRadius)
+        for _, a := range disk { c.Set(a, hexcore.Space) }
+    }
+}
+
+func BenchmarkLookupMap(b *testing.B) {
+    m := randomCells(hex.Axial{}, benchRadius, 3)
+    disk := hex.Disk(hex.Axial{}, benchRadius)
+    b.ResetTimer()
+    n := 0
+    for i := 0; i < b.N; i++ {
+        for _, a := range disk {
+            if m[a] == hexcore.Space { n++ }
+        }
+    }
+}
+
+func BenchmarkLookupDense(b *testing.B) {
+    c := FromMap(hex.Axial{}, benchRadius, randomCells(hex.Axial{}, benchRadius, 3))
+    disk := hex.Disk(hex.Axial{}, benchRadius)
+    b.ResetTimer()
+    n := 0
+    for i := 0; i < b.N; i++ {
+        for _, a := range disk {
+            if st, _ := c.Get(a); st == hexcore.Space { n++ }
+        }
+    }
+}
+
+func BenchmarkIterateMap(b *testing.B) {
+    m := randomCells(hex.Axial{}, benchRadius, 3)
+    b.ResetTimer()
+    n := 0
+    for i := 0; i < b.N; i++ {
+        for _, st := range m {
+            if st == hexcore.Space { n++ }
+        }
+    }
+}
+
+func BenchmarkIterateDense(b *testing.B) {
+    c := FromMap(hex.Axial{}, benchRadius, randomCells(hex.Axial{}, benchRadius, 3))
+    b.ResetTimer()
+    n := 0
+    for i := 0; i < b.N; i++ {
+        for _, st := range c.Cells {
+            if st == hexcore.Space { n++ }
+        }
+    }
+}
+
+func BenchmarkMarshal(b *testing.B) {
+    c := FromMap(hex.Axial{}, benchRadius, randomCells(hex.Axial{}, benchRadius, 3))
+    b.ReportAllocs()
+    for i := 0; i < b.N; i++ {
+        if _, err := c.MarshalBinary(); err != nil { b.Fatal(err) }
+    }
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+    c := FromMap(hex.Axial{}, benchRadius, randomCells(hex.Axial{}, benchRadius, 3))
+    data, _ := c.MarshalBinary()
+    b.ReportAllocs()
+    for i := 0; i < b.N; i++ {
+        var out Chunk
+        if err := out.UnmarshalBinary(data); err != nil { b.Fatal(err) }
+    }
+}
@@ -0,0 +1,72 @@
+package dense
+
+import "github.com/gravitas-015/hexcore/hex"
+
+// Grid holds one value per hex of a disk, in Layout order. The zero value
+// is an empty grid; use NewGrid to get one with cells.
+type Grid[T any] struct {
+    Center hex.Axial
+    Cells  []T
+    layout *Layout
+}
+
+// NewGrid returns a zeroed grid for the disk of radius around center. A
+// negative radius gives an empty grid.
+func NewGrid[T any](center hex.Axial, radius int) *Grid[T] {
+    l := LayoutFor(radius)
+    return &Grid[T]{Center: center, Cells: make([]T, l.Len()), layout: l}
+}
+
+// lay returns the grid's layout, the empty one for a zero-value grid.
+func (g *Grid[T]) lay() *Layout {
+    if g.layout == nil { return emptyLayout }
+    return g.layout
+}
+
+// Radius returns the disk radius (-1 for an empty grid).
+func (g *Grid[T]) Radius() int { return g.lay().radius }
+
+// Len returns the number of hexes in the grid.
+func (g *Grid[T]) Len() int { return len(g.Cells) }
+
+// Index returns the cell index of world hex a.
+func (g *Grid[T]) Index(a hex.Axial) (int, bool) { return g.lay().Index(a.Subtract(g.Center)) }
+
+// Axial returns the world hex of cell index i.
+func (g *Grid[T]) Axial(i int) hex.Axial { return g.Center.Add(g.lay().coords[i]) }
+
+// Contains reports whether a lies inside the grid.
+func (g *Grid[T]) Contains(a hex.Axial) bool {
+    _, ok := g.Index(a)
+    return ok
+}
+
+// Get returns the value at a.
+func (g *Grid[T]) Get(a hex.Axial) (T, bool) {
+    i, ok := g.Index(a)
+    if !ok {
+        var zero T
+        return zero, false
+    }
+    return g.Cells[i], true
+}
+
+// Ptr returns a pointer to the value at a, or nil outside the grid.
+func (g *Grid[T]) Ptr(a hex.Axial) *T {
+    i, ok := g.Index(a)
+    if !ok { return nil }
+    return &g.Cells[i]
+}
+
+// Set stores v at a. It returns false if a is outside the grid.
+func (g *Grid[T]) Set(a hex.Axial, v T) bool {
+    i, ok := g.Index(a)
+    if ok { g.Cells[i] = v }
+    return ok
+}
+
+// Each calls fn for every cell in index order.
+func (g *Grid[T]) Each(fn func(a hex.Axial, v *T)) {
+    coords := g.lay().coords
+    for i := range g.Cells { fn(g.Center.Add(coords[i]), &g.Cells[i]) }
+}
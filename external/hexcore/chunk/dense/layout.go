@@ -0,0 +1,80 @@
+// Package dense stores chunk cells in flat arrays instead of maps. Cells
+// of a chunk disk are numbered row by row (by r, then q), so conversion
+// between axial coordinates and array index is O(1) in both directions
+// and iteration is a plain slice walk.
+package dense
+
+import (
+    "sync"
+
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+// Layout numbers the hexes of a disk of some radius around the origin.
+// Layouts are immutable and shared; get them from LayoutFor.
+type Layout struct {
+    radius   int
+    rowStart []int       // index of the first hex in each row, by r+radius
+    coords   []hex.Axial // offset from the center, by index
+}
+
+var (
+    layoutsMu sync.RWMutex
+    layouts   = map[int]*Layout{}
+)
+
+// emptyLayout has no hexes. It stands for every negative radius and backs
+// zero-value grids.
+var emptyLayout = &Layout{radius: -1}
+
+// LayoutFor returns the layout for radius, building it on first use. A
+// negative radius gives an empty layout (radius -1).
+func LayoutFor(radius int) *Layout {
+    if radius < 0 { return emptyLayout }
+    layoutsMu.RLock()
+    l := layouts[radius]
+    layoutsMu.RUnlock()
+    if l != nil { return l }
+
+    layoutsMu.Lock()
+    defer layoutsMu.Unlock()
+    if l = layouts[radius]; l == nil {
+        l = newLayout(radius)
+        layouts[radius] = l
+    }
+    return l
+}
+
+func newLayout(R int) *Layout {
+    l := &Layout{
+        radius:   R,
+        rowStart: make([]int, 2*R+1),
+        coords:   make([]hex.Axial, 0, 1+3*R*(R+1)),
+    }
+    for r := -R; r <= R; r++ {
+        l.rowStart[r+R] = len(l.coords)
+        for q := qMin(R, r); q <= qMax(R, r); q++ {
+            l.coords = append(l.coords, hex.Axial{Q: q, R: r})
+        }
+    }
+    return l
+}
+
+func qMin(R, r int) int { if -r-R > -R { return -r - R }; return -R }
+func qMax(R, r int) int { if -r+R < R { return -r + R }; return R }
+
+// Radius returns the disk radius.
+func (l *Layout) Radius() int { return l.radius }
+
+// Len returns the number of hexes in the disk.
+func (l *Layout) Len() int { return len(l.coords) }
+
+// Index returns the index of offset d from the disk center.
+func (l *Layout) Index(d hex.Axial) (int, bool) {
+    R := l.radius
+    if d.R < -R || d.R > R || d.Q < qMin(R, d.R) || d.Q > qMax(R, d.R) { return 0, false }
+    return l.rowStart[d.R+R] + d.Q - qMin(R, d.R), true
+}
+
+// Offset returns the offset from the disk center of index i.
+func (l *Layout) Offset(i int) hex.Axial { return l.coords[i] }
@@ -19,7 +19,7 @@ type Link struct {
     To   hex.Axial
 }
 
-// ComputeBoundarySpurs finds edge Space cells (ring R) on open sides of each chunk center
+// BoundarySpurs finds edge Space cells (ring R) on open sides of each chunk center
 // that have fewer than 6 existing neighbors in the current union. For each such cell, it
 // selects an outward direction that does not point towards an existing cell and returns
 // a spur descriptor.
@@ -27,11 +27,11 @@ type Link struct {
 // Inputs:
 // - plan: list of chunk centers generated
 // - R: chunk radius
-// - unionState: map of axial -> state (0=Dead,1=Space,2=Overlay/Yellow) for all generated cells
+// - unionState: generator state (Dead, Space or Overlay) for all generated cells
 //
 // Output:
 // - list of spurs; duplicates by origin are suppressed.
-func ComputeBoundarySpurs(plan []hex.Axial, R int, unionState map[hex.Axial]int) []Spur {
+func BoundarySpurs(plan []hex.Axial, R int, unionState map[hex.Axial]hexcore.HexState) []Spur {
     // Build plan set for quick membership
     planSet := make(map[hex.Axial]bool, len(plan))
     for _, c := range plan { planSet[c] = true }
@@ -60,7 +60,7 @@ func ComputeBoundarySpurs(plan []hex.Axial, R int, unionState map[hex.Axial]int)
             edge := hex.Edge(c, R, s)
             for _, a := range edge {
                 st, ok := unionState[a]
-                if !ok || st != int(hexcore.Space) { continue }
+                if !ok || st != hexcore.Space { continue }
                 if neighborPresentCount(a) >= 6 { continue }
                 if dedup[a] { continue }
                 // Prefer outward direction s if absent; else find any absent dir
@@ -86,13 +86,19 @@ func ComputeBoundarySpurs(plan []hex.Axial, R int, unionState map[hex.Axial]int)
     return out
 }
 
-// ComputeInternalLinks casts rays from each spur origin along its direction until
+// ComputeBoundarySpurs is BoundarySpurs for a union given as raw state
+// values (0=Dead,1=Space,2=Overlay/Yellow).
+func ComputeBoundarySpurs(plan []hex.Axial, R int, unionState map[hex.Axial]int) []Spur {
+    return BoundarySpurs(plan, R, statesOf(unionState))
+}
+
+// InternalLinks casts rays from each spur origin along its direction until
 // it hits any existing cell in the current pocket union. If the hit is a green cell,
 // or a grey cell that is adjacent to at least one green cell, a link is produced from
 // the spur origin to the hit cell.
-// EvaluateInternalLinks returns accepted links and spurs that collided with the pocket
+// It returns accepted links and spurs that collided with the pocket
 // but were rejected (i.e., hit a cell that didn't satisfy the criteria to become a link).
-func EvaluateInternalLinks(spurs []Spur, R int, unionState map[hex.Axial]int) (links []Link, rejected []hex.Axial) {
+func InternalLinks(spurs []Spur, R int, unionState map[hex.Axial]hexcore.HexState) (links []Link, rejected []hex.Axial) {
     present := make(map[hex.Axial]bool, len(unionState))
     for a := range unionState { present[a] = true }
     // conservative upper bound for ray length across a pocket
@@ -115,8 +121,8 @@ func EvaluateInternalLinks(spurs []Spur, R int, unionState map[hex.Axial]int) (l
         }
         if !ok { continue }
         st := unionState[hit]
-        isGreen := st == int(hexcore.Space)
-        isGreyAdjGreen := st == int(hexcore.Dead) && hasAdjacentGreen(hit, unionState)
+        isGreen := st == hexcore.Space
+        isGreyAdjGreen := st == hexcore.Dead && hasAdjacentGreen(hit, unionState)
         if isGreen || isGreyAdjGreen {
             links = append(links, Link{From: a, To: hit})
         } else {
@@ -126,9 +132,22 @@ func EvaluateInternalLinks(spurs []Spur, R int, unionState map[hex.Axial]int) (l
     return links, rejected
 }
 
-func hasAdjacentGreen(a hex.Axial, unionState map[hex.Axial]int) bool {
+// EvaluateInternalLinks is InternalLinks for a union given as raw state
+// values (0=Dead,1=Space,2=Overlay/Yellow).
+func EvaluateInternalLinks(spurs []Spur, R int, unionState map[hex.Axial]int) (links []Link, rejected []hex.Axial) {
+    return InternalLinks(spurs, R, statesOf(unionState))
+}
+
+// statesOf converts raw state values to generator states.
+func statesOf(raw map[hex.Axial]int) map[hex.Axial]hexcore.HexState {
+    out := make(map[hex.Axial]hexcore.HexState, len(raw))
+    for a, st := range raw { out[a] = hexcore.HexState(st) }
+    return out
+}
+
+func hasAdjacentGreen(a hex.Axial, unionState map[hex.Axial]hexcore.HexState) bool {
     for _, d := range hex.Directions {
-        if unionState[a.Add(d)] == int(hexcore.Space) {
+        if unionState[a.Add(d)] == hexcore.Space {
             return true
         }
     }
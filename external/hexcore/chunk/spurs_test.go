@@ -0,0 +1,29 @@
+package chunk
+
+import (
+    "reflect"
+    "testing"
+
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+func TestSpursRawStates(t *testing.T) {
+    plan, cells := stitchWorld(separateRooms()...)
+    raw := make(map[hex.Axial]int, len(cells))
+    for a, st := range cells { raw[a] = int(st) }
+
+    spurs := BoundarySpurs(plan, stitchRadius, cells)
+    if got := ComputeBoundarySpurs(plan, stitchRadius, raw); !reflect.DeepEqual(got, spurs) {
+        t.Errorf("raw spurs differ:\n%v\n%v", got, spurs)
+    }
+    // Spurs off every open room cell, so rays also cross the interior
+    for _, r := range separateRooms() {
+        for d := range hex.Directions { spurs = append(spurs, Spur{Origin: r, Dir: d}) }
+    }
+    links, rejected := InternalLinks(spurs, stitchRadius, cells)
+    rawLinks, rawRejected := EvaluateInternalLinks(spurs, stitchRadius, raw)
+    if !reflect.DeepEqual(rawLinks, links) || !reflect.DeepEqual(rawRejected, rejected) {
+        t.Errorf("raw links differ: %v %v, want %v %v", rawLinks, rawRejected, links, rejected)
+    }
+    if len(links) == 0 { t.Error("fixture produced no links") }
+}
@@ -0,0 +1,198 @@
+package chunk
+
+import (
+    "math/rand"
+    "sort"
+
+    "github.com/gravitas-015/hexcore"
+    "github.com/gravitas-015/hexcore/hex"
+    "github.com/gravitas-015/hexcore/path"
+)
+
+// StitchReport summarizes a stitching pass.
+type StitchReport struct {
+    ComponentsBefore int    // passable components before stitching
+    ComponentsAfter  int    // passable components after stitching (1 on success)
+    Links            []Link // straight spur links that were carved
+    Corridors        int    // BFS corridors carved where no link was found
+    Carved           int    // Dead cells turned into Space
+}
+
+// Stitch makes the passable cells of a generated world (the union of the
+// chunks in plan) one connected region. Each round, every component other
+// than the largest casts spurs from its walls; spurs that reach the main
+// component become links (at most one per chunk per round, chosen with
+// SelectLinksOnePerChunk) and are carved straight. Components no spur
+// reaches are joined to the nearest main cell with a BFS corridor.
+// Carving never leaves the union. Cells are modified in place.
+func Stitch(plan []hex.Axial, R int, cells map[hex.Axial]hexcore.HexState, seed int64) StitchReport {
+    rng := rand.New(rand.NewSource(seed))
+    locked := make(map[hex.Axial]bool)
+    comps := Components(cells)
+    rep := StitchReport{ComponentsBefore: len(comps)}
+
+    for len(comps) > 1 {
+        main := comps[0]
+        inMain := make(map[hex.Axial]bool, len(main))
+        for _, a := range main { inMain[a] = true }
+
+        links := stitchLinks(comps[1:], inMain, plan, R, cells, rng)
+        for _, ln := range links {
+            line := hex.Line(ln.From, ln.To)
+            rep.Carved += carve(cells, line, locked)
+            rep.Links = append(rep.Links, ln)
+        }
+        if len(links) == 0 {
+            // no straight link anywhere: fall back to a corridor for the
+            // component closest to the main one
+            corridor := nearestCorridor(comps[1:], inMain, cells, rng)
+            if corridor == nil { break }
+            rep.Carved += carve(cells, corridor, locked)
+            rep.Corridors++
+        }
+        comps = Components(cells)
+    }
+    rep.ComponentsAfter = len(comps)
+    return rep
+}
+
+// Passable reports whether a generator state can be walked on.
+func Passable(st hexcore.HexState) bool { return st == hexcore.Space || st == hexcore.Overlay }
+
+// Components returns the connected passable regions of cells, largest
+// first (ties broken by lowest cell), each sorted by (q, r).
+func Components(cells map[hex.Axial]hexcore.HexState) [][]hex.Axial {
+    keys := make([]hex.Axial, 0, len(cells))
+    for a, st := range cells {
+        if Passable(st) { keys = append(keys, a) }
+    }
+    sortAxials(keys)
+    seen := make(map[hex.Axial]bool, len(keys))
+    var comps [][]hex.Axial
+    for _, a := range keys {
+        if seen[a] { continue }
+        seen[a] = true
+        comp := []hex.Axial{a}
+        for i := 0; i < len(comp); i++ {
+            for _, d := range hex.Directions {
+                b := comp[i].Add(d)
+                if !seen[b] && Passable(cells[b]) {
+                    seen[b] = true
+                    comp = append(comp, b)
+                }
+            }
+        }
+        sortAxials(comp)
+        comps = append(comps, comp)
+    }
+    sort.SliceStable(comps, func(i, j int) bool { return len(comps[i]) > len(comps[j]) })
+    return comps
+}
+
+// stitchLinks finds, for each non-main component, its shortest spur link
+// to the main component, then keeps at most one link per chunk.
+func stitchLinks(others [][]hex.Axial, inMain map[hex.Axial]bool, plan []hex.Axial, R int,
+    cells map[hex.Axial]hexcore.HexState, rng *rand.Rand) []Link {
+    // Rays only see the main component and the walls around it, so they
+    // fly over other components and stop at the first cell they could
+    // open onto the main region.
+    target := make(map[hex.Axial]hexcore.HexState, 2*len(inMain))
+    for a := range inMain {
+        target[a] = hexcore.Space
+        for _, d := range hex.Directions {
+            if b := a.Add(d); !inMain[b] {
+                if _, ok := cells[b]; ok { target[b] = hexcore.Dead }
+            }
+        }
+    }
+
+    var cand []Link
+    for _, comp := range others {
+        var spurs []Spur
+        for _, a := range comp {
+            for dir, d := range hex.Directions {
+                if !Passable(cells[a.Add(d)]) { spurs = append(spurs, Spur{Origin: a, Dir: dir}) }
+            }
+        }
+        links, _ := InternalLinks(spurs, R, target)
+        best, bestLen := Link{}, -1
+        for _, ln := range links {
+            n := hex.DistanceAxial(ln.From, ln.To)
+            if (bestLen < 0 || n < bestLen) && insideUnion(hex.Line(ln.From, ln.To), cells) {
+                best, bestLen = ln, n
+            }
+        }
+        if bestLen >= 0 { cand = append(cand, best) }
+    }
+    return SelectLinksOnePerChunk(cand, plan, R, rng)
+}
+
+// nearestCorridor returns a corridor from the non-main component closest
+// to the main component, found by a breadth-first search over all cells of
+// the union that ignores walls. The corridor is carved with path.BFSPath
+// when its randomized shortest path stays inside the union.
+func nearestCorridor(others [][]hex.Axial, inMain map[hex.Axial]bool,
+    cells map[hex.Axial]hexcore.HexState, rng *rand.Rand) []hex.Axial {
+    prev := make(map[hex.Axial]hex.Axial)
+    var queue []hex.Axial
+    for _, comp := range others {
+        for _, a := range comp {
+            prev[a] = a
+            queue = append(queue, a)
+        }
+    }
+    for i := 0; i < len(queue); i++ {
+        cur := queue[i]
+        for _, d := range hex.Directions {
+            b := cur.Add(d)
+            if _, ok := cells[b]; !ok { continue }
+            if _, seen := prev[b]; seen { continue }
+            prev[b] = cur
+            if !inMain[b] {
+                queue = append(queue, b)
+                continue
+            }
+            // walk back to the component the search started from
+            corridor := []hex.Axial{b}
+            for k := cur; ; k = prev[k] {
+                corridor = append(corridor, k)
+                if prev[k] == k { break }
+            }
+            from := corridor[len(corridor)-1]
+            n := hex.DistanceAxial(from, b)
+            if p := path.BFSPath(from, n, from, b, rng); p != nil && insideUnion(p, cells) {
+                return p
+            }
+            return corridor
+        }
+    }
+    return nil
+}
+
+// carve opens the Dead cells of line and returns how many it changed.
+func carve(cells map[hex.Axial]hexcore.HexState, line []hex.Axial, locked map[hex.Axial]bool) int {
+    n := 0
+    open := make([]hex.Axial, 0, len(line))
+    for _, a := range line {
+        if !Passable(cells[a]) {
+            n++
+            open = append(open, a)
+        }
+    }
+    path.CarvePath(cells, open, locked)
+    return n
+}
+
+func insideUnion(line []hex.Axial, cells map[hex.Axial]hexcore.HexState) bool {
+    for _, a := range line {
+        if _, ok := cells[a]; !ok { return false }
+    }
+    return true
+}
+
+func axialLess(a, b hex.Axial) bool {
+    if a.Q != b.Q { return a.Q < b.Q }
+    return a.R < b.R
+}
+
+func sortAxials(s []hex.Axial) { sort.Slice(s, func(i, j int) bool { return axialLess(s[i], s[j]) }) }
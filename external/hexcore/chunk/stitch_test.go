@@ -0,0 +1,101 @@
+package chunk
+
+import (
+    "reflect"
+    "testing"
+
+    "github.com/gravitas-015/hexcore"
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+const stitchRadius = 6
+
+// stitchWorld returns a plan of two neighboring chunks whose union is Dead
+// except for small open rooms of radius 1 around rooms.
+func stitchWorld(rooms ...hex.Axial) ([]hex.Axial, map[hex.Axial]hexcore.HexState) {
+    plan := []hex.Axial{{}, hex.NeighborChunkCenter(hex.Axial{}, stitchRadius, 0)}
+    cells := make(map[hex.Axial]hexcore.HexState)
+    for _, c := range plan {
+        for _, a := range hex.Disk(c, stitchRadius) { cells[a] = hexcore.Dead }
+    }
+    for _, r := range rooms {
+        for _, a := range hex.Disk(r, 1) { cells[a] = hexcore.Space }
+    }
+    return plan, cells
+}
+
+// separateRooms returns room centers spread over both chunks, with walls
+// between every room.
+func separateRooms() []hex.Axial {
+    far := hex.NeighborChunkCenter(hex.Axial{}, stitchRadius, 0)
+    return []hex.Axial{{Q: -3, R: 0}, {Q: 2, R: -2}, {Q: 0, R: 3}, far, far.Add(hex.Axial{Q: 1, R: -4})}
+}
+
+func copyCells(cells map[hex.Axial]hexcore.HexState) map[hex.Axial]hexcore.HexState {
+    out := make(map[hex.Axial]hexcore.HexState, len(cells))
+    for a, st := range cells { out[a] = st }
+    return out
+}
+
+func TestStitchConnects(t *testing.T) {
+    plan, cells := stitchWorld(separateRooms()...)
+    before := len(Components(cells))
+    if before != len(separateRooms()) {
+        t.Fatalf("fixture has %d components, want %d", before, len(separateRooms()))
+    }
+
+    rep := Stitch(plan, stitchRadius, cells, 1)
+    if rep.ComponentsBefore != before {
+        t.Errorf("ComponentsBefore = %d, want %d", rep.ComponentsBefore, before)
+    }
+    if rep.ComponentsAfter != 1 || len(Components(cells)) != 1 {
+        t.Errorf("expected one component after stitching, report %d, actual %d", rep.ComponentsAfter, len(Components(cells)))
+    }
+    if rep.Carved == 0 || len(rep.Links)+rep.Corridors == 0 {
+        t.Errorf("nothing carved: %+v", rep)
+    }
+}
+
+func TestStitchDeterministic(t *testing.T) {
+    plan, a := stitchWorld(separateRooms()...)
+    _, b := stitchWorld(separateRooms()...)
+    repA := Stitch(plan, stitchRadius, a, 42)
+    repB := Stitch(plan, stitchRadius, b, 42)
+    if !reflect.DeepEqual(repA, repB) {
+        t.Errorf("same seed gave different reports:\n%+v\n%+v", repA, repB)
+    }
+    if !reflect.DeepEqual(a, b) {
+        t.Error("same seed carved different cells")
+    }
+}
+
+func TestStitchStaysInUnion(t *testing.T) {
+    for seed := int64(0); seed < 10; seed++ {
+        plan, cells := stitchWorld(separateRooms()...)
+        union := copyCells(cells)
+        Stitch(plan, stitchRadius, cells, seed)
+        if len(cells) != len(union) {
+            t.Fatalf("seed %d: stitching added %d cells outside the union", seed, len(cells)-len(union))
+        }
+        for a := range cells {
+            if _, ok := union[a]; !ok {
+                t.Fatalf("seed %d: carved %v outside the union", seed, a)
+            }
+            if _, ok := ChunkOf(a, plan, stitchRadius); !ok {
+                t.Fatalf("seed %d: %v is in no chunk of the plan", seed, a)
+            }
+        }
+    }
+}
+
+func TestStitchConnectedUnchanged(t *testing.T) {
+    plan, cells := stitchWorld(hex.Axial{Q: 1, R: -1})
+    orig := copyCells(cells)
+    rep := Stitch(plan, stitchRadius, cells, 7)
+    if rep.ComponentsBefore != 1 || rep.ComponentsAfter != 1 || rep.Carved != 0 || len(rep.Links) != 0 || rep.Corridors != 0 {
+        t.Errorf("connected input should need no work: %+v", rep)
+    }
+    if !reflect.DeepEqual(cells, orig) {
+        t.Error("connected input was modified")
+    }
+}
@@ -0,0 +1,149 @@
+// Package fov computes field of view and line of sight on hex grids.
+//
+// Visibility is decided by ray casting from hex center to hex center. A ray
+// that runs exactly along an edge or through a corner is cast twice, nudged
+// to either side, and the target is visible if either ray is clear. This
+// makes the result symmetric (a sees b iff b sees a) and permissive at
+// corners: a gap between two walls that only touch at a corner is only
+// closed if both walls are present.
+package fov
+
+import (
+    "sync"
+
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+// Opaque reports whether a hex blocks sight. Opaque hexes are themselves
+// visible, but nothing behind them is.
+type Opaque func(a hex.Axial) bool
+
+// Compute returns the set of hexes visible from origin within radius.
+// The origin is always visible.
+func Compute(origin hex.Axial, radius int, opaque Opaque) map[hex.Axial]bool {
+    vis := make(map[hex.Axial]bool, 1+3*radius*(radius+1))
+    ComputeFunc(origin, radius, opaque, func(a hex.Axial) { vis[a] = true })
+    return vis
+}
+
+// ComputeFunc calls visit once for every hex visible from origin within
+// radius, in ring order outward. Use it instead of Compute to accumulate
+// vision from many observers into one structure without allocating a set
+// per observer.
+func ComputeFunc(origin hex.Axial, radius int, opaque Opaque, visit func(a hex.Axial)) {
+    if radius < 0 { return }
+    t := tableFor(radius)
+    visit(origin)
+    for i := 1; i < len(t.targets); i++ {
+        if t.clear(i, origin, opaque) {
+            visit(origin.Add(t.targets[i]))
+        }
+    }
+}
+
+// HasLineOfSight reports whether b can be seen from a, ignoring range. The
+// end points themselves may be opaque. It is symmetric in a and b.
+func HasLineOfSight(a, b hex.Axial, opaque Opaque) bool {
+    n := hex.DistanceAxial(a, b)
+    if n <= 1 { return true }
+    if clearRay(a, b, n, nudge, opaque) { return true }
+    return clearRay(a, b, n, hex.FracAxial{Q: -nudge.Q, R: -nudge.R}, opaque)
+}
+
+// nudge moves rays off hex edges and corners. It is applied with both signs
+// so that ties are resolved in favour of the viewer.
+var nudge = hex.FracAxial{Q: 1e-6, R: 2e-6}
+
+// clearRay walks the intermediate hexes of the ray from a to b shifted by
+// off, stopping at the first opaque one.
+func clearRay(a, b hex.Axial, n int, off hex.FracAxial, opaque Opaque) bool {
+    fa := hex.FracAxial{Q: float64(a.Q) + off.Q, R: float64(a.R) + off.R}
+    fb := hex.FracAxial{Q: float64(b.Q) + off.Q, R: float64(b.R) + off.R}
+    step := 1.0 / float64(n)
+    for i := 1; i < n; i++ {
+        if opaque(hex.Lerp(fa, fb, step*float64(i)).Round()) { return false }
+    }
+    return true
+}
+
+// table holds the precomputed rays from the origin to every hex within a
+// radius, as offsets relative to the origin. Each target has one or two
+// rays (two when the nudged rays differ); rays store only the hexes
+// strictly between origin and target.
+type table struct {
+    targets []hex.Axial
+    rays    [][2][]hex.Axial
+}
+
+var (
+    tablesMu sync.RWMutex
+    tables   = map[int]*table{}
+)
+
+// tableFor returns the ray table for radius, building it on first use.
+func tableFor(radius int) *table {
+    tablesMu.RLock()
+    t := tables[radius]
+    tablesMu.RUnlock()
+    if t != nil { return t }
+
+    t = buildTable(radius)
+    tablesMu.Lock()
+    if old := tables[radius]; old != nil {
+        t = old
+    } else {
+        tables[radius] = t
+    }
+    tablesMu.Unlock()
+    return t
+}
+
+func buildTable(radius int) *table {
+    o := hex.Axial{}
+    targets := hex.Spiral(o, radius)
+    t := &table{targets: targets, rays: make([][2][]hex.Axial, len(targets))}
+    neg := hex.FracAxial{Q: -nudge.Q, R: -nudge.R}
+    for i, b := range targets {
+        n := hex.DistanceAxial(o, b)
+        if n <= 1 { continue }
+        pos := rayCells(o, b, n, nudge)
+        t.rays[i][0] = pos
+        if alt := rayCells(o, b, n, neg); !sameCells(pos, alt) {
+            t.rays[i][1] = alt
+        }
+    }
+    return t
+}
+
+func rayCells(a, b hex.Axial, n int, off hex.FracAxial) []hex.Axial {
+    fa := hex.FracAxial{Q: float64(a.Q) + off.Q, R: float64(a.R) + off.R}
+    fb := hex.FracAxial{Q: float64(b.Q) + off.Q, R: float64(b.R) + off.R}
+    step := 1.0 / float64(n)
+    cells := make([]hex.Axial, 0, n-1)
+    for i := 1; i < n; i++ {
+        cells = append(cells, hex.Lerp(fa, fb, step*float64(i)).Round())
+    }
+    return cells
+}
+
+func sameCells(a, b []hex.Axial) bool {
+    if len(a) != len(b) { return false }
+    for i := range a {
+        if a[i] != b[i] { return false }
+    }
+    return true
+}
+
+// clear reports whether target i is visible from origin.
+func (t *table) clear(i int, origin hex.Axial, opaque Opaque) bool {
+    rays := t.rays[i]
+    if unblocked(rays[0], origin, opaque) { return true }
+    return rays[1] != nil && unblocked(rays[1], origin, opaque)
+}
+
+func unblocked(cells []hex.Axial, origin hex.Axial, opaque Opaque) bool {
+    for _, c := range cells {
+        if opaque(origin.Add(c)) { return false }
+    }
+    return true
+}
@@ -0,0 +1,96 @@
+package fov
+
+import (
+    "math/rand"
+    "testing"
+
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+func walls(cells ...hex.Axial) Opaque {
+    set := map[hex.Axial]bool{}
+    for _, c := range cells { set[c] = true }
+    return func(a hex.Axial) bool { return set[a] }
+}
+
+func TestOpenField(t *testing.T) {
+    o := hex.Axial{Q: 3, R: -2}
+    for r := 0; r <= 6; r++ {
+        vis := Compute(o, r, walls())
+        if len(vis) != 1+3*r*(r+1) {
+            t.Errorf("radius %d: %d visible, want %d", r, len(vis), 1+3*r*(r+1))
+        }
+        for a := range vis {
+            if hex.DistanceAxial(o, a) > r {
+                t.Errorf("radius %d: %v out of range", r, a)
+            }
+        }
+    }
+}
+
+func TestVisibility(t *testing.T) {
+    o := hex.Axial{}
+    tests := []struct {
+        name   string
+        walls  []hex.Axial
+        target hex.Axial
+        want   bool
+    }{
+        {"wall itself is visible", []hex.Axial{{Q: 1, R: 0}}, hex.Axial{Q: 1, R: 0}, true},
+        {"behind wall", []hex.Axial{{Q: 1, R: 0}}, hex.Axial{Q: 2, R: 0}, false},
+        {"far behind wall", []hex.Axial{{Q: 1, R: 0}}, hex.Axial{Q: 4, R: 0}, false},
+        {"beside wall", []hex.Axial{{Q: 1, R: 0}}, hex.Axial{Q: 2, R: -2}, true},
+        // (2,-1) is reached through the corner between (1,0) and (1,-1)
+        {"corner, one side blocked", []hex.Axial{{Q: 1, R: 0}}, hex.Axial{Q: 2, R: -1}, true},
+        {"corner, other side blocked", []hex.Axial{{Q: 1, R: -1}}, hex.Axial{Q: 2, R: -1}, true},
+        {"corner, both sides blocked", []hex.Axial{{Q: 1, R: 0}, {Q: 1, R: -1}}, hex.Axial{Q: 2, R: -1}, false},
+        {"wall past target", []hex.Axial{{Q: 3, R: 0}}, hex.Axial{Q: 2, R: 0}, true},
+    }
+    for _, tt := range tests {
+        op := walls(tt.walls...)
+        if got := Compute(o, 5, op)[tt.target]; got != tt.want {
+            t.Errorf("%s: Compute visible=%v, want %v", tt.name, got, tt.want)
+        }
+        if got := HasLineOfSight(o, tt.target, op); got != tt.want {
+            t.Errorf("%s: HasLineOfSight=%v, want %v", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestSymmetricAndConsistent(t *testing.T) {
+    rng := rand.New(rand.NewSource(7))
+    set := map[hex.Axial]bool{}
+    for _, a := range hex.Disk(hex.Axial{}, 10) {
+        if rng.Intn(4) == 0 { set[a] = true }
+    }
+    op := func(a hex.Axial) bool { return set[a] }
+
+    for i := 0; i < 30; i++ {
+        o := hex.Disk(hex.Axial{}, 6)[rng.Intn(127)]
+        vis := Compute(o, 4, op)
+        for _, b := range hex.Disk(o, 4) {
+            los := HasLineOfSight(o, b, op)
+            if los != vis[b] {
+                t.Fatalf("Compute and HasLineOfSight disagree for %v -> %v", o, b)
+            }
+            if los != HasLineOfSight(b, o, op) {
+                t.Fatalf("line of sight not symmetric for %v <-> %v", o, b)
+            }
+        }
+    }
+}
+
+func BenchmarkComputeFunc(b *testing.B) {
+    rng := rand.New(rand.NewSource(1))
+    set := map[hex.Axial]bool{}
+    for _, a := range hex.Disk(hex.Axial{}, 40) {
+        if rng.Intn(6) == 0 { set[a] = true }
+    }
+    op := func(a hex.Axial) bool { return set[a] }
+    origins := hex.Disk(hex.Axial{}, 30)
+    seen := map[hex.Axial]bool{}
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        ComputeFunc(origins[i%len(origins)], 8, op, func(a hex.Axial) { seen[a] = true })
+    }
+}
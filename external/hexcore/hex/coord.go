@@ -20,9 +20,27 @@ var Directions = []Axial{
     {+1, 0}, {+1, -1}, {0, -1}, {-1, 0}, {-1, +1}, {0, +1},
 }
 
+// Diagonals for axial diagonal neighbors (distance 2). Diagonals[i] lies
+// between Directions[i] and Directions[i+1].
+var Diagonals = []Axial{
+    {+2, -1}, {+1, -2}, {-1, -1}, {-2, +1}, {-1, +2}, {+1, +1},
+}
+
 // Add returns a+b in axial space.
 func (a Axial) Add(b Axial) Axial { return Axial{a.Q + b.Q, a.R + b.R} }
 
+// Subtract returns a-b in axial space.
+func (a Axial) Subtract(b Axial) Axial { return Axial{a.Q - b.Q, a.R - b.R} }
+
+// S returns the implicit third cube coordinate (-q-r).
+func (a Axial) S() int { return -a.Q - a.R }
+
+// Neighbor returns the adjacent hex in direction dir (0..5, wraps).
+func (a Axial) Neighbor(dir int) Axial { return a.Add(Directions[mod6(dir)]) }
+
+// Diagonal returns the diagonal neighbor in direction dir (0..5, wraps).
+func (a Axial) Diagonal(dir int) Axial { return a.Add(Diagonals[mod6(dir)]) }
+
 // Mul scales an axial vector by k.
 func (a Axial) Mul(k int) Axial { return Axial{a.Q * k, a.R * k} }
 
@@ -56,6 +74,9 @@ func DistanceCube(a, b Cube) int {
     return dz
 }
 
+// mod6 maps any direction index onto 0..5.
+func mod6(d int) int { return ((d % 6) + 6) % 6 }
+
 // AxialToPixel converts axial to pixel coordinates for pointy-top layout.
 // size is the hex radius (corner to center) in pixels.
 func AxialToPixel(a Axial, size float64) (x, y float64) {
@@ -64,3 +85,9 @@ func AxialToPixel(a Axial, size float64) (x, y float64) {
     y = size * 1.5 * float64(a.R)
     return
 }
+
+// PixelToAxial is the inverse of AxialToPixel: it returns the pointy-top hex
+// containing pixel (x, y), rounding through cube coordinates.
+func PixelToAxial(x, y, size float64) Axial {
+    return PointyLayout(size).PixelToFrac(x, y).Round()
+}
@@ -0,0 +1,61 @@
+package hex
+
+import "math"
+
+// FracAxial is an axial coordinate with fractional components, produced by
+// pixel conversion and interpolation. Round snaps it to the containing hex.
+type FracAxial struct {
+    Q float64
+    R float64
+}
+
+// Frac converts an integer axial coordinate to fractional form.
+func (a Axial) Frac() FracAxial { return FracAxial{Q: float64(a.Q), R: float64(a.R)} }
+
+// Round returns the hex containing f using cube rounding: round all three
+// cube components and fix up the one with the largest rounding error so
+// that x+y+z=0 still holds.
+func (f FracAxial) Round() Axial {
+    x, z := f.Q, f.R
+    y := -x - z
+    rx, ry, rz := math.Round(x), math.Round(y), math.Round(z)
+    dx, dy, dz := math.Abs(rx-x), math.Abs(ry-y), math.Abs(rz-z)
+    if dx > dy && dx > dz {
+        rx = -ry - rz
+    } else if dy > dz {
+        ry = -rx - rz
+    } else {
+        rz = -rx - ry
+    }
+    return Axial{Q: int(rx), R: int(rz)}
+}
+
+// Lerp linearly interpolates between a and b (t=0 gives a, t=1 gives b).
+func Lerp(a, b FracAxial, t float64) FracAxial {
+    return FracAxial{
+        Q: a.Q + (b.Q-a.Q)*t,
+        R: a.R + (b.R-a.R)*t,
+    }
+}
+
+// lineNudge offsets line samples off hex edges and corners so that ties
+// round consistently (always to the same side) instead of flickering.
+var lineNudge = FracAxial{Q: 1e-6, R: 2e-6}
+
+// Line returns the hexes on the straight line from a to b, inclusive of both
+// ends. Consecutive hexes are always adjacent and the result has
+// DistanceAxial(a, b)+1 entries.
+func Line(a, b Axial) []Axial {
+    n := DistanceAxial(a, b)
+    res := make([]Axial, 0, n+1)
+    if n == 0 {
+        return append(res, a)
+    }
+    fa := FracAxial{Q: float64(a.Q) + lineNudge.Q, R: float64(a.R) + lineNudge.R}
+    fb := FracAxial{Q: float64(b.Q) + lineNudge.Q, R: float64(b.R) + lineNudge.R}
+    step := 1.0 / float64(n)
+    for i := 0; i <= n; i++ {
+        res = append(res, Lerp(fa, fb, step*float64(i)).Round())
+    }
+    return res
+}
@@ -1,5 +1,7 @@
 package hex
 
+import "math"
+
 // Ring returns the axial coordinates at exact distance k from center c,
 // starting from direction 4 (south-east) and proceeding counter-clockwise.
 // If k==0, returns [c].
@@ -32,6 +34,16 @@ func Disk(c Axial, r int) []Axial {
     return res
 }
 
+// Spiral returns all axial coordinates at distance <= r from center c,
+// ordered ring by ring outward: c first, then Ring(c, 1), Ring(c, 2), ...
+func Spiral(c Axial, r int) []Axial {
+    res := make([]Axial, 0, 1+3*r*(r+1))
+    for k := 0; k <= r; k++ {
+        res = append(res, Ring(c, k)...)
+    }
+    return res
+}
+
 // Edge returns the R axial coordinates on the ring at distance R belonging
 // to the specified side (0..5). The order is along the side.
 func Edge(c Axial, R int, side int) []Axial {
@@ -72,5 +84,38 @@ func Edge(c Axial, R int, side int) []Axial {
     return seg
 }
 
+// NeighborChunkCenter returns the center of the chunk of radius R adjacent
+// to the chunk at center on the given side (0..5). Chunk centers are 2R
+// apart along diagonals, so neighboring chunks share one line of R+1 border
+// cells.
+func NeighborChunkCenter(center Axial, R int, side int) Axial {
+    d := Directions[mod6(side)]
+    dprev := Directions[mod6(side-1)]
+    return center.Add(d.Add(dprev).Mul(R))
+}
+
+// ChunkCenterOf returns the center of the chunk of radius R (on the
+// NeighborChunkCenter lattice through the origin) that a belongs to. Cells
+// on a shared border go to the nearest center, ties to the smallest (q, r).
+func ChunkCenterOf(a Axial, R int) Axial {
+    if R <= 0 { return a }
+    // Lattice basis u = (R, R), v = (2R, -R): solve a = i*u + j*v.
+    fi := float64(a.Q+2*a.R) / float64(3*R)
+    fj := float64(a.Q-a.R) / float64(3*R)
+    i0, j0 := int(math.Floor(fi)), int(math.Floor(fj))
+    best, bestD := Axial{}, -1
+    for di := -1; di <= 2; di++ {
+        for dj := -1; dj <= 2; dj++ {
+            i, j := i0+di, j0+dj
+            c := Axial{Q: (i + 2*j) * R, R: (i - j) * R}
+            d := DistanceAxial(a, c)
+            if bestD < 0 || d < bestD || (d == bestD && (c.Q < best.Q || (c.Q == best.Q && c.R < best.R))) {
+                best, bestD = c, d
+            }
+        }
+    }
+    return best
+}
+
 func min(a, b int) int { if a < b { return a }; return b }
 func max(a, b int) int { if a > b { return a }; return b }
@@ -0,0 +1,296 @@
+package hex
+
+import (
+    "math"
+    "testing"
+)
+
+const eps = 1e-9
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestNeighborsAndDiagonals(t *testing.T) {
+    o := Axial{0, 0}
+    tests := []struct {
+        name string
+        got  Axial
+        want Axial
+    }{
+        {"subtract", Axial{3, -1}.Subtract(Axial{1, 2}), Axial{2, -3}},
+        {"neighbor 0", o.Neighbor(0), Axial{1, 0}},
+        {"neighbor 3", o.Neighbor(3), Axial{-1, 0}},
+        {"neighbor wraps +", o.Neighbor(7), Axial{1, -1}},
+        {"neighbor wraps -", o.Neighbor(-1), Axial{0, 1}},
+        {"diagonal 0", o.Diagonal(0), Axial{2, -1}},
+        {"diagonal 2", o.Diagonal(2), Axial{-1, -1}},
+        {"diagonal 5", Axial{1, 1}.Diagonal(5), Axial{2, 2}},
+    }
+    for _, tt := range tests {
+        if tt.got != tt.want {
+            t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+        }
+    }
+    for i, d := range Diagonals {
+        if DistanceAxial(o, d) != 2 {
+            t.Errorf("diagonal %d at distance %d", i, DistanceAxial(o, d))
+        }
+        if d != Directions[i].Add(Directions[(i+1)%6]) {
+            t.Errorf("diagonal %d not between directions %d and %d", i, i, (i+1)%6)
+        }
+    }
+    if (Axial{2, -5}).S() != 3 {
+        t.Errorf("S() wrong")
+    }
+}
+
+func TestRotate(t *testing.T) {
+    o := Axial{0, 0}
+    tests := []struct {
+        a, center Axial
+        k         int
+        want      Axial
+    }{
+        {Axial{1, 0}, o, 0, Axial{1, 0}},
+        {Axial{1, 0}, o, 1, Axial{1, -1}},
+        {Axial{1, 0}, o, 2, Axial{0, -1}},
+        {Axial{1, 0}, o, 3, Axial{-1, 0}},
+        {Axial{1, 0}, o, -1, Axial{0, 1}},
+        {Axial{1, 0}, o, 6, Axial{1, 0}},
+        {Axial{2, -1}, o, 1, Axial{1, -2}},
+        {Axial{3, 2}, Axial{2, 2}, 3, Axial{1, 2}},
+        {Axial{4, 1}, Axial{2, 2}, 1, Axial{3, 0}},
+    }
+    for _, tt := range tests {
+        if got := Rotate(tt.a, tt.center, tt.k); got != tt.want {
+            t.Errorf("Rotate(%v, %v, %d) = %v, want %v", tt.a, tt.center, tt.k, got, tt.want)
+        }
+    }
+    // Directions[i] rotates onto Directions[i+k]
+    for i, d := range Directions {
+        for k := -6; k <= 6; k++ {
+            if got := Rotate(d, o, k); got != Directions[mod6(i+k)] {
+                t.Errorf("direction %d rotated %d: got %v", i, k, got)
+            }
+        }
+    }
+}
+
+func TestReflect(t *testing.T) {
+    o := Axial{0, 0}
+    c := Axial{1, 1}
+    tests := []struct {
+        name string
+        got  Axial
+        want Axial
+    }{
+        {"q", ReflectQ(Axial{1, 2}, o), Axial{1, -3}},
+        {"r", ReflectR(Axial{1, 2}, o), Axial{-3, 2}},
+        {"s", ReflectS(Axial{1, 2}, o), Axial{2, 1}},
+        {"q centered", ReflectQ(Axial{2, 2}, c), Axial{2, -1}},
+        {"s centered", ReflectS(Axial{2, 3}, c), Axial{3, 2}},
+    }
+    for _, tt := range tests {
+        if tt.got != tt.want {
+            t.Errorf("reflect %s: got %v, want %v", tt.name, tt.got, tt.want)
+        }
+    }
+    // Reflections are involutions and preserve distance to the center
+    for _, a := range Disk(c, 3) {
+        for _, f := range []func(a, c Axial) Axial{ReflectQ, ReflectR, ReflectS} {
+            b := f(a, c)
+            if f(b, c) != a || DistanceAxial(a, c) != DistanceAxial(b, c) {
+                t.Fatalf("reflection of %v not an isometric involution", a)
+            }
+        }
+    }
+}
+
+func TestRound(t *testing.T) {
+    tests := []struct {
+        f    FracAxial
+        want Axial
+    }{
+        {FracAxial{0, 0}, Axial{0, 0}},
+        {FracAxial{0.2, 0.1}, Axial{0, 0}},
+        {FracAxial{0.9, -0.1}, Axial{1, 0}},
+        {FracAxial{1.6, -0.6}, Axial{2, -1}},
+        {FracAxial{-2.1, 1.2}, Axial{-2, 1}},
+        {FracAxial{0.4, 0.4}, Axial{0, 1}},
+    }
+    for _, tt := range tests {
+        if got := tt.f.Round(); got != tt.want {
+            t.Errorf("Round(%v) = %v, want %v", tt.f, got, tt.want)
+        }
+    }
+}
+
+func TestLine(t *testing.T) {
+    tests := []struct {
+        a, b Axial
+        want []Axial
+    }{
+        {Axial{0, 0}, Axial{0, 0}, []Axial{{0, 0}}},
+        {Axial{0, 0}, Axial{3, 0}, []Axial{{0, 0}, {1, 0}, {2, 0}, {3, 0}}},
+        {Axial{0, 0}, Axial{0, -2}, []Axial{{0, 0}, {0, -1}, {0, -2}}},
+        // Along a diagonal the line passes exactly through corners; the
+        // nudge resolves them to the same side every time
+        {Axial{0, 0}, Axial{2, -1}, []Axial{{0, 0}, {1, 0}, {2, -1}}},
+        {Axial{0, 0}, Axial{-2, 1}, []Axial{{0, 0}, {-1, 1}, {-2, 1}}},
+    }
+    for _, tt := range tests {
+        got := Line(tt.a, tt.b)
+        if len(got) != len(tt.want) {
+            t.Errorf("Line(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+            continue
+        }
+        for i := range got {
+            if got[i] != tt.want[i] {
+                t.Errorf("Line(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+                break
+            }
+        }
+    }
+
+    // Every line is contiguous and has distance+1 cells
+    for _, b := range Disk(Axial{0, 0}, 6) {
+        a := Axial{1, -2}
+        line := Line(a, b)
+        if len(line) != DistanceAxial(a, b)+1 || line[0] != a || line[len(line)-1] != b {
+            t.Fatalf("Line(%v, %v) has wrong ends or length: %v", a, b, line)
+        }
+        for i := 1; i < len(line); i++ {
+            if DistanceAxial(line[i-1], line[i]) != 1 {
+                t.Fatalf("Line(%v, %v) not contiguous: %v", a, b, line)
+            }
+        }
+    }
+}
+
+func TestSpiral(t *testing.T) {
+    c := Axial{2, -1}
+    sp := Spiral(c, 3)
+    if len(sp) != 37 {
+        t.Fatalf("expected 37 cells, got %d", len(sp))
+    }
+    if sp[0] != c {
+        t.Errorf("spiral should start at center, got %v", sp[0])
+    }
+    seen := map[Axial]bool{}
+    prev := 0
+    for _, a := range sp {
+        d := DistanceAxial(c, a)
+        if d < prev || d > 3 || seen[a] {
+            t.Fatalf("spiral out of order or duplicated at %v", a)
+        }
+        prev = d
+        seen[a] = true
+    }
+    for _, a := range Disk(c, 3) {
+        if !seen[a] {
+            t.Errorf("spiral missing %v", a)
+        }
+    }
+}
+
+func TestPixelConversion(t *testing.T) {
+    x, y := AxialToPixel(Axial{1, 0}, 10)
+    if !near(x, 10*math.Sqrt(3)) || !near(y, 0) {
+        t.Errorf("AxialToPixel(1,0) = (%v, %v)", x, y)
+    }
+    x, y = AxialToPixel(Axial{0, 2}, 10)
+    if !near(x, 10*math.Sqrt(3)) || !near(y, 30) {
+        t.Errorf("AxialToPixel(0,2) = (%v, %v)", x, y)
+    }
+
+    p := FlatLayout(10).ToPixel(Axial{1, 0})
+    if !near(p.X, 15) || !near(p.Y, 5*math.Sqrt(3)) {
+        t.Errorf("flat ToPixel(1,0) = %v", p)
+    }
+
+    if got := PixelToAxial(17.3, 0.5, 10); got != (Axial{1, 0}) {
+        t.Errorf("PixelToAxial near (1,0) = %v", got)
+    }
+
+    // Centers and points just inside each corner pick their own hex
+    layouts := map[string]Layout{
+        "pointy": PointyLayout(10),
+        "flat":   FlatLayout(10),
+        "offset": {Orientation: PointyTop, Size: Point{8, 12}, Origin: Point{100, -50}},
+    }
+    for name, l := range layouts {
+        for _, a := range Disk(Axial{0, 0}, 5) {
+            c := l.ToPixel(a)
+            if got := l.PixelToAxial(c.X, c.Y); got != a {
+                t.Fatalf("%s: center of %v picked %v", name, a, got)
+            }
+            for i, corner := range l.Corners(a) {
+                px := c.X + 0.95*(corner.X-c.X)
+                py := c.Y + 0.95*(corner.Y-c.Y)
+                if got := l.PixelToAxial(px, py); got != a {
+                    t.Fatalf("%s: corner %d of %v picked %v", name, i, a, got)
+                }
+            }
+        }
+    }
+}
+
+func TestCorners(t *testing.T) {
+    tests := []struct {
+        name  string
+        l     Layout
+        first Point
+    }{
+        {"pointy", PointyLayout(1), Point{math.Sqrt(3) / 2, 0.5}},
+        {"flat", FlatLayout(1), Point{1, 0}},
+    }
+    for _, tt := range tests {
+        corners := tt.l.Corners(Axial{0, 0})
+        if !near(corners[0].X, tt.first.X) || !near(corners[0].Y, tt.first.Y) {
+            t.Errorf("%s: first corner %v, want %v", tt.name, corners[0], tt.first)
+        }
+        for i, c := range corners {
+            if r := math.Hypot(c.X, c.Y); math.Abs(r-1) > eps {
+                t.Errorf("%s: corner %d at radius %v", tt.name, i, r)
+            }
+        }
+    }
+
+    // Neighboring hexes share two corners
+    l := PointyLayout(10)
+    shared := 0
+    for _, a := range l.Corners(Axial{0, 0}) {
+        for _, b := range l.Corners(Axial{1, 0}) {
+            if near(a.X, b.X) && near(a.Y, b.Y) {
+                shared++
+            }
+        }
+    }
+    if shared != 2 {
+        t.Errorf("expected neighbors to share 2 corners, got %d", shared)
+    }
+}
+
+func TestChunkCenterOf(t *testing.T) {
+    for _, R := range []int{1, 3, 9} {
+        centers := map[Axial]bool{{}: true}
+        for s := 0; s < 6; s++ { centers[NeighborChunkCenter(Axial{}, R, s)] = true }
+        for c := range centers {
+            if got := ChunkCenterOf(c, R); got != c {
+                t.Errorf("R=%d: center %v maps to %v", R, c, got)
+            }
+        }
+        for _, a := range Disk(Axial{}, 3*R) {
+            c := ChunkCenterOf(a, R)
+            if DistanceAxial(a, c) > R {
+                t.Fatalf("R=%d: %v assigned to %v at distance %d", R, a, c, DistanceAxial(a, c))
+            }
+            // the center lies on the lattice: it is its own center
+            if ChunkCenterOf(c, R) != c {
+                t.Fatalf("R=%d: %v is not a lattice point", R, c)
+            }
+            if DistanceAxial(a, Axial{}) < R && c != (Axial{}) {
+                t.Fatalf("R=%d: interior cell %v assigned to %v", R, a, c)
+            }
+        }
+    }
+}
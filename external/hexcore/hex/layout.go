@@ -0,0 +1,97 @@
+package hex
+
+import "math"
+
+// Orientation holds the axial<->pixel matrices for a hex orientation.
+type Orientation struct {
+    F0, F1, F2, F3 float64 // forward: axial -> pixel
+    B0, B1, B2, B3 float64 // backward: pixel -> axial
+    StartAngle     float64 // first corner angle, in multiples of 60 degrees
+}
+
+var sqrt3 = math.Sqrt(3)
+
+// PointyTop is the orientation used by AxialToPixel: hexes have a vertex at
+// the top and rows of constant r are horizontal.
+var PointyTop = Orientation{
+    F0: sqrt3, F1: sqrt3 / 2, F2: 0, F3: 1.5,
+    B0: sqrt3 / 3, B1: -1.0 / 3, B2: 0, B3: 2.0 / 3,
+    StartAngle: 0.5,
+}
+
+// FlatTop has a flat edge at the top and columns of constant q are vertical.
+var FlatTop = Orientation{
+    F0: 1.5, F1: 0, F2: sqrt3 / 2, F3: sqrt3,
+    B0: 2.0 / 3, B1: 0, B2: -1.0 / 3, B3: sqrt3 / 3,
+    StartAngle: 0,
+}
+
+// Point is a position in pixel space.
+type Point struct {
+    X float64
+    Y float64
+}
+
+// Layout maps hexes to pixels. Size is the corner-to-center radius per axis
+// (equal X and Y for regular hexes); Origin is the pixel position of hex
+// (0,0).
+type Layout struct {
+    Orientation Orientation
+    Size        Point
+    Origin      Point
+}
+
+// PointyLayout returns a pointy-top layout of regular hexes at the origin.
+func PointyLayout(size float64) Layout {
+    return Layout{Orientation: PointyTop, Size: Point{size, size}}
+}
+
+// FlatLayout returns a flat-top layout of regular hexes at the origin.
+func FlatLayout(size float64) Layout {
+    return Layout{Orientation: FlatTop, Size: Point{size, size}}
+}
+
+// ToPixel returns the pixel position of the center of a.
+func (l Layout) ToPixel(a Axial) Point {
+    o := l.Orientation
+    q, r := float64(a.Q), float64(a.R)
+    return Point{
+        X: (o.F0*q+o.F1*r)*l.Size.X + l.Origin.X,
+        Y: (o.F2*q+o.F3*r)*l.Size.Y + l.Origin.Y,
+    }
+}
+
+// PixelToFrac returns the fractional axial coordinate at pixel (x, y).
+func (l Layout) PixelToFrac(x, y float64) FracAxial {
+    o := l.Orientation
+    px := (x - l.Origin.X) / l.Size.X
+    py := (y - l.Origin.Y) / l.Size.Y
+    return FracAxial{
+        Q: o.B0*px + o.B1*py,
+        R: o.B2*px + o.B3*py,
+    }
+}
+
+// PixelToAxial returns the hex containing pixel (x, y), e.g. for picking
+// the hex under a mouse click.
+func (l Layout) PixelToAxial(x, y float64) Axial {
+    return l.PixelToFrac(x, y).Round()
+}
+
+// cornerOffset returns the offset of corner i from a hex center.
+func (l Layout) cornerOffset(i int) Point {
+    angle := 2 * math.Pi * (l.Orientation.StartAngle + float64(i)) / 6
+    return Point{X: l.Size.X * math.Cos(angle), Y: l.Size.Y * math.Sin(angle)}
+}
+
+// Corners returns the six polygon corners of a in pixel space, in order of
+// increasing angle.
+func (l Layout) Corners(a Axial) [6]Point {
+    var res [6]Point
+    c := l.ToPixel(a)
+    for i := 0; i < 6; i++ {
+        off := l.cornerOffset(i)
+        res[i] = Point{X: c.X + off.X, Y: c.Y + off.Y}
+    }
+    return res
+}
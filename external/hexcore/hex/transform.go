@@ -0,0 +1,33 @@
+package hex
+
+// Rotate rotates a around center by k steps of 60 degrees. Positive k turns
+// in the order of Directions, so Directions[i] maps to Directions[i+k].
+func Rotate(a, center Axial, k int) Axial {
+    v := a.Subtract(center).ToCube()
+    for i := 0; i < mod6(k); i++ {
+        // one step: (x, y, z) -> (-y, -z, -x)
+        v = Cube{X: -v.Y, Y: -v.Z, Z: -v.X}
+    }
+    return center.Add(v.ToAxial())
+}
+
+// ReflectQ mirrors a across the line through center where q is constant
+// (swaps the r and s components).
+func ReflectQ(a, center Axial) Axial {
+    v := a.Subtract(center)
+    return center.Add(Axial{Q: v.Q, R: v.S()})
+}
+
+// ReflectR mirrors a across the line through center where r is constant
+// (swaps the q and s components).
+func ReflectR(a, center Axial) Axial {
+    v := a.Subtract(center)
+    return center.Add(Axial{Q: v.S(), R: v.R})
+}
+
+// ReflectS mirrors a across the line through center where s is constant
+// (swaps the q and r components).
+func ReflectS(a, center Axial) Axial {
+    v := a.Subtract(center)
+    return center.Add(Axial{Q: v.R, R: v.Q})
+}
@@ -0,0 +1,234 @@
+package path
+
+import (
+    "sort"
+
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+// Cooperative plans unit moves with windowed hierarchical cooperative A*
+// (WHCA*): each unit searches in space-time over the next Window ticks,
+// avoiding hexes and moves reserved by units planned before it, then
+// reserves its own steps. Units replan as their window runs out (see
+// NeedsReplan), which also lets later units take their turn first.
+type Cooperative struct {
+    Table     *Reservations
+    Window    int                          // ticks searched ahead per plan
+    Neighbors func(a hex.Axial) []hex.Axial // as for AStar
+    Cost      func(a, b hex.Axial) int      // as for AStar; nil = 1
+    Passable  func(a hex.Axial) bool        // used to place formation slots; nil = all
+
+    nodes map[spaceTime]coopRec
+    open  openList
+}
+
+type coopRec struct {
+    g      int
+    parent spaceTime
+    closed bool
+}
+
+// NewCooperative returns a planner with its own reservation table.
+func NewCooperative(window int,
+    neighbors func(a hex.Axial) []hex.Axial,
+    cost func(a, b hex.Axial) int,
+) *Cooperative {
+    if window < 1 { window = 1 }
+    return &Cooperative{
+        Table:     NewReservations(),
+        Window:    window,
+        Neighbors: neighbors,
+        Cost:      cost,
+        nodes:     make(map[spaceTime]coopRec),
+    }
+}
+
+// Plan releases unit's previous reservations, plans its next Window ticks
+// from start at tick toward goal and reserves the result. Waiting in place
+// costs one per tick. If the goal is reached inside the window the unit is
+// parked there and the schedule is Complete. If no move is possible the
+// schedule holds the unit at start.
+func (c *Cooperative) Plan(unit int, start, goal hex.Axial, tick int) Schedule {
+    c.Table.Release(unit)
+    s := c.search(unit, start, goal, tick)
+    c.Table.ReserveSchedule(s)
+    return s
+}
+
+// NeedsReplan reports whether s should be replanned at tick t: incomplete
+// schedules are refreshed halfway through their window.
+func (c *Cooperative) NeedsReplan(s Schedule, t int) bool {
+    if s.Complete || len(s.Steps) == 0 { return false }
+    return t >= s.Steps[0].Tick+(c.Window+1)/2
+}
+
+func (c *Cooperative) search(unit int, start, goal hex.Axial, tick int) Schedule {
+    clear(c.nodes)
+    c.open.reset()
+    h := HeuristicTo(goal)
+    horizon := tick + c.Window
+    root := spaceTime{start, tick}
+    c.nodes[root] = coopRec{parent: root}
+    c.open.pushAt(start, tick, h(start), 0)
+
+    relax := func(from spaceTime, g int, to spaceTime) {
+        old, seen := c.nodes[to]
+        if seen && old.closed { return }
+        if !seen || g < old.g {
+            c.nodes[to] = coopRec{g: g, parent: from}
+            c.open.pushAt(to.A, to.T, g+h(to.A), g)
+        }
+    }
+
+    for c.open.len() > 0 {
+        e := c.open.pop()
+        cur := spaceTime{e.a, e.t}
+        rec := c.nodes[cur]
+        if rec.closed || e.g != rec.g { continue }
+        rec.closed = true
+        c.nodes[cur] = rec
+
+        if cur.A == goal && c.canStay(unit, goal, cur.T, horizon) {
+            return c.schedule(unit, root, cur, true)
+        }
+        if cur.T >= horizon {
+            // window exhausted: the rest is left to the next plan
+            return c.schedule(unit, root, cur, false)
+        }
+        if c.Table.CanMove(unit, cur.A, cur.A, cur.T) {
+            relax(cur, rec.g+1, spaceTime{cur.A, cur.T + 1})
+        }
+        for _, nb := range c.Neighbors(cur.A) {
+            if c.Table.CanMove(unit, cur.A, nb, cur.T) {
+                relax(cur, rec.g+stepCost(c.Cost, cur.A, nb), spaceTime{nb, cur.T + 1})
+            }
+        }
+    }
+    return Schedule{Unit: unit, Steps: []Waypoint{{Tick: tick, Hex: start}}}
+}
+
+// canStay reports whether unit can remain on a from tick t through the
+// horizon without running into someone else's later reservation.
+func (c *Cooperative) canStay(unit int, a hex.Axial, t, horizon int) bool {
+    for ; t <= horizon; t++ {
+        if !c.Table.IsFree(unit, a, t) { return false }
+    }
+    return true
+}
+
+func (c *Cooperative) schedule(unit int, root, end spaceTime, complete bool) Schedule {
+    n := end.T - root.T + 1
+    steps := make([]Waypoint, n)
+    for k := end; ; k = c.nodes[k].parent {
+        steps[k.T-root.T] = Waypoint{Tick: k.T, Hex: k.A}
+        if k == root { break }
+    }
+    return Schedule{Unit: unit, Steps: steps, Complete: complete}
+}
+
+// PlanGroup moves units as a formation: each unit keeps its offset from
+// the first unit (the leader), so the group arrives at goal in the shape
+// it started in. Slots that are impassable or taken fall back to the
+// nearest free hex. Units closest to their slot are planned first so the
+// front of the group does not get stuck behind the back. Schedules are
+// returned in the order of units.
+func (c *Cooperative) PlanGroup(units []int, starts []hex.Axial, goal hex.Axial, tick int) []Schedule {
+    if len(units) == 0 || len(units) != len(starts) { return nil }
+    slots := c.formationSlots(starts, goal)
+
+    order := make([]int, len(units))
+    for i := range order { order[i] = i }
+    sort.SliceStable(order, func(i, j int) bool {
+        a, b := order[i], order[j]
+        return hex.DistanceAxial(starts[a], slots[a]) < hex.DistanceAxial(starts[b], slots[b])
+    })
+
+    for _, u := range units { c.Table.Release(u) }
+    out := make([]Schedule, len(units))
+    for _, i := range order {
+        out[i] = c.Plan(units[i], starts[i], slots[i], tick)
+    }
+    return out
+}
+
+// formationSlots returns each unit's target: goal plus its offset from the
+// leader, moved to the nearest passable unclaimed hex if needed.
+func (c *Cooperative) formationSlots(starts []hex.Axial, goal hex.Axial) []hex.Axial {
+    passable := c.Passable
+    if passable == nil { passable = func(hex.Axial) bool { return true } }
+    taken := make(map[hex.Axial]bool, len(starts))
+    slots := make([]hex.Axial, len(starts))
+    for i, s := range starts {
+        want := goal.Add(s.Subtract(starts[0]))
+        slots[i] = want
+        for r := 0; r <= c.Window; r++ {
+            found := false
+            for _, a := range hex.Ring(want, r) {
+                if passable(a) && !taken[a] {
+                    slots[i], found = a, true
+                    break
+                }
+            }
+            if found { break }
+        }
+        taken[slots[i]] = true
+    }
+    return slots
+}
+
+// Agent is a unit's current hex and the hex it is trying to enter next.
+type Agent struct {
+    Unit int
+    At   hex.Axial
+    Next hex.Axial
+}
+
+// Deadlocks returns the cycles in the wait-for graph of agents: a unit
+// waits for another if it wants to enter the hex the other occupies. A
+// cycle of two is a head-on block; longer cycles are units waiting on each
+// other in a loop. None of them can make progress until one yields (e.g.
+// replans with the others' hexes treated as blocked). Cycles are returned
+// as unit IDs in wait order (each waits for the next, the last for the
+// first).
+func Deadlocks(agents []Agent) [][]int {
+    occupant := make(map[hex.Axial]int, len(agents))
+    for i, a := range agents { occupant[a.At] = i }
+    waitsFor := make([]int, len(agents))
+    for i, a := range agents {
+        waitsFor[i] = -1
+        if a.Next == a.At { continue }
+        if j, ok := occupant[a.Next]; ok && j != i { waitsFor[i] = j }
+    }
+
+    // each agent waits for at most one other, so cycles are found by
+    // walking the chains once
+    const (
+        unvisited = 0
+        onStack   = 1
+        done      = 2
+    )
+    state := make([]int, len(agents))
+    var cycles [][]int
+    for i := range agents {
+        if state[i] != unvisited { continue }
+        var chain []int
+        j := i
+        for j >= 0 && state[j] == unvisited {
+            state[j] = onStack
+            chain = append(chain, j)
+            j = waitsFor[j]
+        }
+        if j >= 0 && state[j] == onStack {
+            var cyc []int
+            for k := len(chain) - 1; k >= 0; k-- {
+                cyc = append(cyc, agents[chain[k]].Unit)
+                if chain[k] == j { break }
+            }
+            // restore chain order starting from j
+            for a, b := 0, len(cyc)-1; a < b; a, b = a+1, b-1 { cyc[a], cyc[b] = cyc[b], cyc[a] }
+            cycles = append(cycles, cyc)
+        }
+        for _, k := range chain { state[k] = done }
+    }
+    return cycles
+}
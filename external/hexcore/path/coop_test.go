@@ -0,0 +1,187 @@
+package path
+
+import (
+    "testing"
+
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+// checkSchedules verifies that schedules are well formed and never put two
+// units on one hex at the same tick or swap two units through each other.
+func checkSchedules(t *testing.T, scheds []Schedule) {
+    t.Helper()
+    end := 0
+    for _, s := range scheds {
+        for i := 1; i < len(s.Steps); i++ {
+            if s.Steps[i].Tick != s.Steps[i-1].Tick+1 || hex.DistanceAxial(s.Steps[i-1].Hex, s.Steps[i].Hex) > 1 {
+                t.Fatalf("unit %d: malformed schedule %v", s.Unit, s.Steps)
+            }
+        }
+        if s.End() > end { end = s.End() }
+    }
+    for tick := scheds[0].Steps[0].Tick; tick <= end; tick++ {
+        at := map[hex.Axial]int{}
+        for _, s := range scheds {
+            a := s.At(tick)
+            if u, ok := at[a]; ok {
+                t.Fatalf("units %d and %d both on %v at tick %d", u, s.Unit, a, tick)
+            }
+            at[a] = s.Unit
+        }
+        for _, s := range scheds {
+            for _, o := range scheds {
+                if s.Unit < o.Unit && s.At(tick) == o.At(tick+1) && o.At(tick) == s.At(tick+1) && s.At(tick) != s.At(tick+1) {
+                    t.Fatalf("units %d and %d swap at tick %d", s.Unit, o.Unit, tick)
+                }
+            }
+        }
+    }
+}
+
+func TestReservations(t *testing.T) {
+    r := NewReservations()
+    a, b := hex.Axial{Q: 0, R: 0}, hex.Axial{Q: 1, R: 0}
+    if !r.Reserve(1, a, 5) || r.Reserve(2, a, 5) || !r.Reserve(1, a, 5) {
+        t.Fatalf("reservation ownership not enforced")
+    }
+    if !r.IsFree(2, a, 6) {
+        t.Errorf("reservation leaked into next tick")
+    }
+
+    r.ReserveSchedule(Schedule{Unit: 3, Steps: []Waypoint{{Tick: 1, Hex: b}, {Tick: 2, Hex: a}}})
+    if r.CanMove(4, a, b, 1) {
+        t.Errorf("head-on swap allowed")
+    }
+    if !r.CanMove(4, b, a, 3) {
+        t.Errorf("move after the other unit left was refused")
+    }
+
+    r.Park(6, b, 10)
+    if r.IsFree(7, b, 12) || !r.IsFree(7, b, 9) {
+        t.Errorf("parking window wrong")
+    }
+
+    r.Prune(3)
+    if !r.IsFree(2, a, 2) || r.IsFree(2, a, 5) {
+        t.Errorf("prune dropped the wrong reservations")
+    }
+    r.Release(1)
+    r.Release(6)
+    if !r.IsFree(2, a, 5) || !r.IsFree(7, b, 12) {
+        t.Errorf("release left reservations behind")
+    }
+}
+
+func TestCooperativeCrossing(t *testing.T) {
+    c := NewCooperative(16, grid(5), nil)
+    // three pairs of units swapping places across the center
+    var scheds []Schedule
+    for i, d := range hex.Directions[:3] {
+        from, to := d.Mul(3), d.Mul(-3)
+        scheds = append(scheds, c.Plan(2*i, from, to, 0), c.Plan(2*i+1, to, from, 0))
+    }
+    for _, s := range scheds {
+        if !s.Complete {
+            t.Fatalf("unit %d did not reach its goal: %v", s.Unit, s.Steps)
+        }
+    }
+    checkSchedules(t, scheds)
+}
+
+func TestCooperativeCorridor(t *testing.T) {
+    // a one-hex-wide corridor with a side bay at (2,-1): two units meeting
+    // head-on must use the bay to pass
+    cells := map[hex.Axial]bool{{Q: 2, R: -1}: true}
+    for q := -3; q <= 3; q++ { cells[hex.Axial{Q: q, R: 0}] = true }
+    nb := NeighborsFromUnion(cells, func(hex.Axial) bool { return true })
+    c := NewCooperative(20, nb, nil)
+
+    a := c.Plan(1, hex.Axial{Q: -3, R: 0}, hex.Axial{Q: 3, R: 0}, 0)
+    b := c.Plan(2, hex.Axial{Q: 3, R: 0}, hex.Axial{Q: -3, R: 0}, 0)
+    if !a.Complete || !b.Complete {
+        t.Fatalf("units did not pass each other: %v / %v", a.Steps, b.Steps)
+    }
+    checkSchedules(t, []Schedule{a, b})
+    if len(b.Steps) <= 7 {
+        t.Errorf("second unit should have had to yield, got %v", b.Steps)
+    }
+}
+
+func TestCooperativeWindow(t *testing.T) {
+    c := NewCooperative(4, grid(10), nil)
+    s := c.Plan(1, hex.Axial{Q: -8, R: 0}, hex.Axial{Q: 8, R: 0}, 100)
+    if s.Complete || len(s.Steps) != 5 || s.At(104) != (hex.Axial{Q: -4, R: 0}) {
+        t.Fatalf("expected 4-tick partial plan, got %+v", s)
+    }
+    if c.NeedsReplan(s, 101) || !c.NeedsReplan(s, 102) {
+        t.Errorf("replan point should be halfway through the window")
+    }
+    s = c.Plan(1, s.At(102), hex.Axial{Q: 8, R: 0}, 102)
+    if s.Steps[0].Hex != (hex.Axial{Q: -6, R: 0}) {
+        t.Errorf("replan did not start from the current hex: %v", s.Steps)
+    }
+}
+
+func TestPlanGroupKeepsFormation(t *testing.T) {
+    c := NewCooperative(20, grid(8), nil)
+    blocked := hex.Axial{Q: 5, R: -1}
+    c.Passable = func(a hex.Axial) bool { return a != blocked }
+    units := []int{10, 11, 12}
+    starts := []hex.Axial{{Q: -4, R: 0}, {Q: -4, R: 1}, {Q: -5, R: 0}}
+    goal := hex.Axial{Q: 4, R: -1}
+
+    scheds := c.PlanGroup(units, starts, goal, 0)
+    checkSchedules(t, scheds)
+    for i, s := range scheds {
+        if s.Unit != units[i] || !s.Complete {
+            t.Fatalf("unit %d: incomplete or out of order", units[i])
+        }
+    }
+    if end := scheds[1].At(scheds[1].End()); end != (hex.Axial{Q: 4, R: 0}) {
+        t.Errorf("follower ended at %v, want (4,0)", end)
+    }
+    // the slot for unit 12 is (3,-1); it is free, so the formation holds
+    if end := scheds[2].At(scheds[2].End()); end != (hex.Axial{Q: 3, R: -1}) {
+        t.Errorf("follower ended at %v, want (3,-1)", end)
+    }
+
+    if c.PlanGroup(units, starts[:2], goal, 0) != nil {
+        t.Errorf("mismatched units and starts should be rejected")
+    }
+
+    // a blocked slot moves to the nearest free hex
+    scheds = c.PlanGroup(units[:2], []hex.Axial{{Q: -4, R: 0}, {Q: -3, R: 0}}, goal, 0)
+    if end := scheds[1].At(scheds[1].End()); end == blocked || !scheds[1].Complete {
+        t.Errorf("follower should avoid blocked slot, ended at %v", end)
+    }
+}
+
+func TestDeadlocks(t *testing.T) {
+    a, b, c, d := hex.Axial{Q: 0, R: 0}, hex.Axial{Q: 1, R: 0}, hex.Axial{Q: 1, R: -1}, hex.Axial{Q: 5, R: 5}
+    tests := []struct {
+        name   string
+        agents []Agent
+        want   [][]int
+    }{
+        {"none", []Agent{{1, a, b}, {2, b, d}}, nil},
+        {"head-on", []Agent{{1, a, b}, {2, b, a}}, [][]int{{1, 2}}},
+        {"loop of three", []Agent{{7, d, a}, {1, a, b}, {2, b, c}, {3, c, a}}, [][]int{{1, 2, 3}}},
+        {"waiting unit", []Agent{{1, a, a}, {2, b, a}}, nil},
+    }
+    for _, tt := range tests {
+        got := Deadlocks(tt.agents)
+        if len(got) != len(tt.want) {
+            t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+            continue
+        }
+        for i := range got {
+            if len(got[i]) != len(tt.want[i]) {
+                t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+                continue
+            }
+            for j := range got[i] {
+                if got[i][j] != tt.want[i][j] { t.Errorf("%s: got %v, want %v", tt.name, got, tt.want) }
+            }
+        }
+    }
+}
@@ -0,0 +1,130 @@
+package path
+
+import "github.com/gravitas-015/hexcore/hex"
+
+// FlowField holds, for every hex that can reach one of its goals, the
+// cheapest remaining cost and the next hex to step to. Units of a group
+// sharing a destination can all follow one field instead of running a
+// search each.
+type FlowField struct {
+    dist map[hex.Axial]int
+    next map[hex.Axial]hex.Axial
+}
+
+// NewFlowField runs Dijkstra outward from goals. The cost of stepping from
+// a to b is cost(a, b), so the field respects one-way costs; neighbors
+// must be symmetric (b is a neighbor of a iff a is a neighbor of b).
+// maxCost bounds the field (0 = unbounded, which requires a finite graph).
+func NewFlowField(goals []hex.Axial,
+    neighbors func(a hex.Axial) []hex.Axial,
+    cost func(a, b hex.Axial) int,
+    maxCost int,
+) *FlowField {
+    return NewSearch().FlowField(goals, neighbors, cost, maxCost)
+}
+
+// FlowField is NewFlowField run through this context.
+func (s *Search) FlowField(goals []hex.Axial,
+    neighbors func(a hex.Axial) []hex.Axial,
+    cost func(a, b hex.Axial) int,
+    maxCost int,
+) *FlowField {
+    s.dijkstra(goals, neighbors, func(from, to hex.Axial) int { return stepCost(cost, to, from) }, maxCost)
+    f := &FlowField{
+        dist: make(map[hex.Axial]int, len(s.nodes)),
+        next: make(map[hex.Axial]hex.Axial, len(s.nodes)),
+    }
+    for a, rec := range s.nodes {
+        if !rec.closed { continue }
+        f.dist[a] = rec.g
+        if !s.goals[a] { f.next[a] = rec.parent }
+    }
+    return f
+}
+
+// Cost returns the remaining cost from a to the nearest goal.
+func (f *FlowField) Cost(a hex.Axial) (int, bool) {
+    d, ok := f.dist[a]
+    return d, ok
+}
+
+// Next returns the hex to step to from a. It returns false on a goal or
+// outside the field.
+func (f *FlowField) Next(a hex.Axial) (hex.Axial, bool) {
+    n, ok := f.next[a]
+    return n, ok
+}
+
+// Path follows the field from a to its goal, inclusive of both ends, or
+// returns nil if a is outside the field.
+func (f *FlowField) Path(a hex.Axial) []hex.Axial {
+    if _, ok := f.dist[a]; !ok { return nil }
+    path := []hex.Axial{a}
+    for {
+        n, ok := f.next[a]
+        if !ok { return path }
+        path = append(path, n)
+        a = n
+    }
+}
+
+// Len returns the number of hexes covered by the field.
+func (f *FlowField) Len() int { return len(f.dist) }
+
+// Reachable returns every hex reachable from start with at most points
+// movement points, mapped to its cheapest cost. start itself costs 0.
+func Reachable(start hex.Axial, points int,
+    neighbors func(a hex.Axial) []hex.Axial,
+    cost func(a, b hex.Axial) int,
+) map[hex.Axial]int {
+    return NewSearch().Reachable(start, points, neighbors, cost)
+}
+
+// Reachable is the package-level Reachable run through this context.
+func (s *Search) Reachable(start hex.Axial, points int,
+    neighbors func(a hex.Axial) []hex.Axial,
+    cost func(a, b hex.Axial) int,
+) map[hex.Axial]int {
+    if points < 0 { return map[hex.Axial]int{} }
+    // a zero bound means "unbounded" to dijkstra; nothing but start fits in 0
+    if points == 0 { return map[hex.Axial]int{start: 0} }
+    s.dijkstra([]hex.Axial{start}, neighbors, func(from, to hex.Axial) int { return stepCost(cost, from, to) }, points)
+    out := make(map[hex.Axial]int, len(s.nodes))
+    for a, rec := range s.nodes {
+        if rec.closed { out[a] = rec.g }
+    }
+    return out
+}
+
+// dijkstra settles every hex reachable from sources within maxCost (0 =
+// unbounded). step(from, to) is the cost of relaxing the edge; parent
+// links point back toward the source that settled each hex.
+func (s *Search) dijkstra(sources []hex.Axial,
+    neighbors func(a hex.Axial) []hex.Axial,
+    step func(from, to hex.Axial) int,
+    maxCost int,
+) {
+    s.reset()
+    for _, a := range sources {
+        s.goals[a] = true
+        s.nodes[a] = nodeRec{g: 0, parent: a}
+        s.open.push(a, 0, 0)
+    }
+    for s.open.len() > 0 {
+        cur := s.open.pop()
+        rec := s.nodes[cur.a]
+        if rec.closed || cur.g != rec.g { continue }
+        rec.closed = true
+        s.nodes[cur.a] = rec
+        for _, nb := range neighbors(cur.a) {
+            old, seen := s.nodes[nb]
+            if seen && old.closed { continue }
+            g := rec.g + step(cur.a, nb)
+            if maxCost > 0 && g > maxCost { continue }
+            if !seen || g < old.g {
+                s.nodes[nb] = nodeRec{g: g, parent: cur.a}
+                s.open.push(nb, g, g)
+            }
+        }
+    }
+}
@@ -0,0 +1,333 @@
+package path
+
+import (
+    "sort"
+
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+// Hierarchy is an HPA*-style planner over a map made of hex chunks of a
+// common radius. Neighboring chunks (see hex.NeighborChunkCenter) share a
+// line of border cells; every contiguous passable run along a border gets
+// one portal cell, picked with the same seeded hash as
+// SelectPortalDeterministic so both chunks agree on it. Portal-to-portal
+// costs inside each chunk are precomputed, queries search this abstract
+// graph first and then refine each hop with a local A* inside one chunk.
+//
+// When terrain changes, call Invalidate for the changed hex: only the
+// chunks containing it are rebuilt, lazily on the next query. A Hierarchy
+// is not safe for concurrent use.
+type Hierarchy struct {
+    radius   int
+    seed     int64
+    passable func(a hex.Axial) bool
+    cost     func(a, b hex.Axial) int
+
+    chunks     map[hex.Axial]*hpaChunk
+    order      []hex.Axial // chunk centers in insertion order
+    lattice    bool        // every center is on the hex.ChunkCenterOf lattice
+    nodeChunks map[hex.Axial][]*hpaChunk
+    dirty      bool
+    search     *Search
+    rebuilds   int
+}
+
+type hpaChunk struct {
+    center  hex.Axial
+    index   int // position in Hierarchy.order
+    portals []hex.Axial
+    edges   map[hex.Axial][]hpaEdge
+    dirty   bool
+}
+
+type hpaEdge struct {
+    to   hex.Axial
+    cost int
+}
+
+// NewHierarchy builds the abstract graph for the given chunk centers.
+// - passable: reports whether a hex can be entered
+// - cost: edge cost between adjacent hexes (nil = 1)
+// - seed: portal selection seed, as for SelectPortalDeterministic
+func NewHierarchy(centers []hex.Axial, radius int, seed int64,
+    passable func(a hex.Axial) bool,
+    cost func(a, b hex.Axial) int,
+) *Hierarchy {
+    h := &Hierarchy{
+        radius:     radius,
+        seed:       seed,
+        passable:   passable,
+        cost:       cost,
+        chunks:     make(map[hex.Axial]*hpaChunk, len(centers)),
+        nodeChunks: make(map[hex.Axial][]*hpaChunk),
+        search:     NewSearch(),
+        lattice:    radius > 0,
+    }
+    for _, c := range centers {
+        if h.chunks[c] != nil { continue }
+        h.chunks[c] = &hpaChunk{center: c, index: len(h.order), dirty: true}
+        h.order = append(h.order, c)
+        if hex.ChunkCenterOf(c, radius) != c { h.lattice = false }
+    }
+    h.dirty = true
+    h.refresh()
+    return h
+}
+
+// Invalidate marks every chunk containing a for rebuilding. Call it after
+// the passability or cost of a changes.
+func (h *Hierarchy) Invalidate(a hex.Axial) {
+    for _, c := range h.chunksAt(a) {
+        c.dirty = true
+        h.dirty = true
+    }
+}
+
+// Portals returns the portal cells of the chunk at center.
+func (h *Hierarchy) Portals(center hex.Axial) []hex.Axial {
+    h.refresh()
+    if c := h.chunks[center]; c != nil { return c.portals }
+    return nil
+}
+
+// Find returns a path from start to goal, inclusive of both ends, or nil if
+// there is none. Paths follow portals, so they can be slightly longer than
+// the true shortest path.
+func (h *Hierarchy) Find(start, goal hex.Axial) []hex.Axial {
+    h.refresh()
+    startChunks, goalChunks := h.chunksAt(start), h.chunksAt(goal)
+    if len(startChunks) == 0 || len(goalChunks) == 0 { return nil }
+    if start == goal { return []hex.Axial{start} }
+
+    // Temporary edges from start to the portals of its chunks, and from the
+    // portals of the goal's chunks to goal (which may include start itself).
+    startEdges := map[hex.Axial]int{}
+    for _, c := range startChunks {
+        h.localCosts(c, start, false)
+        h.collectCosts(startEdges, c.portals)
+        h.collectCosts(startEdges, []hex.Axial{goal})
+    }
+    goalEdges := map[hex.Axial]int{}
+    for _, c := range goalChunks {
+        h.localCosts(c, goal, true)
+        h.collectCosts(goalEdges, c.portals)
+    }
+
+    hops := h.abstractPath(start, goal, startEdges, goalEdges)
+    if hops == nil { return nil }
+
+    path := []hex.Axial{start}
+    for i := 1; i < len(hops); i++ {
+        seg := h.refine(hops[i-1], hops[i])
+        if seg == nil { return nil }
+        path = append(path, seg[1:]...)
+    }
+    return path
+}
+
+// abstractPath runs A* over portals, with start and goal spliced in.
+func (h *Hierarchy) abstractPath(start, goal hex.Axial, startEdges, goalEdges map[hex.Axial]int) []hex.Axial {
+    s := h.search
+    s.reset()
+    s.nodes[start] = nodeRec{g: 0, parent: start}
+    s.open.push(start, hex.DistanceAxial(start, goal), 0)
+
+    relax := func(from hex.Axial, g int, to hex.Axial, step int) {
+        old, seen := s.nodes[to]
+        if seen && old.closed { return }
+        if t := g + step; !seen || t < old.g {
+            s.nodes[to] = nodeRec{g: t, parent: from}
+            s.open.push(to, t+hex.DistanceAxial(to, goal), t)
+        }
+    }
+
+    for s.open.len() > 0 {
+        cur := s.open.pop()
+        rec := s.nodes[cur.a]
+        if rec.closed || cur.g != rec.g { continue }
+        rec.closed = true
+        s.nodes[cur.a] = rec
+        if cur.a == goal { return s.trace(start, goal) }
+
+        if cur.a == start {
+            for p, c := range startEdges { relax(start, rec.g, p, c) }
+            continue
+        }
+        if c, ok := goalEdges[cur.a]; ok { relax(cur.a, rec.g, goal, c) }
+        for _, ch := range h.nodeChunks[cur.a] {
+            for _, e := range ch.edges[cur.a] { relax(cur.a, rec.g, e.to, e.cost) }
+        }
+    }
+    return nil
+}
+
+// refine finds the cheapest local path from a to b inside a chunk that
+// contains both.
+func (h *Hierarchy) refine(a, b hex.Axial) []hex.Axial {
+    var best []hex.Axial
+    bestCost := 0
+    for _, c := range h.chunksAt(a) {
+        if hex.DistanceAxial(c.center, b) > h.radius { continue }
+        p := h.search.AStar(a, b, HeuristicTo(b), h.localNeighbors(c.center), h.cost)
+        if p == nil { continue }
+        pc := 0
+        for i := 1; i < len(p); i++ { pc += stepCost(h.cost, p[i-1], p[i]) }
+        if best == nil || pc < bestCost { best, bestCost = p, pc }
+    }
+    return best
+}
+
+// localCosts runs Dijkstra from a inside chunk c. With reverse set the
+// costs are those of reaching a rather than leaving it. Results are left
+// in h.search.nodes.
+func (h *Hierarchy) localCosts(c *hpaChunk, a hex.Axial, reverse bool) {
+    step := func(from, to hex.Axial) int { return stepCost(h.cost, from, to) }
+    if reverse {
+        step = func(from, to hex.Axial) int { return stepCost(h.cost, to, from) }
+    }
+    h.search.dijkstra([]hex.Axial{a}, h.localNeighbors(c.center), step, 0)
+}
+
+// collectCosts records into dst the cheapest cost found by the last
+// localCosts run for each of targets that was reached.
+func (h *Hierarchy) collectCosts(dst map[hex.Axial]int, targets []hex.Axial) {
+    for _, p := range targets {
+        rec, ok := h.search.nodes[p]
+        if !ok || !rec.closed { continue }
+        if old, seen := dst[p]; !seen || rec.g < old { dst[p] = rec.g }
+    }
+}
+
+// localNeighbors returns passable neighbors restricted to one chunk.
+func (h *Hierarchy) localNeighbors(center hex.Axial) func(a hex.Axial) []hex.Axial {
+    return func(a hex.Axial) []hex.Axial {
+        out := make([]hex.Axial, 0, 6)
+        for _, d := range hex.Directions {
+            b := a.Add(d)
+            if hex.DistanceAxial(center, b) <= h.radius && h.passable(b) {
+                out = append(out, b)
+            }
+        }
+        return out
+    }
+}
+
+// chunksAt returns the chunks containing a, in insertion order; border
+// cells belong to two or three chunks. On the lattice these are the chunk
+// of a and some of its six neighbors (the next centers are 3R away), so
+// they are looked up directly; other layouts fall back to a scan.
+func (h *Hierarchy) chunksAt(a hex.Axial) []*hpaChunk {
+    var out []*hpaChunk
+    if !h.lattice {
+        for _, c := range h.order {
+            if hex.DistanceAxial(c, a) <= h.radius { out = append(out, h.chunks[c]) }
+        }
+        return out
+    }
+    home := hex.ChunkCenterOf(a, h.radius)
+    for s := -1; s < 6; s++ {
+        c := home
+        if s >= 0 { c = hex.NeighborChunkCenter(home, h.radius, s) }
+        if ch := h.chunks[c]; ch != nil && hex.DistanceAxial(c, a) <= h.radius { out = append(out, ch) }
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].index < out[j].index })
+    return out
+}
+
+// refresh rebuilds dirty chunks: their border portals first (a changed
+// border cell dirties both chunks sharing it), then their intra-chunk
+// portal-to-portal costs.
+func (h *Hierarchy) refresh() {
+    if !h.dirty { return }
+    for _, center := range h.order {
+        if c := h.chunks[center]; c.dirty { h.rebuildPortals(c) }
+    }
+    for _, center := range h.order {
+        if c := h.chunks[center]; c.dirty {
+            h.rebuildEdges(c)
+            c.dirty = false
+            h.rebuilds++
+        }
+    }
+    h.dirty = false
+}
+
+func (h *Hierarchy) rebuildPortals(c *hpaChunk) {
+    for _, p := range c.portals { h.unlinkNode(p, c) }
+    seen := map[hex.Axial]bool{}
+    c.portals = c.portals[:0]
+    for s := 0; s < 6; s++ {
+        n := hex.NeighborChunkCenter(c.center, h.radius, s)
+        if h.chunks[n] == nil { continue }
+        for _, p := range h.borderPortals(c.center, n) {
+            if seen[p] { continue }
+            seen[p] = true
+            c.portals = append(c.portals, p)
+            h.nodeChunks[p] = append(h.nodeChunks[p], c)
+        }
+    }
+}
+
+func (h *Hierarchy) unlinkNode(p hex.Axial, c *hpaChunk) {
+    list := h.nodeChunks[p]
+    for i, x := range list {
+        if x == c {
+            list = append(list[:i], list[i+1:]...)
+            break
+        }
+    }
+    if len(list) == 0 {
+        delete(h.nodeChunks, p)
+    } else {
+        h.nodeChunks[p] = list
+    }
+}
+
+func (h *Hierarchy) rebuildEdges(c *hpaChunk) {
+    c.edges = make(map[hex.Axial][]hpaEdge, len(c.portals))
+    for _, p := range c.portals {
+        h.localCosts(c, p, false)
+        for _, q := range c.portals {
+            if q == p { continue }
+            if rec, ok := h.search.nodes[q]; ok && rec.closed {
+                c.edges[p] = append(c.edges[p], hpaEdge{to: q, cost: rec.g})
+            }
+        }
+    }
+}
+
+// borderPortals returns one portal per contiguous passable run along the
+// border shared by chunks a and b. The result does not depend on the order
+// of a and b.
+func (h *Hierarchy) borderPortals(a, b hex.Axial) []hex.Axial {
+    var border []hex.Axial
+    for _, x := range hex.Ring(a, h.radius) {
+        if hex.DistanceAxial(b, x) <= h.radius { border = append(border, x) }
+    }
+    // the border is a straight line, so (q, r) order walks along it
+    sort.Slice(border, func(i, j int) bool {
+        if border[i].Q != border[j].Q { return border[i].Q < border[j].Q }
+        return border[i].R < border[j].R
+    })
+
+    var out []hex.Axial
+    runStart := -1
+    flush := func(end int) {
+        if runStart < 0 { return }
+        best, bestH := border[runStart], hashCoordWithSeed(h.seed, border[runStart])
+        for _, x := range border[runStart+1 : end] {
+            if hv := hashCoordWithSeed(h.seed, x); hv < bestH { best, bestH = x, hv }
+        }
+        out = append(out, best)
+        runStart = -1
+    }
+    for i, x := range border {
+        if !h.passable(x) {
+            flush(i)
+            continue
+        }
+        if runStart < 0 { runStart = i }
+    }
+    flush(len(border))
+    return out
+}
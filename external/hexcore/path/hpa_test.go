@@ -0,0 +1,184 @@
+package path
+
+import (
+    "math/rand"
+    "testing"
+
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+// chunkPlan returns a center chunk, its six neighbors and the ring of
+// chunks around those.
+func chunkPlan(R int) []hex.Axial {
+    plan := []hex.Axial{{}}
+    seen := map[hex.Axial]bool{{}: true}
+    for i := 0; i < len(plan) && len(plan) < 19; i++ {
+        for s := 0; s < 6; s++ {
+            n := hex.NeighborChunkCenter(plan[i], R, s)
+            if !seen[n] && hex.DistanceAxial(hex.Axial{}, n) <= 4*R {
+                seen[n] = true
+                plan = append(plan, n)
+            }
+        }
+    }
+    return plan
+}
+
+func unionOf(plan []hex.Axial, R int) map[hex.Axial]bool {
+    u := map[hex.Axial]bool{}
+    for _, c := range plan {
+        for _, a := range hex.Disk(c, R) { u[a] = true }
+    }
+    return u
+}
+
+func validPath(t *testing.T, p []hex.Axial, start, goal hex.Axial, ok func(hex.Axial) bool) {
+    t.Helper()
+    if p[0] != start || p[len(p)-1] != goal || !contiguous(p) {
+        t.Fatalf("malformed path from %v to %v: %v", start, goal, p)
+    }
+    for _, a := range p {
+        if !ok(a) { t.Fatalf("path crosses blocked or unknown hex %v: %v", a, p) }
+    }
+}
+
+func TestHierarchyMatchesFlatSearch(t *testing.T) {
+    const R = 3
+    plan := chunkPlan(R)
+    union := unionOf(plan, R)
+    rng := rand.New(rand.NewSource(3))
+    wall := map[hex.Axial]bool{}
+    for a := range union {
+        if rng.Intn(4) == 0 { wall[a] = true }
+    }
+    open := func(a hex.Axial) bool { return union[a] && !wall[a] }
+
+    h := NewHierarchy(plan, R, 42, open, nil)
+    cells := make([]hex.Axial, 0, len(union))
+    for _, a := range hex.Disk(hex.Axial{}, 5*R) {
+        if open(a) { cells = append(cells, a) }
+    }
+    for i := 0; i < 200; i++ {
+        start, goal := cells[rng.Intn(len(cells))], cells[rng.Intn(len(cells))]
+        flat := AStar(start, goal, HeuristicTo(goal), NeighborsFromUnion(union, open), nil)
+        got := h.Find(start, goal)
+        if (flat == nil) != (got == nil) {
+            t.Fatalf("%v -> %v: hierarchical found=%v, flat found=%v", start, goal, got != nil, flat != nil)
+        }
+        if got == nil { continue }
+        validPath(t, got, start, goal, open)
+        if len(got) < len(flat) {
+            t.Fatalf("%v -> %v: hierarchical path shorter than optimal", start, goal)
+        }
+    }
+}
+
+func TestHierarchyPortalsAgree(t *testing.T) {
+    const R = 4
+    plan := chunkPlan(R)
+    union := unionOf(plan, R)
+    h := NewHierarchy(plan, R, 7, func(a hex.Axial) bool { return union[a] }, nil)
+
+    for s := 0; s < 6; s++ {
+        n := hex.NeighborChunkCenter(hex.Axial{}, R, s)
+        mine, theirs := h.borderPortals(hex.Axial{}, n), h.borderPortals(n, hex.Axial{})
+        if len(mine) != 1 || len(theirs) != 1 || mine[0] != theirs[0] {
+            t.Errorf("side %d: portals disagree: %v vs %v", s, mine, theirs)
+        }
+        if hex.DistanceAxial(hex.Axial{}, mine[0]) != R || hex.DistanceAxial(n, mine[0]) != R {
+            t.Errorf("side %d: portal %v not on the shared border", s, mine[0])
+        }
+        found := false
+        for _, p := range h.Portals(n) { found = found || p == mine[0] }
+        if !found { t.Errorf("side %d: neighbor does not list portal %v", s, mine[0]) }
+    }
+
+    // splitting a border into two runs yields two portals
+    mid := hex.Axial{Q: 1, R: R - 1}
+    h = NewHierarchy(plan, R, 7, func(a hex.Axial) bool { return union[a] && a != mid }, nil)
+    if got := h.borderPortals(hex.Axial{}, hex.NeighborChunkCenter(hex.Axial{}, R, 0)); len(got) != 2 {
+        t.Errorf("expected two portals around a blocked border cell, got %v", got)
+    }
+}
+
+func TestHierarchyInvalidate(t *testing.T) {
+    const R = 3
+    plan := chunkPlan(R)
+    union := unionOf(plan, R)
+    wall := map[hex.Axial]bool{}
+    open := func(a hex.Axial) bool { return union[a] && !wall[a] }
+    h := NewHierarchy(plan, R, 1, open, nil)
+    if h.rebuilds != len(plan) {
+        t.Fatalf("expected %d initial builds, got %d", len(plan), h.rebuilds)
+    }
+
+    start, goal := hex.Axial{Q: -1, R: 0}, hex.Axial{Q: 1, R: 0}
+    if p := h.Find(start, goal); len(p) != 3 {
+        t.Fatalf("expected direct path, got %v", p)
+    }
+
+    // an interior change rebuilds one chunk
+    wall[hex.Axial{}] = true
+    h.Invalidate(hex.Axial{})
+    p := h.Find(start, goal)
+    validPath(t, p, start, goal, open)
+    if len(p) != 4 {
+        t.Errorf("expected detour of 3 steps, got %v", p)
+    }
+    if h.rebuilds != len(plan)+1 {
+        t.Errorf("interior change rebuilt %d chunks", h.rebuilds-len(plan))
+    }
+
+    // a border change rebuilds both chunks sharing the border
+    border := hex.Axial{Q: 1, R: R - 1}
+    if n := len(h.chunksAt(border)); n != 2 {
+        t.Fatalf("expected border cell in 2 chunks, got %d", n)
+    }
+    wall[border] = true
+    h.Invalidate(border)
+    h.Find(start, goal)
+    if h.rebuilds != len(plan)+3 {
+        t.Errorf("border change rebuilt %d chunks", h.rebuilds-len(plan)-1)
+    }
+
+    // walling off the goal makes it unreachable
+    for _, d := range hex.Directions {
+        wall[goal.Add(d)] = true
+        h.Invalidate(goal.Add(d))
+    }
+    if p := h.Find(start, goal); p != nil {
+        t.Errorf("expected no path to walled-off goal, got %v", p)
+    }
+}
+
+func TestHierarchyChunksAt(t *testing.T) {
+    const R = 4
+    plan := chunkPlan(R)
+    union := unionOf(plan, R)
+    open := func(a hex.Axial) bool { return union[a] }
+
+    // A plan shifted off the lattice uses the scan; both must agree with it
+    shifted := make([]hex.Axial, len(plan))
+    for i, c := range plan { shifted[i] = c.Add(hex.Axial{Q: 1}) }
+
+    for i, centers := range [][]hex.Axial{plan, shifted} {
+        h := NewHierarchy(centers, R, 3, open, nil)
+        if h.lattice != (i == 0) { t.Fatalf("plan %d: lattice = %v", i, h.lattice) }
+        for a := range unionOf(centers, R) {
+            var want []hex.Axial
+            for _, c := range centers {
+                if hex.DistanceAxial(c, a) <= R { want = append(want, c) }
+            }
+            got := h.chunksAt(a)
+            if len(got) != len(want) {
+                t.Fatalf("chunksAt(%v) = %d chunks, want %v", a, len(got), want)
+            }
+            for i, c := range got {
+                if c.center != want[i] { t.Fatalf("chunksAt(%v)[%d] = %v, want %v", a, i, c.center, want[i]) }
+            }
+        }
+        if got := h.chunksAt(hex.Axial{Q: 100 * R}); len(got) != 0 {
+            t.Errorf("cell outside the map is in %d chunks", len(got))
+        }
+    }
+}
@@ -0,0 +1,194 @@
+package path
+
+import (
+    "testing"
+
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+// grid returns a neighbor function over a disc of radius R with the given
+// hexes blocked.
+func grid(R int, blocked ...hex.Axial) func(a hex.Axial) []hex.Axial {
+    wall := map[hex.Axial]bool{}
+    for _, b := range blocked { wall[b] = true }
+    inner := NeighborsWithinDisc(hex.Axial{}, R)
+    return func(a hex.Axial) []hex.Axial {
+        out := inner(a)[:0:0]
+        for _, b := range inner(a) {
+            if !wall[b] { out = append(out, b) }
+        }
+        return out
+    }
+}
+
+func pathCost(p []hex.Axial, cost func(a, b hex.Axial) int) int {
+    total := 0
+    for i := 1; i < len(p); i++ { total += stepCost(cost, p[i-1], p[i]) }
+    return total
+}
+
+func contiguous(p []hex.Axial) bool {
+    for i := 1; i < len(p); i++ {
+        if hex.DistanceAxial(p[i-1], p[i]) != 1 { return false }
+    }
+    return true
+}
+
+func TestAStar(t *testing.T) {
+    // a wall across q=1 except at the rim forces a detour
+    var wall []hex.Axial
+    for r := -3; r <= 3; r++ {
+        if hex.DistanceAxial(hex.Axial{}, hex.Axial{Q: 1, R: r}) < 4 { wall = append(wall, hex.Axial{Q: 1, R: r}) }
+    }
+    swamp := func(a, b hex.Axial) int {
+        if b == (hex.Axial{Q: 1, R: 0}) { return 10 }
+        return 1
+    }
+    tests := []struct {
+        name      string
+        start     hex.Axial
+        goal      hex.Axial
+        neighbors func(a hex.Axial) []hex.Axial
+        cost      func(a, b hex.Axial) int
+        wantCost  int // -1 for no path
+    }{
+        {"same hex", hex.Axial{}, hex.Axial{}, grid(3), nil, 0},
+        {"straight", hex.Axial{Q: -2, R: 0}, hex.Axial{Q: 2, R: 0}, grid(3), nil, 4},
+        {"around swamp", hex.Axial{Q: 0, R: 0}, hex.Axial{Q: 2, R: 0}, grid(3), swamp, 3},
+        {"through wall gap", hex.Axial{Q: 0, R: 0}, hex.Axial{Q: 2, R: 0}, grid(4, wall...), nil, 7},
+        {"walled off", hex.Axial{Q: 0, R: 0}, hex.Axial{Q: 2, R: 0}, grid(3, wall...), nil, -1},
+    }
+    s := NewSearch()
+    for _, tt := range tests {
+        for _, p := range [][]hex.Axial{
+            AStar(tt.start, tt.goal, HeuristicTo(tt.goal), tt.neighbors, tt.cost),
+            s.AStar(tt.start, tt.goal, HeuristicTo(tt.goal), tt.neighbors, tt.cost),
+        } {
+            if tt.wantCost < 0 {
+                if p != nil { t.Errorf("%s: expected no path, got %v", tt.name, p) }
+                continue
+            }
+            if len(p) == 0 || p[0] != tt.start || p[len(p)-1] != tt.goal || !contiguous(p) {
+                t.Errorf("%s: malformed path %v", tt.name, p)
+                continue
+            }
+            if c := pathCost(p, tt.cost); c != tt.wantCost {
+                t.Errorf("%s: cost %d, want %d (%v)", tt.name, c, tt.wantCost, p)
+            }
+        }
+    }
+}
+
+func TestAStarMulti(t *testing.T) {
+    goals := []hex.Axial{{Q: 3, R: 0}, {Q: -2, R: 0}, {Q: 0, R: 3}}
+    res := AStarMulti(hex.Axial{}, goals, nil, grid(4), nil, Budget{})
+    if !res.Complete || res.Cost != 2 || res.Path[len(res.Path)-1] != (hex.Axial{Q: -2, R: 0}) {
+        t.Fatalf("expected nearest goal (-2,0) at cost 2, got %+v", res)
+    }
+
+    // blocking the nearest goal sends the search to the next one
+    res = AStarMulti(hex.Axial{}, goals, nil, grid(4, hex.Axial{Q: -2, R: 0}), nil, Budget{})
+    if !res.Complete || res.Cost != 3 {
+        t.Fatalf("expected a goal at cost 3, got %+v", res)
+    }
+}
+
+func TestBudgetPartialPath(t *testing.T) {
+    start, goal := hex.Axial{Q: -5, R: 0}, hex.Axial{Q: 5, R: 0}
+    tests := []struct {
+        name   string
+        budget Budget
+    }{
+        {"max nodes", Budget{MaxNodes: 4}},
+        {"max cost", Budget{MaxCost: 3}},
+    }
+    for _, tt := range tests {
+        res := AStarMulti(start, []hex.Axial{goal}, nil, grid(6), nil, tt.budget)
+        if res.Complete {
+            t.Errorf("%s: expected partial result, got %+v", tt.name, res)
+            continue
+        }
+        end := res.Path[len(res.Path)-1]
+        if res.Path[0] != start || !contiguous(res.Path) || hex.DistanceAxial(end, goal) >= hex.DistanceAxial(start, goal) {
+            t.Errorf("%s: partial path does not make progress: %v", tt.name, res.Path)
+        }
+        if tt.budget.MaxCost > 0 && res.Cost > tt.budget.MaxCost {
+            t.Errorf("%s: cost %d over budget", tt.name, res.Cost)
+        }
+        if tt.budget.MaxNodes > 0 && res.Expanded > tt.budget.MaxNodes {
+            t.Errorf("%s: expanded %d nodes", tt.name, res.Expanded)
+        }
+    }
+
+    // a big enough budget still finds the full path
+    res := AStarMulti(start, []hex.Axial{goal}, nil, grid(6), nil, Budget{MaxCost: 10})
+    if !res.Complete || res.Cost != 10 {
+        t.Errorf("expected complete path of cost 10, got %+v", res)
+    }
+}
+
+func TestFlowField(t *testing.T) {
+    goal := hex.Axial{Q: 2, R: -1}
+    nb := grid(4, hex.Axial{Q: 1, R: 0}, hex.Axial{Q: 0, R: 1})
+    hills := func(a, b hex.Axial) int { return 1 + (b.Q+4)%3 }
+    f := NewFlowField([]hex.Axial{goal}, nb, hills, 0)
+
+    for _, a := range hex.Disk(hex.Axial{}, 4) {
+        if a == (hex.Axial{Q: 1, R: 0}) || a == (hex.Axial{Q: 0, R: 1}) { continue }
+        want := AStar(a, goal, func(hex.Axial) int { return 0 }, nb, hills)
+        got, ok := f.Cost(a)
+        if want == nil {
+            if ok { t.Errorf("%v: field covers unreachable hex", a) }
+            continue
+        }
+        if !ok || got != pathCost(want, hills) {
+            t.Errorf("%v: field cost %d (%v), want %d", a, got, ok, pathCost(want, hills))
+        }
+        p := f.Path(a)
+        if p[len(p)-1] != goal || !contiguous(p) || pathCost(p, hills) != got {
+            t.Errorf("%v: bad field path %v", a, p)
+        }
+    }
+    if _, ok := f.Next(goal); ok {
+        t.Errorf("goal should have no next step")
+    }
+
+    bounded := NewFlowField([]hex.Axial{goal}, nb, nil, 2)
+    if bounded.Len() != 1+3*2*3-2 {
+        // two of the 19 hexes within 2 are walls
+        t.Errorf("bounded field covers %d hexes", bounded.Len())
+    }
+}
+
+func TestReachable(t *testing.T) {
+    tests := []struct {
+        name   string
+        points int
+        cost   func(a, b hex.Axial) int
+        want   int
+    }{
+        {"zero", 0, nil, 1},
+        {"unit cost", 2, nil, 19},
+        {"all expensive", 3, func(a, b hex.Axial) int { return 2 }, 7},
+        {"negative", -1, nil, 0},
+    }
+    for _, tt := range tests {
+        got := Reachable(hex.Axial{}, tt.points, grid(5), tt.cost)
+        if len(got) != tt.want {
+            t.Errorf("%s: %d reachable, want %d", tt.name, len(got), tt.want)
+        }
+        for a, c := range got {
+            if c > tt.points { t.Errorf("%s: %v costs %d", tt.name, a, c) }
+        }
+    }
+}
+
+func BenchmarkSearchReuse(b *testing.B) {
+    nb := grid(20)
+    s := NewSearch()
+    start, goal := hex.Axial{Q: -15, R: 0}, hex.Axial{Q: 15, R: -5}
+    b.ReportAllocs()
+    for i := 0; i < b.N; i++ {
+        s.AStar(start, goal, HeuristicTo(goal), nb, nil)
+    }
+}
@@ -0,0 +1,172 @@
+package path
+
+import "github.com/gravitas-015/hexcore/hex"
+
+// Reservations is a space-time reservation table over hex × tick. Each
+// (hex, tick) may be held by one unit; moves additionally reserve the
+// edge they cross so two units cannot swap places through each other.
+// Parked units hold a hex from some tick onward, e.g. after arriving.
+type Reservations struct {
+    cells  map[spaceTime]int
+    edges  map[edgeTime]int
+    parked map[hex.Axial]parking
+    owned  map[int][]spaceTime
+    moves  map[int][]edgeTime
+}
+
+type spaceTime struct {
+    A hex.Axial
+    T int
+}
+
+// edgeTime is a move from A to B leaving at tick T.
+type edgeTime struct {
+    A, B hex.Axial
+    T    int
+}
+
+type parking struct {
+    unit int
+    from int
+}
+
+// NewReservations returns an empty table.
+func NewReservations() *Reservations {
+    return &Reservations{
+        cells:  make(map[spaceTime]int),
+        edges:  make(map[edgeTime]int),
+        parked: make(map[hex.Axial]parking),
+        owned:  make(map[int][]spaceTime),
+        moves:  make(map[int][]edgeTime),
+    }
+}
+
+// Holder returns the unit holding a at tick t, if any.
+func (r *Reservations) Holder(a hex.Axial, t int) (int, bool) {
+    if u, ok := r.cells[spaceTime{a, t}]; ok { return u, true }
+    if p, ok := r.parked[a]; ok && t >= p.from { return p.unit, true }
+    return 0, false
+}
+
+// IsFree reports whether unit may occupy a at tick t.
+func (r *Reservations) IsFree(unit int, a hex.Axial, t int) bool {
+    u, ok := r.Holder(a, t)
+    return !ok || u == unit
+}
+
+// CanMove reports whether unit may move from a to b leaving at tick t
+// (arriving at t+1): b must be free at t+1 and nobody else may be moving
+// from b to a at the same time.
+func (r *Reservations) CanMove(unit int, a, b hex.Axial, t int) bool {
+    if !r.IsFree(unit, b, t+1) { return false }
+    if a == b { return true }
+    u, ok := r.edges[edgeTime{b, a, t}]
+    return !ok || u == unit
+}
+
+// Reserve holds a at tick t for unit. It returns false if another unit
+// already holds it.
+func (r *Reservations) Reserve(unit int, a hex.Axial, t int) bool {
+    if !r.IsFree(unit, a, t) { return false }
+    k := spaceTime{a, t}
+    if _, ok := r.cells[k]; !ok {
+        r.cells[k] = unit
+        r.owned[unit] = append(r.owned[unit], k)
+    }
+    return true
+}
+
+// Park holds a for unit from tick t onward, until released.
+func (r *Reservations) Park(unit int, a hex.Axial, t int) bool {
+    if p, ok := r.parked[a]; ok && p.unit != unit { return false }
+    r.parked[a] = parking{unit: unit, from: t}
+    return true
+}
+
+// ReserveSchedule reserves every step of s and the moves between them, and
+// parks the unit on its final hex if the schedule reaches its goal.
+func (r *Reservations) ReserveSchedule(s Schedule) {
+    for i, w := range s.Steps {
+        r.Reserve(s.Unit, w.Hex, w.Tick)
+        if i > 0 && s.Steps[i-1].Hex != w.Hex {
+            e := edgeTime{s.Steps[i-1].Hex, w.Hex, s.Steps[i-1].Tick}
+            r.edges[e] = s.Unit
+            r.moves[s.Unit] = append(r.moves[s.Unit], e)
+        }
+    }
+    if s.Complete && len(s.Steps) > 0 {
+        last := s.Steps[len(s.Steps)-1]
+        r.Park(s.Unit, last.Hex, last.Tick)
+    }
+}
+
+// Release drops every reservation held by unit, including parking.
+func (r *Reservations) Release(unit int) {
+    for _, k := range r.owned[unit] {
+        if r.cells[k] == unit { delete(r.cells, k) }
+    }
+    for _, e := range r.moves[unit] {
+        if r.edges[e] == unit { delete(r.edges, e) }
+    }
+    delete(r.owned, unit)
+    delete(r.moves, unit)
+    for a, p := range r.parked {
+        if p.unit == unit { delete(r.parked, a) }
+    }
+}
+
+// Prune drops reservations for ticks before t. Call it as the game clock
+// advances to keep the table small.
+func (r *Reservations) Prune(t int) {
+    for unit, keys := range r.owned {
+        kept := keys[:0]
+        for _, k := range keys {
+            if k.T < t {
+                delete(r.cells, k)
+                continue
+            }
+            kept = append(kept, k)
+        }
+        r.owned[unit] = kept
+    }
+    for unit, moves := range r.moves {
+        kept := moves[:0]
+        for _, e := range moves {
+            if e.T < t {
+                delete(r.edges, e)
+                continue
+            }
+            kept = append(kept, e)
+        }
+        r.moves[unit] = kept
+    }
+}
+
+// Waypoint is where a unit should be at a given tick.
+type Waypoint struct {
+    Tick int
+    Hex  hex.Axial
+}
+
+// Schedule is a per-tick plan for one unit: Steps[i] is the unit's hex at
+// tick Steps[0].Tick+i. Consecutive steps are equal (wait) or adjacent.
+type Schedule struct {
+    Unit     int
+    Steps    []Waypoint
+    Complete bool // the last step is the goal
+}
+
+// At returns the unit's planned hex at tick t, clamped to the schedule.
+func (s Schedule) At(t int) hex.Axial {
+    if len(s.Steps) == 0 { return hex.Axial{} }
+    i := t - s.Steps[0].Tick
+    if i < 0 { i = 0 }
+    if i >= len(s.Steps) { i = len(s.Steps) - 1 }
+    return s.Steps[i].Hex
+}
+
+// End returns the tick of the last step.
+func (s Schedule) End() int {
+    if len(s.Steps) == 0 { return 0 }
+    return s.Steps[len(s.Steps)-1].Tick
+}
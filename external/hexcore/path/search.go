@@ -0,0 +1,212 @@
+package path
+
+import "github.com/gravitas-015/hexcore/hex"
+
+// Budget limits how much work a search may do. Zero fields are unlimited.
+type Budget struct {
+    MaxCost  int // do not expand hexes whose path cost exceeds this
+    MaxNodes int // stop after expanding this many hexes
+}
+
+// Result is the outcome of a budgeted or multi-goal search.
+type Result struct {
+    // Path runs from start to Path[len(Path)-1]. When Complete is false it
+    // is a partial path to the explored hex closest to a goal (by the
+    // heuristic), or just [start] if nothing better was found.
+    Path     []hex.Axial
+    Cost     int  // total edge cost of Path
+    Complete bool // Path ends on a goal
+    Expanded int  // number of hexes expanded
+}
+
+// Search is a reusable A*/Dijkstra context. Its bookkeeping maps and heap
+// are kept between calls, so running many searches through one Search
+// avoids most allocations. A Search is not safe for concurrent use.
+type Search struct {
+    nodes map[hex.Axial]nodeRec
+    goals map[hex.Axial]bool
+    open  openList
+}
+
+// nodeRec is the per-hex bookkeeping of a search.
+type nodeRec struct {
+    g      int
+    parent hex.Axial
+    closed bool
+}
+
+// NewSearch returns an empty search context.
+func NewSearch() *Search {
+    return &Search{
+        nodes: make(map[hex.Axial]nodeRec),
+        goals: make(map[hex.Axial]bool),
+    }
+}
+
+func (s *Search) reset() {
+    clear(s.nodes)
+    clear(s.goals)
+    s.open.reset()
+}
+
+// AStar is the package-level AStar run through this context.
+func (s *Search) AStar(start, goal hex.Axial,
+    h func(a hex.Axial) int,
+    neighbors func(a hex.Axial) []hex.Axial,
+    cost func(a, b hex.Axial) int,
+) []hex.Axial {
+    res := s.AStarMulti(start, []hex.Axial{goal}, h, neighbors, cost, Budget{})
+    if !res.Complete { return nil }
+    return res.Path
+}
+
+// AStarMulti searches from start to the cheapest of several goals.
+// - h: admissible heuristic; nil uses the hex distance to the nearest goal
+// - budget: limits on cost and expansions; when hit, a partial path is returned
+// If no goal is reached, Result.Complete is false and Result.Path leads to
+// the explored hex with the smallest heuristic.
+func (s *Search) AStarMulti(start hex.Axial, goals []hex.Axial,
+    h func(a hex.Axial) int,
+    neighbors func(a hex.Axial) []hex.Axial,
+    cost func(a, b hex.Axial) int,
+    budget Budget,
+) Result {
+    s.reset()
+    for _, g := range goals { s.goals[g] = true }
+    if h == nil { h = HeuristicToAny(goals) }
+    if len(goals) == 0 { return Result{Path: []hex.Axial{start}} }
+
+    s.nodes[start] = nodeRec{g: 0, parent: start}
+    s.open.push(start, h(start), 0)
+    best, bestH := start, h(start)
+    expanded := 0
+
+    for s.open.len() > 0 {
+        cur := s.open.pop()
+        rec := s.nodes[cur.a]
+        if rec.closed || cur.g != rec.g { continue }
+        rec.closed = true
+        s.nodes[cur.a] = rec
+        expanded++
+
+        if s.goals[cur.a] {
+            return Result{Path: s.trace(start, cur.a), Cost: rec.g, Complete: true, Expanded: expanded}
+        }
+        if hc := cur.f - cur.g; hc < bestH {
+            best, bestH = cur.a, hc
+        }
+        if budget.MaxNodes > 0 && expanded >= budget.MaxNodes { break }
+
+        for _, nb := range neighbors(cur.a) {
+            old, seen := s.nodes[nb]
+            if seen && old.closed { continue }
+            tentative := rec.g + stepCost(cost, cur.a, nb)
+            if budget.MaxCost > 0 && tentative > budget.MaxCost { continue }
+            if !seen || tentative < old.g {
+                s.nodes[nb] = nodeRec{g: tentative, parent: cur.a}
+                s.open.push(nb, tentative+h(nb), tentative)
+            }
+        }
+    }
+    return Result{Path: s.trace(start, best), Cost: s.nodes[best].g, Expanded: expanded}
+}
+
+// trace walks parent links back from end to start.
+func (s *Search) trace(start, end hex.Axial) []hex.Axial {
+    path := []hex.Axial{end}
+    for k := end; k != start; {
+        k = s.nodes[k].parent
+        path = append(path, k)
+    }
+    for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+        path[i], path[j] = path[j], path[i]
+    }
+    return path
+}
+
+// stepCost returns cost(a, b), clamped to at least 1 (nil cost means 1).
+func stepCost(cost func(a, b hex.Axial) int, a, b hex.Axial) int {
+    if cost == nil { return 1 }
+    if c := cost(a, b); c > 0 { return c }
+    return 1
+}
+
+// AStarMulti runs a multi-goal, budgeted search with a fresh context.
+func AStarMulti(start hex.Axial, goals []hex.Axial,
+    h func(a hex.Axial) int,
+    neighbors func(a hex.Axial) []hex.Axial,
+    cost func(a, b hex.Axial) int,
+    budget Budget,
+) Result {
+    return NewSearch().AStarMulti(start, goals, h, neighbors, cost, budget)
+}
+
+// HeuristicToAny returns the hex distance to the nearest of goals.
+func HeuristicToAny(goals []hex.Axial) func(a hex.Axial) int {
+    if len(goals) == 1 { return HeuristicTo(goals[0]) }
+    return func(a hex.Axial) int {
+        best := -1
+        for _, g := range goals {
+            if d := hex.DistanceAxial(a, g); best < 0 || d < best { best = d }
+        }
+        if best < 0 { return 0 }
+        return best
+    }
+}
+
+// openList is a binary min-heap of frontier entries ordered by f, then by
+// higher g (prefer deeper nodes on ties), then by insertion order so
+// results are deterministic. Stale entries are skipped on pop.
+type openList struct {
+    items []openEntry
+    seq   int
+}
+
+type openEntry struct {
+    a   hex.Axial
+    t   int // time step, for space-time searches
+    f   int
+    g   int
+    seq int
+}
+
+func (o *openList) len() int { return len(o.items) }
+
+func (o *openList) reset() { o.items = o.items[:0]; o.seq = 0 }
+
+func (o *openList) less(i, j int) bool {
+    a, b := &o.items[i], &o.items[j]
+    if a.f != b.f { return a.f < b.f }
+    if a.g != b.g { return a.g > b.g }
+    return a.seq < b.seq
+}
+
+func (o *openList) push(a hex.Axial, f, g int) { o.pushAt(a, 0, f, g) }
+
+func (o *openList) pushAt(a hex.Axial, t, f, g int) {
+    o.seq++
+    o.items = append(o.items, openEntry{a: a, t: t, f: f, g: g, seq: o.seq})
+    for i := len(o.items) - 1; i > 0; {
+        p := (i - 1) / 2
+        if !o.less(i, p) { break }
+        o.items[i], o.items[p] = o.items[p], o.items[i]
+        i = p
+    }
+}
+
+func (o *openList) pop() openEntry {
+    h := o.items
+    top := h[0]
+    n := len(h) - 1
+    h[0] = h[n]
+    o.items = h[:n]
+    for i := 0; ; {
+        l, r, m := 2*i+1, 2*i+2, i
+        if l < n && o.less(l, m) { m = l }
+        if r < n && o.less(r, m) { m = r }
+        if m == i { break }
+        h[i], h[m] = h[m], h[i]
+        i = m
+    }
+    return top
+}
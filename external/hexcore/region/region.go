@@ -0,0 +1,127 @@
+// Package region provides area tools over hex coordinates: connected
+// regions under a predicate, region outlines for drawing borders, and
+// territory assignment from owner seeds by path cost.
+package region
+
+import (
+    "sort"
+
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+// Fill returns the connected region containing start in which in holds,
+// sorted by (q, r). It returns nil if start itself is not in.
+func Fill(start hex.Axial, in func(a hex.Axial) bool) []hex.Axial {
+    if !in(start) { return nil }
+    seen := map[hex.Axial]bool{start: true}
+    out := []hex.Axial{start}
+    for i := 0; i < len(out); i++ {
+        for _, d := range hex.Directions {
+            b := out[i].Add(d)
+            if !seen[b] && in(b) {
+                seen[b] = true
+                out = append(out, b)
+            }
+        }
+    }
+    sortAxials(out)
+    return out
+}
+
+// Regions splits cells into connected regions where in holds. Regions are
+// ordered by their first cell and each is sorted by (q, r); cells where in
+// is false are dropped.
+func Regions(cells []hex.Axial, in func(a hex.Axial) bool) [][]hex.Axial {
+    set := make(map[hex.Axial]bool, len(cells))
+    for _, a := range cells {
+        if in(a) { set[a] = true }
+    }
+    keys := make([]hex.Axial, 0, len(set))
+    for a := range set { keys = append(keys, a) }
+    sortAxials(keys)
+
+    done := make(map[hex.Axial]bool, len(set))
+    var out [][]hex.Axial
+    for _, a := range keys {
+        if done[a] { continue }
+        r := Fill(a, func(b hex.Axial) bool { return set[b] })
+        for _, b := range r { done[b] = true }
+        out = append(out, r)
+    }
+    return out
+}
+
+// Edge is the side of Hex that faces hex.Directions[Dir].
+type Edge struct {
+    Hex hex.Axial
+    Dir int
+}
+
+// Outer returns the hex on the other side of the edge.
+func (e Edge) Outer() hex.Axial { return e.Hex.Neighbor(e.Dir) }
+
+// Corners returns the pixel end points of the edge in layout l, for
+// drawing borders.
+func (e Edge) Corners(l hex.Layout) (hex.Point, hex.Point) {
+    // the edge's corners are the two closest to the neighboring center
+    corners := l.Corners(e.Hex)
+    o := l.ToPixel(e.Outer())
+    dist := func(p hex.Point) float64 { return (p.X-o.X)*(p.X-o.X) + (p.Y-o.Y)*(p.Y-o.Y) }
+    i0, i1 := -1, -1
+    for i, c := range corners {
+        switch {
+        case i0 < 0 || dist(c) < dist(corners[i0]):
+            i1, i0 = i0, i
+        case i1 < 0 || dist(c) < dist(corners[i1]):
+            i1 = i
+        }
+    }
+    if i1 < i0 { i0, i1 = i1, i0 }
+    return corners[i0], corners[i1]
+}
+
+// Outline returns the hexes of region that have at least one neighbor
+// outside it, sorted by (q, r).
+func Outline(region []hex.Axial) []hex.Axial {
+    set := toSet(region)
+    var out []hex.Axial
+    for a := range set {
+        for _, d := range hex.Directions {
+            if !set[a.Add(d)] {
+                out = append(out, a)
+                break
+            }
+        }
+    }
+    sortAxials(out)
+    return out
+}
+
+// BoundaryEdges returns every edge between region and the outside, ordered
+// by hex (q, r) and then direction.
+func BoundaryEdges(region []hex.Axial) []Edge {
+    set := toSet(region)
+    keys := make([]hex.Axial, 0, len(set))
+    for a := range set { keys = append(keys, a) }
+    sortAxials(keys)
+    var out []Edge
+    for _, a := range keys {
+        for dir, d := range hex.Directions {
+            if !set[a.Add(d)] { out = append(out, Edge{Hex: a, Dir: dir}) }
+        }
+    }
+    return out
+}
+
+func toSet(cells []hex.Axial) map[hex.Axial]bool {
+    set := make(map[hex.Axial]bool, len(cells))
+    for _, a := range cells { set[a] = true }
+    return set
+}
+
+func axialLess(a, b hex.Axial) bool {
+    if a.Q != b.Q { return a.Q < b.Q }
+    return a.R < b.R
+}
+
+func sortAxials(s []hex.Axial) { sort.Slice(s, func(i, j int) bool { return axialLess(s[i], s[j]) }) }
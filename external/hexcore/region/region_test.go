@@ -0,0 +1,190 @@
+package region
+
+import (
+    "math"
+    "testing"
+
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+func diskSet(c hex.Axial, r int) map[hex.Axial]bool {
+    set := map[hex.Axial]bool{}
+    for _, a := range hex.Disk(c, r) { set[a] = true }
+    return set
+}
+
+func TestFillAndRegions(t *testing.T) {
+    o := hex.Axial{}
+    // two disks with a wall ring between them
+    open := diskSet(o, 2)
+    for _, a := range hex.Ring(o, 4) { open[a] = true }
+    in := func(a hex.Axial) bool { return open[a] }
+
+    if got := Fill(o, in); len(got) != 19 {
+        t.Errorf("fill of disk: %d cells, want 19", len(got))
+    }
+    if got := Fill(hex.Axial{Q: 3}, in); got != nil {
+        t.Errorf("fill from closed hex: %v", got)
+    }
+    if got := Fill(hex.Axial{Q: 4}, in); len(got) != 24 {
+        t.Errorf("fill of ring: %d cells, want 24", len(got))
+    }
+
+    regions := Regions(hex.Disk(o, 5), in)
+    if len(regions) != 2 {
+        t.Fatalf("expected 2 regions, got %d", len(regions))
+    }
+    // ordered by first cell: the ring reaches q=-4 first
+    if len(regions[0]) != 24 || len(regions[1]) != 19 {
+        t.Errorf("region sizes %d, %d", len(regions[0]), len(regions[1]))
+    }
+}
+
+func TestOutlineAndEdges(t *testing.T) {
+    c := hex.Axial{Q: 2, R: -1}
+    region := hex.Disk(c, 3)
+    outline := Outline(region)
+    ring := toSet(hex.Ring(c, 3))
+    if len(outline) != len(ring) {
+        t.Fatalf("outline has %d hexes, ring %d", len(outline), len(ring))
+    }
+    for _, a := range outline {
+        if !ring[a] { t.Errorf("%v in outline but not on ring", a) }
+    }
+
+    // a disk of radius r has 6*(2r+1) boundary edges
+    edges := BoundaryEdges(region)
+    if len(edges) != 42 {
+        t.Errorf("expected 42 edges, got %d", len(edges))
+    }
+    set := toSet(region)
+    for _, e := range edges {
+        if !set[e.Hex] || set[e.Outer()] { t.Fatalf("edge %v does not separate the region", e) }
+    }
+
+    if got := BoundaryEdges([]hex.Axial{c}); len(got) != 6 {
+        t.Errorf("single hex: %d edges", len(got))
+    }
+}
+
+func TestEdgeCorners(t *testing.T) {
+    near := func(p, q hex.Point) bool { return math.Abs(p.X-q.X) < 1e-6 && math.Abs(p.Y-q.Y) < 1e-6 }
+    for name, l := range map[string]hex.Layout{"pointy": hex.PointyLayout(10), "flat": hex.FlatLayout(10)} {
+        a := hex.Axial{Q: 1, R: 2}
+        for dir := 0; dir < 6; dir++ {
+            e := Edge{Hex: a, Dir: dir}
+            p, q := e.Corners(l)
+            // the edge is shared with the neighbor's opposite edge
+            p2, q2 := Edge{Hex: e.Outer(), Dir: (dir + 3) % 6}.Corners(l)
+            if !(near(p, p2) && near(q, q2)) && !(near(p, q2) && near(q, p2)) {
+                t.Errorf("%s dir %d: %v-%v vs neighbor %v-%v", name, dir, p, q, p2, q2)
+            }
+            if d := math.Hypot(p.X-q.X, p.Y-q.Y); math.Abs(d-10) > 1e-6 {
+                t.Errorf("%s dir %d: edge length %v", name, dir, d)
+            }
+        }
+    }
+}
+
+func TestTerritory(t *testing.T) {
+    o := hex.Axial{}
+    board := diskSet(o, 6)
+    neighbors := func(a hex.Axial) []hex.Axial {
+        var out []hex.Axial
+        for _, d := range hex.Directions {
+            if b := a.Add(d); board[b] { out = append(out, b) }
+        }
+        return out
+    }
+
+    seeds := map[int]hex.Axial{1: {Q: -3}, 2: {Q: 3}}
+    tr := NewTerritory(seeds, neighbors, nil, 0)
+    if id, _ := tr.Owner(hex.Axial{Q: -5}); id != 1 {
+        t.Errorf("west owned by %d", id)
+    }
+    if id, _ := tr.Owner(hex.Axial{Q: 5}); id != 2 {
+        t.Errorf("east owned by %d", id)
+    }
+    // the center is equidistant: lower ID wins
+    if id, _ := tr.Owner(o); id != 1 {
+        t.Errorf("tie owned by %d, want 1", id)
+    }
+    if d, _ := tr.Cost(hex.Axial{Q: 3, R: 2}); d != 2 {
+        t.Errorf("cost %d, want 2", d)
+    }
+    if n := len(tr.Region(1)) + len(tr.Region(2)); n != len(board) {
+        t.Errorf("territories cover %d of %d hexes", n, len(board))
+    }
+
+    // expensive hexes east of the center push the border east
+    cost := func(a, b hex.Axial) int {
+        if b.Q > 0 { return 3 }
+        return 1
+    }
+    weighted := NewTerritory(seeds, neighbors, cost, 0)
+    if id, _ := weighted.Owner(hex.Axial{Q: 1}); id != 1 {
+        t.Errorf("weighted border: (1,0) owned by %d", id)
+    }
+
+    limited := NewTerritory(map[int]hex.Axial{7: o}, neighbors, nil, 2)
+    if _, ok := limited.Owner(hex.Axial{Q: 3}); ok {
+        t.Errorf("hex beyond maxCost was claimed")
+    }
+    if n := len(limited.Region(7)); n != 19 {
+        t.Errorf("limited territory has %d hexes, want 19", n)
+    }
+}
+
+func TestTerritoryIncremental(t *testing.T) {
+    board := diskSet(hex.Axial{}, 7)
+    // a wall with a gap makes costs non-trivial
+    for r := -7; r <= 4; r++ { delete(board, hex.Axial{Q: 1, R: r}) }
+    neighbors := func(a hex.Axial) []hex.Axial {
+        var out []hex.Axial
+        for _, d := range hex.Directions {
+            if b := a.Add(d); board[b] { out = append(out, b) }
+        }
+        return out
+    }
+    cost := func(a, b hex.Axial) int { return 1 + (b.Q+b.R+16)%3 }
+
+    seeds := map[int]hex.Axial{1: {Q: -4, R: 1}, 2: {Q: 4, R: -2}, 3: {Q: 0, R: 5}, 4: {Q: -2, R: -3}}
+    tr := NewTerritory(seeds, neighbors, cost, 0)
+
+    moves := []struct {
+        id int
+        to hex.Axial
+    }{
+        {2, hex.Axial{Q: 3, R: 3}},
+        {1, hex.Axial{Q: 5, R: -4}},
+        {3, hex.Axial{Q: -5, R: 2}},
+        {4, hex.Axial{Q: -2, R: -3}},
+        {2, hex.Axial{Q: 0, R: 0}},
+    }
+    for i, m := range moves {
+        tr.MoveSeed(m.id, m.to)
+        seeds[m.id] = m.to
+        full := NewTerritory(seeds, neighbors, cost, 0)
+        for a := range board {
+            gi, gok := tr.Owner(a)
+            wi, wok := full.Owner(a)
+            gd, _ := tr.Cost(a)
+            wd, _ := full.Cost(a)
+            if gi != wi || gok != wok || gd != wd {
+                t.Fatalf("move %d: %v is (%d, %d), full recompute gives (%d, %d)", i, a, gi, gd, wi, wd)
+            }
+        }
+    }
+
+    tr.RemoveSeed(3)
+    delete(seeds, 3)
+    full := NewTerritory(seeds, neighbors, cost, 0)
+    for a := range board {
+        gi, _ := tr.Owner(a)
+        wi, _ := full.Owner(a)
+        if gi != wi { t.Fatalf("after removal %v owned by %d, want %d", a, gi, wi) }
+    }
+    if got := tr.Owners(); len(got) != 3 || got[0] != 1 || got[2] != 4 {
+        t.Errorf("owners %v", got)
+    }
+}
@@ -0,0 +1,182 @@
+package region
+
+import (
+    "container/heap"
+    "sort"
+
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+// Territory assigns every reachable hex to the owner seed it is cheapest
+// to reach from (a Voronoi diagram under path cost). Ties go to the lower
+// seed ID, so the assignment is deterministic. Seeds can be added,
+// removed and moved incrementally: only the hexes whose owner or cost can
+// change are recomputed.
+type Territory struct {
+    neighbors func(a hex.Axial) []hex.Axial
+    cost      func(a, b hex.Axial) int
+    maxCost   int
+
+    seeds map[int]hex.Axial
+    owner map[hex.Axial]int
+    dist  map[hex.Axial]int
+}
+
+// NewTerritory computes the territory of seeds (owner ID -> seed hex).
+// - neighbors, cost: as for path.AStar; cost(a, b) is paid moving away
+//   from the seed, from a to b (nil = 1)
+// - maxCost: hexes farther than this from every seed stay unowned (0 = no
+//   limit, which requires a finite neighbors graph)
+func NewTerritory(seeds map[int]hex.Axial,
+    neighbors func(a hex.Axial) []hex.Axial,
+    cost func(a, b hex.Axial) int,
+    maxCost int,
+) *Territory {
+    t := &Territory{
+        neighbors: neighbors,
+        cost:      cost,
+        maxCost:   maxCost,
+        seeds:     make(map[int]hex.Axial, len(seeds)),
+        owner:     make(map[hex.Axial]int),
+        dist:      make(map[hex.Axial]int),
+    }
+    var q claimQueue
+    for id, at := range seeds {
+        t.seeds[id] = at
+        q = append(q, claim{at: at, owner: id})
+    }
+    heap.Init(&q)
+    t.grow(&q)
+    return t
+}
+
+// Owner returns the owner of a.
+func (t *Territory) Owner(a hex.Axial) (int, bool) {
+    id, ok := t.owner[a]
+    return id, ok
+}
+
+// Cost returns the path cost from a's owner seed to a.
+func (t *Territory) Cost(a hex.Axial) (int, bool) {
+    d, ok := t.dist[a]
+    return d, ok
+}
+
+// Seed returns the seed hex of owner id.
+func (t *Territory) Seed(id int) (hex.Axial, bool) {
+    at, ok := t.seeds[id]
+    return at, ok
+}
+
+// Region returns the hexes owned by id, sorted by (q, r).
+func (t *Territory) Region(id int) []hex.Axial {
+    var out []hex.Axial
+    for a, o := range t.owner {
+        if o == id { out = append(out, a) }
+    }
+    sortAxials(out)
+    return out
+}
+
+// Owners returns the seed IDs in ascending order.
+func (t *Territory) Owners() []int {
+    out := make([]int, 0, len(t.seeds))
+    for id := range t.seeds { out = append(out, id) }
+    sort.Ints(out)
+    return out
+}
+
+// AddSeed adds an owner (or moves it, if id already exists). Only hexes
+// the new seed reaches more cheaply than their current owner change hands.
+func (t *Territory) AddSeed(id int, at hex.Axial) {
+    if _, ok := t.seeds[id]; ok { t.RemoveSeed(id) }
+    t.seeds[id] = at
+    q := claimQueue{{at: at, owner: id}}
+    t.grow(&q)
+}
+
+// RemoveSeed drops an owner. Its hexes are released and regrown from the
+// neighboring territories.
+func (t *Territory) RemoveSeed(id int) {
+    if _, ok := t.seeds[id]; !ok { return }
+    delete(t.seeds, id)
+
+    freed := t.Region(id)
+    for _, a := range freed {
+        delete(t.owner, a)
+        delete(t.dist, a)
+    }
+    // Every other hex keeps its cost: a cheapest path to a hex never runs
+    // through another owner's hex, so none of them depended on id.
+    // Restart the search from the owned hexes bordering the freed area.
+    var q claimQueue
+    seen := make(map[hex.Axial]bool)
+    for _, a := range freed {
+        for _, b := range t.neighbors(a) {
+            if o, ok := t.owner[b]; ok && !seen[b] {
+                seen[b] = true
+                q = append(q, claim{at: b, owner: o, dist: t.dist[b], resume: true})
+            }
+        }
+    }
+    heap.Init(&q)
+    t.grow(&q)
+}
+
+// MoveSeed moves owner id to a new seed hex.
+func (t *Territory) MoveSeed(id int, to hex.Axial) { t.AddSeed(id, to) }
+
+// grow runs the multi-source Dijkstra from the queued claims, taking a hex
+// whenever a claim beats its current (cost, owner).
+func (t *Territory) grow(q *claimQueue) {
+    for q.Len() > 0 {
+        c := heap.Pop(q).(claim)
+        if !c.resume {
+            if !t.better(c.at, c.dist, c.owner) { continue }
+            t.owner[c.at] = c.owner
+            t.dist[c.at] = c.dist
+        } else if t.owner[c.at] != c.owner || t.dist[c.at] != c.dist {
+            continue
+        }
+        for _, b := range t.neighbors(c.at) {
+            d := c.dist + t.step(c.at, b)
+            if t.maxCost > 0 && d > t.maxCost { continue }
+            if t.better(b, d, c.owner) { heap.Push(q, claim{at: b, owner: c.owner, dist: d}) }
+        }
+    }
+}
+
+// better reports whether owner reaching a at cost d beats the current owner.
+func (t *Territory) better(a hex.Axial, d, owner int) bool {
+    cur, ok := t.dist[a]
+    if !ok { return true }
+    if d != cur { return d < cur }
+    return owner < t.owner[a]
+}
+
+func (t *Territory) step(a, b hex.Axial) int {
+    if t.cost == nil { return 1 }
+    if c := t.cost(a, b); c > 0 { return c }
+    return 1
+}
+
+// claim is a seed's bid for a hex. resume marks hexes that are already
+// owned and only re-expand their neighbors.
+type claim struct {
+    at     hex.Axial
+    owner  int
+    dist   int
+    resume bool
+}
+
+type claimQueue []claim
+
+func (q claimQueue) Len() int { return len(q) }
+func (q claimQueue) Less(i, j int) bool {
+    if q[i].dist != q[j].dist { return q[i].dist < q[j].dist }
+    if q[i].owner != q[j].owner { return q[i].owner < q[j].owner }
+    return axialLess(q[i].at, q[j].at)
+}
+func (q claimQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *claimQueue) Push(x any)   { *q = append(*q, x.(claim)) }
+func (q *claimQueue) Pop() any     { old := *q; n := len(old); x := old[n-1]; *q = old[:n-1]; return x }
@@ -0,0 +1,145 @@
+package terrain
+
+import (
+    "github.com/gravitas-015/hexcore"
+    "github.com/gravitas-015/hexcore/hex"
+)
+
+// Deposit is a harvestable resource sitting on a hex.
+type Deposit struct {
+    Resource string
+    Amount   int
+}
+
+// Cell is the layered state of one hex.
+type Cell struct {
+    Terrain   TypeID
+    Elevation int8
+    Deposit   Deposit
+    Owner     uint32 // owning empire; 0 = unowned
+}
+
+// Map stores cells for a set of hexes and answers the questions other
+// packages ask about them. Hexes that are not in the map behave as Void.
+// The callback accessors (Passable, Neighbors, Cost, Opaque) plug directly
+// into hexcore/path and hexcore/fov.
+type Map struct {
+    Registry *Registry
+    cells    map[hex.Axial]Cell
+}
+
+// NewMap returns an empty map using reg for terrain types.
+func NewMap(reg *Registry) *Map {
+    return &Map{Registry: reg, cells: make(map[hex.Axial]Cell)}
+}
+
+// ImportStates adds generator output to the map, translating each state
+// through the registry. Existing layers other than terrain are kept.
+func (m *Map) ImportStates(states map[hex.Axial]hexcore.HexState) {
+    for a, s := range states {
+        c := m.cells[a]
+        c.Terrain = m.Registry.FromState(s)
+        m.cells[a] = c
+    }
+}
+
+// Len returns the number of hexes in the map.
+func (m *Map) Len() int { return len(m.cells) }
+
+// Cell returns the cell at a.
+func (m *Map) Cell(a hex.Axial) (Cell, bool) {
+    c, ok := m.cells[a]
+    return c, ok
+}
+
+// Set replaces the cell at a.
+func (m *Map) Set(a hex.Axial, c Cell) { m.cells[a] = c }
+
+// SetTerrain changes the terrain layer at a.
+func (m *Map) SetTerrain(a hex.Axial, id TypeID) {
+    c := m.cells[a]
+    c.Terrain = id
+    m.cells[a] = c
+}
+
+// SetElevation changes the elevation layer at a.
+func (m *Map) SetElevation(a hex.Axial, e int8) {
+    c := m.cells[a]
+    c.Elevation = e
+    m.cells[a] = c
+}
+
+// SetDeposit changes the resource deposit at a.
+func (m *Map) SetDeposit(a hex.Axial, d Deposit) {
+    c := m.cells[a]
+    c.Deposit = d
+    m.cells[a] = c
+}
+
+// SetOwner changes the ownership layer at a.
+func (m *Map) SetOwner(a hex.Axial, owner uint32) {
+    c := m.cells[a]
+    c.Owner = owner
+    m.cells[a] = c
+}
+
+// Type returns the terrain type at a (Void outside the map).
+func (m *Map) Type(a hex.Axial) *Type {
+    c, ok := m.cells[a]
+    if !ok { c.Terrain = Void }
+    if t, ok := m.Registry.Get(c.Terrain); ok { return t }
+    return &voidType
+}
+
+var voidType = Type{ID: Void, Name: "void", BlocksVision: true}
+
+// Buildable reports whether structures may be placed at a.
+func (m *Map) Buildable(a hex.Axial) bool {
+    _, ok := m.cells[a]
+    return ok && m.Type(a).Buildable
+}
+
+// Yield returns the resources one harvest of a produces: the terrain's
+// base yield plus any deposit.
+func (m *Map) Yield(a hex.Axial) map[string]int {
+    out := map[string]int{}
+    for res, n := range m.Type(a).Yield { out[res] += n }
+    if d := m.cells[a].Deposit; d.Resource != "" && d.Amount > 0 { out[d.Resource] += d.Amount }
+    return out
+}
+
+// Passable returns a predicate telling whether class may enter a hex.
+func (m *Map) Passable(class UnitClass) func(a hex.Axial) bool {
+    return func(a hex.Axial) bool { return m.Type(a).Passable.Has(class) }
+}
+
+// Neighbors returns a neighbor function for path searches that yields the
+// adjacent hexes class may enter.
+func (m *Map) Neighbors(class UnitClass) func(a hex.Axial) []hex.Axial {
+    passable := m.Passable(class)
+    return func(a hex.Axial) []hex.Axial {
+        out := make([]hex.Axial, 0, 6)
+        for _, d := range hex.Directions {
+            if b := a.Add(d); passable(b) { out = append(out, b) }
+        }
+        return out
+    }
+}
+
+// Cost returns an edge cost function for path searches: the destination's
+// terrain move cost, plus one per level climbed for ground units.
+func (m *Map) Cost(class UnitClass) func(a, b hex.Axial) int {
+    return func(a, b hex.Axial) int {
+        cost := m.Type(b).MoveCost
+        if cost < 1 { cost = 1 }
+        if class == Ground {
+            if climb := int(m.cells[b].Elevation) - int(m.cells[a].Elevation); climb > 0 { cost += climb }
+        }
+        return cost
+    }
+}
+
+// Opaque returns a sight-blocking predicate for field-of-view queries.
+func (m *Map) Opaque() func(a hex.Axial) bool {
+    return func(a hex.Axial) bool { return m.Type(a).BlocksVision }
+}
@@ -0,0 +1,166 @@
+// Package terrain is the shared terrain model: a registry of terrain types
+// and per-hex layers (terrain, elevation, resource deposit, ownership).
+// Map generation, pathfinding, vision and the game map all read the same
+// types from here instead of keeping their own notion of what a hex is.
+package terrain
+
+import (
+    "fmt"
+    "strconv"
+
+    "github.com/gravitas-015/hexcore"
+)
+
+// TypeID identifies a terrain type in a Registry.
+type TypeID uint8
+
+// UnitClass is a movement class. Passability is defined per class.
+type UnitClass uint8
+
+const (
+    Ground UnitClass = iota
+    Naval
+    Air
+)
+
+// ClassMask is a set of unit classes.
+type ClassMask uint8
+
+// Classes returns the mask containing cs.
+func Classes(cs ...UnitClass) ClassMask {
+    var m ClassMask
+    for _, c := range cs { m |= 1 << c }
+    return m
+}
+
+// Has reports whether c is in the mask.
+func (m ClassMask) Has(c UnitClass) bool { return m&(1<<c) != 0 }
+
+// Type describes one kind of terrain.
+type Type struct {
+    ID           TypeID
+    Name         string         // stable name, used in configs
+    MoveCost     int            // cost to enter a hex of this type (>= 1)
+    Passable     ClassMask      // unit classes that may enter
+    Buildable    bool           // structures may be placed here
+    BlocksVision bool           // hexes behind this one are hidden
+    Yield        map[string]int // base resource output per harvest
+}
+
+// Built-in terrain types. Void and Plains line up with hexcore.Dead and
+// hexcore.Space so generator output maps over directly.
+const (
+    Void TypeID = iota
+    Plains
+    Forest
+    Hills
+    Mountains
+    Water
+)
+
+// builtinNames are the names of the built-in types, indexed by ID. Every
+// registry keeps these IDs for these names, so a TypeID can be written by
+// name without knowing the registry.
+var builtinNames = [...]string{
+    Void:      "void",
+    Plains:    "plains",
+    Forest:    "forest",
+    Hills:     "hills",
+    Mountains: "mountains",
+    Water:     "water",
+}
+
+// MarshalText writes a built-in type by name, so the wire format does not
+// depend on ID order. Other types are written as their number.
+func (id TypeID) MarshalText() ([]byte, error) {
+    if int(id) < len(builtinNames) { return []byte(builtinNames[id]), nil }
+    return strconv.AppendUint(nil, uint64(id), 10), nil
+}
+
+// UnmarshalText reads a built-in type name or a type number.
+func (id *TypeID) UnmarshalText(text []byte) error {
+    for i, name := range builtinNames {
+        if name == string(text) { *id = TypeID(i); return nil }
+    }
+    n, err := strconv.ParseUint(string(text), 10, 8)
+    if err != nil { return fmt.Errorf("unknown terrain %q", text) }
+    *id = TypeID(n)
+    return nil
+}
+
+// Registry holds the terrain types in play. Lookups by ID are array
+// indexed, so they are cheap enough for pathfinding inner loops.
+type Registry struct {
+    byID   [256]*Type
+    byName map[string]TypeID
+    states map[hexcore.HexState]TypeID
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+    return &Registry{
+        byName: make(map[string]TypeID),
+        states: make(map[hexcore.HexState]TypeID),
+    }
+}
+
+// DefaultRegistry returns a registry with the built-in types. Generator
+// states map as Dead -> Void, Space and Overlay -> Plains.
+func DefaultRegistry() *Registry {
+    r := NewRegistry()
+    land := Classes(Ground, Air)
+    for _, t := range []Type{
+        {ID: Void, Name: builtinNames[Void], BlocksVision: true},
+        {ID: Plains, Name: builtinNames[Plains], MoveCost: 1, Passable: land, Buildable: true},
+        {ID: Forest, Name: builtinNames[Forest], MoveCost: 2, Passable: land, BlocksVision: true, Yield: map[string]int{"wood": 2}},
+        {ID: Hills, Name: builtinNames[Hills], MoveCost: 2, Passable: land, Buildable: true, Yield: map[string]int{"stone": 1}},
+        {ID: Mountains, Name: builtinNames[Mountains], MoveCost: 3, Passable: Classes(Air), BlocksVision: true, Yield: map[string]int{"ore": 1}},
+        {ID: Water, Name: builtinNames[Water], MoveCost: 1, Passable: Classes(Naval, Air), Yield: map[string]int{"food": 1}},
+    } {
+        if err := r.Register(t); err != nil { panic(err) }
+    }
+    r.MapState(hexcore.Dead, Void)
+    r.MapState(hexcore.Space, Plains)
+    r.MapState(hexcore.Overlay, Plains)
+    return r
+}
+
+// Register adds a terrain type. IDs and names must be unique, the built-in
+// IDs (Void to Water) are reserved for their built-in names, and a type
+// that any class can enter needs a move cost of at least 1.
+func (r *Registry) Register(t Type) error {
+    if t.Name == "" { return fmt.Errorf("terrain %d: empty name", t.ID) }
+    if int(t.ID) < len(builtinNames) && t.Name != builtinNames[t.ID] {
+        return fmt.Errorf("terrain %q: id %d is reserved for %q", t.Name, t.ID, builtinNames[t.ID])
+    }
+    if r.byID[t.ID] != nil { return fmt.Errorf("terrain %d: id already registered as %q", t.ID, r.byID[t.ID].Name) }
+    if _, ok := r.byName[t.Name]; ok { return fmt.Errorf("terrain %q: name already registered", t.Name) }
+    if t.Passable != 0 && t.MoveCost < 1 { return fmt.Errorf("terrain %q: move cost must be >= 1", t.Name) }
+    tc := t
+    r.byID[t.ID] = &tc
+    r.byName[t.Name] = t.ID
+    return nil
+}
+
+// MapState sets the terrain a generator state becomes on import.
+func (r *Registry) MapState(s hexcore.HexState, id TypeID) { r.states[s] = id }
+
+// FromState returns the terrain for a generator state; unknown states are
+// Void.
+func (r *Registry) FromState(s hexcore.HexState) TypeID {
+    if id, ok := r.states[s]; ok { return id }
+    return Void
+}
+
+// Get returns the type with the given ID.
+func (r *Registry) Get(id TypeID) (*Type, bool) {
+    t := r.byID[id]
+    return t, t != nil
+}
+
+// Lookup returns the type with the given name.
+func (r *Registry) Lookup(name string) (*Type, bool) {
+    id, ok := r.byName[name]
+    if !ok { return nil, false }
+    return r.byID[id], true
+}
@@ -0,0 +1,138 @@
+package terrain
+
+import (
+    "encoding/json"
+    "testing"
+
+    "github.com/gravitas-015/hexcore"
+    "github.com/gravitas-015/hexcore/fov"
+    "github.com/gravitas-015/hexcore/hex"
+    "github.com/gravitas-015/hexcore/path"
+)
+
+func TestRegistry(t *testing.T) {
+    r := DefaultRegistry()
+    if f, ok := r.Lookup("forest"); !ok || f.ID != Forest || !f.BlocksVision || f.Yield["wood"] != 2 {
+        t.Errorf("unexpected forest: %+v", f)
+    }
+    if m, _ := r.Get(Mountains); m.Passable.Has(Ground) || !m.Passable.Has(Air) {
+        t.Errorf("mountains should be air-only: %+v", m)
+    }
+    if r.FromState(hexcore.Dead) != Void || r.FromState(hexcore.Space) != Plains || r.FromState(hexcore.Overlay) != Plains {
+        t.Errorf("generator states mapped wrongly")
+    }
+    if r.FromState(hexcore.HexState(99)) != Void {
+        t.Errorf("unknown state should be void")
+    }
+
+    tests := []struct {
+        name string
+        typ  Type
+    }{
+        {"duplicate id", Type{ID: Plains, Name: "grass", MoveCost: 1}},
+        {"duplicate name", Type{ID: 40, Name: "plains", MoveCost: 1}},
+        {"empty name", Type{ID: 41}},
+        {"zero cost", Type{ID: 42, Name: "ice", Passable: Classes(Ground)}},
+        {"reserved id", Type{ID: Water, Name: "lava", MoveCost: 1}},
+    }
+    for _, tt := range tests {
+        if err := r.Register(tt.typ); err == nil {
+            t.Errorf("%s: expected error", tt.name)
+        }
+    }
+    if err := r.Register(Type{ID: 42, Name: "swamp", MoveCost: 4, Passable: Classes(Ground)}); err != nil {
+        t.Errorf("register swamp: %v", err)
+    }
+}
+
+func TestMapLayers(t *testing.T) {
+    m := NewMap(DefaultRegistry())
+    m.ImportStates(map[hex.Axial]hexcore.HexState{
+        {Q: 0, R: 0}: hexcore.Space,
+        {Q: 1, R: 0}: hexcore.Space,
+        {Q: 2, R: 0}: hexcore.Dead,
+    })
+    m.SetTerrain(hex.Axial{Q: 1, R: 0}, Forest)
+    m.SetElevation(hex.Axial{Q: 1, R: 0}, 2)
+    m.SetDeposit(hex.Axial{Q: 1, R: 0}, Deposit{Resource: "wood", Amount: 3})
+    m.SetOwner(hex.Axial{Q: 0, R: 0}, 7)
+
+    o, f, v, out := hex.Axial{Q: 0, R: 0}, hex.Axial{Q: 1, R: 0}, hex.Axial{Q: 2, R: 0}, hex.Axial{Q: 9, R: 9}
+    if c, _ := m.Cell(f); c.Terrain != Forest || c.Elevation != 2 || c.Deposit.Amount != 3 {
+        t.Errorf("layers not kept: %+v", c)
+    }
+    if c, _ := m.Cell(o); c.Owner != 7 || c.Terrain != Plains {
+        t.Errorf("owner layer not kept: %+v", c)
+    }
+
+    ground := m.Passable(Ground)
+    if !ground(o) || !ground(f) || ground(v) || ground(out) {
+        t.Errorf("ground passability wrong")
+    }
+    if !m.Buildable(o) || m.Buildable(f) || m.Buildable(out) {
+        t.Errorf("buildability wrong")
+    }
+    if y := m.Yield(f); y["wood"] != 5 {
+        t.Errorf("expected 5 wood (2 base + 3 deposit), got %v", y)
+    }
+    cost := m.Cost(Ground)
+    if c := cost(o, f); c != 4 {
+        t.Errorf("expected forest cost 2 + climb 2, got %d", c)
+    }
+    if c := cost(f, o); c != 1 {
+        t.Errorf("downhill into plains should cost 1, got %d", c)
+    }
+    if c := m.Cost(Air)(o, f); c != 2 {
+        t.Errorf("air units ignore elevation, got %d", c)
+    }
+    opaque := m.Opaque()
+    if opaque(o) || !opaque(f) || !opaque(out) {
+        t.Errorf("vision blocking wrong")
+    }
+}
+
+func TestMapDrivesPathAndVision(t *testing.T) {
+    m := NewMap(DefaultRegistry())
+    for _, a := range hex.Disk(hex.Axial{}, 4) { m.SetTerrain(a, Plains) }
+    // a mountain ridge with a forest gap
+    for r := -4; r <= 4; r++ { m.SetTerrain(hex.Axial{Q: 1, R: r}, Mountains) }
+    m.SetTerrain(hex.Axial{Q: 1, R: 0}, Forest)
+
+    start, goal := hex.Axial{Q: -1, R: 0}, hex.Axial{Q: 3, R: 0}
+    p := path.AStar(start, goal, path.HeuristicTo(goal), m.Neighbors(Ground), m.Cost(Ground))
+    if p == nil {
+        t.Fatalf("no ground path through the forest gap")
+    }
+    through := false
+    for _, a := range p { through = through || a == (hex.Axial{Q: 1, R: 0}) }
+    if !through {
+        t.Errorf("ground path should use the forest gap: %v", p)
+    }
+
+    vis := fov.Compute(start, 4, m.Opaque())
+    if !vis[hex.Axial{Q: 1, R: 0}] || vis[goal] {
+        t.Errorf("forest should be seen but hide what is behind it")
+    }
+}
+
+func TestTypeIDText(t *testing.T) {
+    data, err := json.Marshal(Cell{Terrain: Forest, Owner: 3})
+    if err != nil { t.Fatalf("marshal: %v", err) }
+    if want := `{"Terrain":"forest","Elevation":0,"Deposit":{"Resource":"","Amount":0},"Owner":3}`; string(data) != want {
+        t.Errorf("got %s, want %s", data, want)
+    }
+    var c Cell
+    if err := json.Unmarshal(data, &c); err != nil || c.Terrain != Forest {
+        t.Errorf("round trip gave %+v, %v", c, err)
+    }
+
+    for id, text := range map[TypeID]string{Void: "void", Water: "water", 42: "42"} {
+        got, _ := id.MarshalText()
+        var back TypeID
+        if string(got) != text || back.UnmarshalText(got) != nil || back != id {
+            t.Errorf("%d: marshalled %q, read back %d", id, got, back)
+        }
+    }
+    var bad TypeID
+    if bad.UnmarshalText([]byte("lava")) == nil { t.Error("expected error for unknown name") }
+}
@@ -3,7 +3,11 @@ package hexcore
 // HexState represents the state of a single hex cell.
 type HexState int
 
+// Generator states. Overlay marks generator-carved connections (spurs and
+// links) that are open like Space. See the terrain package for how states
+// map onto terrain types.
 const (
-	Dead  HexState = 0
-	Space HexState = 1
+	Dead    HexState = 0
+	Space   HexState = 1
+	Overlay HexState = 2
 )
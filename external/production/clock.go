@@ -0,0 +1,132 @@
+package production
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock supplies the current time to a Manager. Every time-dependent
+// operation (job start and end times, progress, cancellation, queue events)
+// reads it, so a clock driven by the game loop controls production
+// completely.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock (the default).
+type SystemClock struct{}
+
+// Now returns time.Now().
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// TickClock derives time from a tick counter: Now is start + tick*step.
+// Recipe durations are then effectively measured in ticks (a 5s recipe with
+// a 100ms step takes 50 ticks), and production freezes whenever the game
+// stops ticking, e.g. while the session is paused.
+type TickClock struct {
+	mu    sync.RWMutex
+	start time.Time
+	step  time.Duration
+	tick  int64
+}
+
+// NewTickClock creates a tick clock at tick 0.
+func NewTickClock(start time.Time, step time.Duration) *TickClock {
+	return &TickClock{start: start, step: step}
+}
+
+// Now returns the time of the current tick.
+func (c *TickClock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.start.Add(time.Duration(c.tick) * c.step)
+}
+
+// Advance moves the clock forward by n ticks and returns the new tick.
+func (c *TickClock) Advance(n int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n > 0 {
+		c.tick += n
+	}
+	return c.tick
+}
+
+// SetTick jumps to an absolute tick (e.g. the server's tick counter).
+// Ticks never go backwards; an earlier tick is ignored.
+func (c *TickClock) SetTick(tick int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if tick > c.tick {
+		c.tick = tick
+	}
+}
+
+// Tick returns the current tick.
+func (c *TickClock) Tick() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tick
+}
+
+// TimeAt returns the time the clock will read at tick, e.g. to expire a
+// buff "until tick N".
+func (c *TickClock) TimeAt(tick int64) time.Time {
+	return c.start.Add(time.Duration(tick) * c.step)
+}
+
+// PausableClock wraps another clock and can be paused: while paused Now
+// stands still, and after Resume it continues from where it stopped, so
+// jobs lose no progress and gain none during the pause.
+type PausableClock struct {
+	mu       sync.RWMutex
+	base     Clock
+	offset   time.Duration // total time spent paused
+	pausedAt time.Time
+	paused   bool
+}
+
+// NewPausableClock wraps base (nil means SystemClock).
+func NewPausableClock(base Clock) *PausableClock {
+	if base == nil {
+		base = SystemClock{}
+	}
+	return &PausableClock{base: base}
+}
+
+// Now returns the base time minus all time spent paused.
+func (c *PausableClock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.paused {
+		return c.pausedAt.Add(-c.offset)
+	}
+	return c.base.Now().Add(-c.offset)
+}
+
+// Pause freezes the clock. Pausing a paused clock does nothing.
+func (c *PausableClock) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		c.paused = true
+		c.pausedAt = c.base.Now()
+	}
+}
+
+// Resume restarts a paused clock.
+func (c *PausableClock) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		c.paused = false
+		c.offset += c.base.Now().Sub(c.pausedAt)
+	}
+}
+
+// Paused reports whether the clock is paused.
+func (c *PausableClock) Paused() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.paused
+}
@@ -0,0 +1,71 @@
+package production
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitas-015/inventory"
+)
+
+func TestTickClockDrivesManager(t *testing.T) {
+	registry := NewRecipeRegistry()
+	registry.Register(&Recipe{
+		ID:       "plank",
+		Outputs:  []ItemYield{{Item: "plank", Quantity: 1, Probability: 1.0}},
+		Duration: 5 * time.Second,
+	})
+	invProvider := NewSimpleInventoryProvider()
+	inv := inventory.NewVolume("inv", "player1", 1000)
+	invProvider.AddInventory(inv)
+
+	clock := NewTickClock(time.Unix(1000, 0), time.Second)
+	mgr := NewManager("test", registry, invProvider, NewNullEventBus(), nil)
+	mgr.SetClock(clock)
+
+	id, err := mgr.StartProduction("plank", "player1", "inv")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if job := mgr.GetJob(id); !job.StartTime.Equal(time.Unix(1000, 0)) {
+		t.Errorf("job started at %v, want tick 0", job.StartTime)
+	}
+
+	clock.Advance(3)
+	mgr.UpdateNow()
+	if job := mgr.GetJob(id); job == nil || job.Progress != 0.6 {
+		t.Fatalf("expected 60%% progress after 3 of 5 ticks, got %+v", job)
+	}
+
+	clock.Advance(2)
+	mgr.UpdateNow()
+	if mgr.GetJob(id) != nil || countItem(inv, "plank") != 1 {
+		t.Error("job should complete on tick 5")
+	}
+}
+
+func TestPausableClock(t *testing.T) {
+	base := NewTickClock(time.Unix(0, 0), time.Second)
+	clock := NewPausableClock(base)
+
+	base.Advance(2)
+	clock.Pause()
+	clock.Pause() // no-op
+	base.Advance(10)
+	if got := clock.Now(); !got.Equal(time.Unix(2, 0)) {
+		t.Errorf("paused clock moved to %v", got)
+	}
+
+	clock.Resume()
+	base.Advance(3)
+	if got := clock.Now(); !got.Equal(time.Unix(5, 0)) {
+		t.Errorf("resumed clock at %v, want 5s (pause excluded)", got)
+	}
+	if clock.Paused() {
+		t.Error("clock should not be paused")
+	}
+
+	base.SetTick(1) // ticks never go backwards
+	if base.Tick() != 15 {
+		t.Errorf("tick went backwards to %d", base.Tick())
+	}
+}
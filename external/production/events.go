@@ -2,6 +2,7 @@ package production
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gravitas-015/inventory"
@@ -21,6 +22,28 @@ const (
 	EventJobFailed
 	// EventJobCancelled is emitted when a job is cancelled.
 	EventJobCancelled
+	// EventJobQueued is emitted when a job joins a producer queue.
+	EventJobQueued
+	// EventJobDequeued is emitted when a queued job is removed without starting.
+	EventJobDequeued
+	// EventQueueReordered is emitted when a queued job changes position or priority.
+	EventQueueReordered
+	// EventJobWaiting is emitted when a queued job has a free slot but cannot
+	// start yet (e.g., inputs not available).
+	EventJobWaiting
+	// EventJobBlocked is emitted when a finished job's outputs do not fit in
+	// the target inventory and are buffered.
+	EventJobBlocked
+	// EventJobUnblocked is emitted when buffered outputs have been delivered.
+	EventJobUnblocked
+	// EventJobModifiersChanged is emitted when a running job is retimed
+	// because its modifiers changed.
+	EventJobModifiersChanged
+	// EventJobStalled is emitted when a job's upkeep falls short and it
+	// slows down or stops (brownout).
+	EventJobStalled
+	// EventJobRecovered is emitted when a stalled job's upkeep is met again.
+	EventJobRecovered
 )
 
 // String returns a human-readable representation of the event type.
@@ -36,6 +59,24 @@ func (t EventType) String() string {
 		return "JobFailed"
 	case EventJobCancelled:
 		return "JobCancelled"
+	case EventJobQueued:
+		return "JobQueued"
+	case EventJobDequeued:
+		return "JobDequeued"
+	case EventQueueReordered:
+		return "QueueReordered"
+	case EventJobWaiting:
+		return "JobWaiting"
+	case EventJobBlocked:
+		return "JobBlocked"
+	case EventJobUnblocked:
+		return "JobUnblocked"
+	case EventJobModifiersChanged:
+		return "JobModifiersChanged"
+	case EventJobStalled:
+		return "JobStalled"
+	case EventJobRecovered:
+		return "JobRecovered"
 	default:
 		return "Unknown"
 	}
@@ -61,56 +102,278 @@ type EventBus interface {
 	Publish(event Event)
 }
 
-// SimpleEventBus is a basic in-memory event bus implementation.
+// AnyOwner subscribes to the events of every owner, including events with
+// no owner.
+const AnyOwner inventory.OwnerID = "*"
+
+// OverflowPolicy decides what Publish does when a subscriber's queue is full.
+type OverflowPolicy int
+
+const (
+	// Block makes Publish wait for room (backpressure): nothing is lost,
+	// but a slow handler slows the publishing manager down.
+	Block OverflowPolicy = iota
+	// DropNewest discards the event being published.
+	DropNewest
+	// DropOldest discards the oldest queued event to make room.
+	DropOldest
+)
+
+// String returns the policy name.
+func (p OverflowPolicy) String() string {
+	switch p {
+	case Block:
+		return "Block"
+	case DropNewest:
+		return "DropNewest"
+	case DropOldest:
+		return "DropOldest"
+	default:
+		return "Unknown"
+	}
+}
+
+// SimpleEventBus is an in-memory event bus with ordered delivery.
+//
+// Every subscription has its own bounded queue and worker goroutine, so a
+// handler sees events one at a time in publish order (Started before
+// Completed) and a slow handler only delays itself. An owner can have any
+// number of subscriptions; AnyOwner subscriptions see every event, and
+// subscriptions to the empty owner see events that have no owner.
+//
+// Under the default Block policy Manager waits, holding its lock, while a
+// handler's queue is full. A handler that calls back into the same manager
+// (GetJob, StartProduction, ...) can then deadlock; such handlers should use
+// a drop policy or a large enough buffer.
 type SimpleEventBus struct {
-	mu        sync.RWMutex
-	handlers  map[inventory.OwnerID]func(Event)
+	mu         sync.RWMutex
+	subs       map[inventory.OwnerID][]*subscription
 	bufferSize int
+	policy     OverflowPolicy
+	dropped    atomic.Uint64
+	closed     bool
+}
+
+// subscription is one handler with its queue.
+type subscription struct {
+	handler func(Event)
+
+	mu      sync.Mutex
+	cond    *sync.Cond // signalled on every queue change
+	queue   []Event
+	busy    bool // handler running
+	stopped bool
 }
 
 // NewSimpleEventBus creates a new event bus with default buffer size.
 func NewSimpleEventBus() *SimpleEventBus {
-	return &SimpleEventBus{
-		handlers:   make(map[inventory.OwnerID]func(Event)),
-		bufferSize: 100, // Default buffer size
-	}
+	return NewSimpleEventBusWithBuffer(100)
 }
 
-// NewSimpleEventBusWithBuffer creates a new event bus with specified buffer size.
+// NewSimpleEventBusWithBuffer creates a new event bus with specified buffer
+// size (events queued per subscription; at least 1).
 func NewSimpleEventBusWithBuffer(bufferSize int) *SimpleEventBus {
 	return &SimpleEventBus{
-		handlers:   make(map[inventory.OwnerID]func(Event)),
-		bufferSize: bufferSize,
+		subs:       make(map[inventory.OwnerID][]*subscription),
+		bufferSize: max(bufferSize, 1),
 	}
 }
 
-// Subscribe registers a handler for events for a specific owner.
+// SetOverflowPolicy sets what happens when a subscription's queue is full
+// (Block by default).
+func (bus *SimpleEventBus) SetOverflowPolicy(policy OverflowPolicy) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	bus.policy = policy
+}
+
+// Subscribe adds a handler for events for a specific owner (or AnyOwner).
+// Earlier handlers for the owner stay subscribed.
 func (bus *SimpleEventBus) Subscribe(owner inventory.OwnerID, handler func(Event)) {
+	bus.Listen(owner, handler)
+}
+
+// Listen is Subscribe returning a function that removes just this handler.
+// Events still queued for it are discarded.
+func (bus *SimpleEventBus) Listen(owner inventory.OwnerID, handler func(Event)) (cancel func()) {
 	bus.mu.Lock()
 	defer bus.mu.Unlock()
-	bus.handlers[owner] = handler
+	if bus.closed {
+		return func() {}
+	}
+
+	sub := &subscription{handler: handler}
+	sub.cond = sync.NewCond(&sub.mu)
+	bus.subs[owner] = append(bus.subs[owner], sub)
+	go sub.run()
+
+	return func() {
+		bus.mu.Lock()
+		list := bus.subs[owner]
+		for i, s := range list {
+			if s == sub {
+				bus.subs[owner] = append(list[:i:i], list[i+1:]...)
+				break
+			}
+		}
+		bus.mu.Unlock()
+		sub.stop(true)
+	}
 }
 
-// Unsubscribe removes the handler for an owner.
+// Unsubscribe removes every handler for an owner. Events still queued for
+// them are discarded.
 func (bus *SimpleEventBus) Unsubscribe(owner inventory.OwnerID) {
 	bus.mu.Lock()
-	defer bus.mu.Unlock()
-	delete(bus.handlers, owner)
+	subs := bus.subs[owner]
+	delete(bus.subs, owner)
+	bus.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.stop(true)
+	}
 }
 
-// Publish sends an event to subscribed handlers.
-// Handlers are called asynchronously in separate goroutines to prevent blocking.
+// Publish queues an event for the owner's handlers and the AnyOwner
+// handlers. Events without a job or owner go to the empty owner's handlers.
+//
+// The job is copied when published: handlers run later on their own
+// goroutines, and see the job as it was at the event, not as the manager
+// has changed it since (e.g. a repeating job already restarted).
 func (bus *SimpleEventBus) Publish(event Event) {
+	var owner inventory.OwnerID
+	if event.Job != nil {
+		owner = event.Job.Owner
+	}
+
+	bus.mu.RLock()
+	if bus.closed {
+		bus.mu.RUnlock()
+		return
+	}
+	targets := make([]*subscription, 0, len(bus.subs[owner])+len(bus.subs[AnyOwner]))
+	targets = append(targets, bus.subs[owner]...)
+	if owner != AnyOwner {
+		targets = append(targets, bus.subs[AnyOwner]...)
+	}
+	policy := bus.policy
+	bus.mu.RUnlock()
+
+	if len(targets) == 0 {
+		return
+	}
+	if event.Job != nil {
+		event.Job = event.Job.Clone()
+	}
+	for _, sub := range targets {
+		if !sub.push(event, bus.bufferSize, policy) {
+			bus.dropped.Add(1)
+		}
+	}
+}
+
+// Dropped returns the number of events discarded by the overflow policy.
+func (bus *SimpleEventBus) Dropped() uint64 {
+	return bus.dropped.Load()
+}
+
+// Flush waits until every event published so far has been handled.
+func (bus *SimpleEventBus) Flush() {
+	for _, sub := range bus.all() {
+		sub.mu.Lock()
+		for (len(sub.queue) > 0 || sub.busy) && !sub.stopped {
+			sub.cond.Wait()
+		}
+		sub.mu.Unlock()
+	}
+}
+
+// Close delivers the events already queued, then stops all workers. Later
+// publishes and subscriptions are ignored.
+func (bus *SimpleEventBus) Close() {
+	bus.mu.Lock()
+	bus.closed = true
+	bus.mu.Unlock()
+
+	bus.Flush()
+	for _, sub := range bus.all() {
+		sub.stop(false)
+	}
+}
+
+// all returns every subscription.
+func (bus *SimpleEventBus) all() []*subscription {
 	bus.mu.RLock()
 	defer bus.mu.RUnlock()
+	var subs []*subscription
+	for _, list := range bus.subs {
+		subs = append(subs, list...)
+	}
+	return subs
+}
+
+// push queues an event, applying policy if the queue is full. Returns false
+// if an event was dropped.
+func (s *subscription) push(event Event, size int, policy OverflowPolicy) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivered := true
+	for len(s.queue) >= size && !s.stopped {
+		switch policy {
+		case DropNewest:
+			return false
+		case DropOldest:
+			s.queue = s.queue[1:]
+			delivered = false
+		default:
+			s.cond.Wait()
+		}
+	}
+	if s.stopped {
+		return false
+	}
+	s.queue = append(s.queue, event)
+	s.cond.Broadcast()
+	return delivered
+}
 
-	// Get handler for the job's owner
-	if event.Job != nil && event.Job.Owner != "" {
-		if handler, exists := bus.handlers[event.Job.Owner]; exists {
-			// Call handler asynchronously to prevent blocking
-			go handler(event)
+// run delivers queued events in order until the subscription stops.
+func (s *subscription) run() {
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.stopped {
+			s.cond.Wait()
 		}
+		if s.stopped {
+			s.mu.Unlock()
+			return
+		}
+		event := s.queue[0]
+		s.queue = s.queue[1:]
+		s.busy = true
+		s.cond.Broadcast()
+		s.mu.Unlock()
+
+		s.handler(event)
+
+		s.mu.Lock()
+		s.busy = false
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	}
+}
+
+// stop ends the worker after its current event, optionally discarding what
+// is still queued.
+func (s *subscription) stop(discard bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if discard {
+		s.queue = nil
 	}
+	s.stopped = true
+	s.cond.Broadcast()
 }
 
 // NullEventBus is an event bus that does nothing (for testing or when events not needed).
@@ -0,0 +1,196 @@
+package production
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gravitas-015/inventory"
+)
+
+func ownerEvent(owner inventory.OwnerID, n int) Event {
+	return Event{Type: EventJobProgress, Job: &Job{Owner: owner}, Data: map[string]any{"n": n}}
+}
+
+func TestSimpleEventBusOrdered(t *testing.T) {
+	bus := NewSimpleEventBusWithBuffer(4)
+	defer bus.Close()
+
+	var mu sync.Mutex
+	var first, second, all, none []int
+	record := func(list *[]int) func(Event) {
+		return func(e Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			*list = append(*list, e.Data["n"].(int))
+		}
+	}
+	bus.Subscribe("player1", record(&first))
+	bus.Subscribe("player1", record(&second))
+	bus.Subscribe(AnyOwner, record(&all))
+	bus.Subscribe("", record(&none))
+
+	for i := 0; i < 50; i++ {
+		bus.Publish(ownerEvent("player1", i))
+	}
+	bus.Publish(ownerEvent("player2", 50))
+	bus.Publish(Event{Type: EventJobProgress, Data: map[string]any{"n": 51}})
+	bus.Flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+	for name, got := range map[string][]int{"first": first, "second": second} {
+		if len(got) != 50 {
+			t.Fatalf("%s handler got %d events, want 50", name, len(got))
+		}
+		for i, n := range got {
+			if n != i {
+				t.Fatalf("%s handler out of order at %d: %v", name, i, got)
+			}
+		}
+	}
+	if len(all) != 52 || all[50] != 50 || all[51] != 51 {
+		t.Errorf("wildcard handler got %v", all)
+	}
+	if len(none) != 1 || none[0] != 51 {
+		t.Errorf("no-owner handler got %v", none)
+	}
+	if n := bus.Dropped(); n != 0 {
+		t.Errorf("blocking bus dropped %d events", n)
+	}
+}
+
+func TestSimpleEventBusOverflow(t *testing.T) {
+	for _, tt := range []struct {
+		policy OverflowPolicy
+		want   []int
+	}{
+		{DropNewest, []int{0, 1, 2}},
+		{DropOldest, []int{0, 3, 4}},
+	} {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			bus := NewSimpleEventBusWithBuffer(2)
+			bus.SetOverflowPolicy(tt.policy)
+			defer bus.Close()
+
+			// The handler holds event 0 until all events are published
+			release := make(chan struct{})
+			started := make(chan struct{})
+			var got []int
+			bus.Subscribe("player1", func(e Event) {
+				n := e.Data["n"].(int)
+				if n == 0 {
+					close(started)
+					<-release
+				}
+				got = append(got, n)
+			})
+
+			bus.Publish(ownerEvent("player1", 0))
+			<-started
+			for i := 1; i < 5; i++ {
+				bus.Publish(ownerEvent("player1", i))
+			}
+			close(release)
+			bus.Flush()
+
+			if !equalInts(got, tt.want) {
+				t.Errorf("delivered %v, want %v", got, tt.want)
+			}
+			if n := bus.Dropped(); n != 2 {
+				t.Errorf("dropped %d, want 2", n)
+			}
+		})
+	}
+}
+
+func TestSimpleEventBusUnsubscribe(t *testing.T) {
+	bus := NewSimpleEventBus()
+	defer bus.Close()
+
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	count := func(name string) func(Event) {
+		return func(Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			counts[name]++
+		}
+	}
+	cancel := bus.Listen("player1", count("a"))
+	bus.Subscribe("player1", count("b"))
+
+	bus.Publish(ownerEvent("player1", 0))
+	bus.Flush()
+	cancel()
+	bus.Publish(ownerEvent("player1", 1))
+	bus.Flush()
+	bus.Unsubscribe("player1")
+	bus.Publish(ownerEvent("player1", 2))
+
+	// Give a stray delivery a chance to show up
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if counts["a"] != 1 || counts["b"] != 2 {
+		t.Errorf("unexpected deliveries: %v", counts)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSimpleEventBusSnapshotsJob(t *testing.T) {
+	registry := NewRecipeRegistry()
+	registry.Register(&Recipe{
+		ID:       "plank",
+		Outputs:  []ItemYield{{Item: "plank", Quantity: 1, Probability: 1.0}},
+		Duration: time.Minute,
+	})
+	invProvider := NewSimpleInventoryProvider()
+	invProvider.AddInventory(inventory.NewVolume("inv", "player1", 1000))
+
+	bus := NewSimpleEventBus()
+	defer bus.Close()
+	release := make(chan struct{})
+	var completed []Event
+	bus.Subscribe("player1", func(e Event) {
+		if e.Type == EventJobCompleted {
+			<-release // Read the job only after the manager restarted it
+			completed = append(completed, e)
+		}
+	})
+
+	mgr := NewManager("test", registry, invProvider, bus, nil)
+	clock := NewTickClock(time.Unix(0, 0), time.Second)
+	mgr.SetClock(clock)
+	id, err := mgr.StartRepeatingProduction("plank", "player1", "inv")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	clock.Advance(60)
+	mgr.UpdateNow()
+	if job := mgr.GetJob(id); job.State != JobRunning {
+		t.Fatalf("repeating job should have restarted, got %s", job.State)
+	}
+	close(release)
+	bus.Flush()
+
+	if len(completed) != 1 {
+		t.Fatalf("expected one completed event, got %d", len(completed))
+	}
+	job := completed[0].Job
+	if job.State != JobComplete || job.Progress != 1 || !job.EndTime.Equal(clock.TimeAt(60)) {
+		t.Errorf("completed event shows the restarted job: %s, progress %v, ends %v", job.State, job.Progress, job.EndTime)
+	}
+}
@@ -5,3 +5,5 @@ go 1.21
 require github.com/gravitas-015/inventory v0.0.0
 
 replace github.com/gravitas-015/inventory => ../inventory
+
+require gopkg.in/yaml.v3 v3.0.1
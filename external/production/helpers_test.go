@@ -0,0 +1,56 @@
+package production
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gravitas-015/inventory"
+)
+
+// recordingBus collects events synchronously for inspection.
+type recordingBus struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (b *recordingBus) Subscribe(owner inventory.OwnerID, handler func(Event)) {}
+func (b *recordingBus) Unsubscribe(owner inventory.OwnerID)                    {}
+func (b *recordingBus) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, event)
+}
+
+func (b *recordingBus) count(t EventType) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for _, e := range b.events {
+		if e.Type == t {
+			n++
+		}
+	}
+	return n
+}
+
+// newTestRegistry returns a registry holding recipes.
+func newTestRegistry(t *testing.T, recipes ...*Recipe) *RecipeRegistry {
+	t.Helper()
+	registry := NewRecipeRegistry()
+	for _, r := range recipes {
+		if err := registry.Register(r); err != nil {
+			t.Fatalf("register %s: %v", r.ID, err)
+		}
+	}
+	return registry
+}
+
+// newTestManager returns a manager over registry with a recording event bus
+// and player1's empty inventory "inv".
+func newTestManager(registry *RecipeRegistry, sources ...ModifierSource) (*Manager, *inventory.Inventory, *recordingBus) {
+	invProvider := NewSimpleInventoryProvider()
+	inv := inventory.NewVolume("inv", "player1", 1000)
+	invProvider.AddInventory(inv)
+	bus := &recordingBus{}
+	return NewManager("test", registry, invProvider, bus, sources), inv, bus
+}
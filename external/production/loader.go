@@ -0,0 +1,657 @@
+package production
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitas-015/inventory"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadError is a problem found while loading a recipe file.
+type LoadError struct {
+	File   string
+	Line   int
+	Recipe RecipeID // empty if the error is not tied to one recipe
+	Msg    string
+}
+
+// Error formats the error as file:line: recipe: message.
+func (e *LoadError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.File)
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d", e.Line)
+	}
+	if e.Recipe != "" {
+		fmt.Fprintf(&b, ": recipe %s", e.Recipe)
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Msg)
+	return b.String()
+}
+
+// LoadErrors collects every problem found in a load, in file and line order.
+type LoadErrors []*LoadError
+
+// Error joins the individual errors, one per line.
+func (e LoadErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// ParseRecipes parses and validates recipe definitions from YAML or JSON
+// (JSON is read as YAML, so both share one format). name is used in error
+// messages. If items is non-nil every item ID must be registered in it.
+//
+// The document is either a list of recipes or a mapping with a "recipes"
+// list:
+//
+//	recipes:
+//	  - id: iron_sword
+//	    name: Iron Sword
+//	    duration: 5s            # Go duration, or a number of seconds
+//	    inputs:
+//	      - {item: iron_ingot, quantity: 3}
+//	      - {item: hammer, quantity: 1, consume: false}   # tool
+//	    outputs:
+//	      - {item: iron_sword, quantity: 1}
+//	      - {item: gem, quantity: 1, probability: 0.1, pity: 9}
+//	    upkeep:                 # per second while running
+//	      - {resource: power, rate: 5}
+//
+// Inputs are consumed unless consume is false, and probability defaults to
+// 1. Unlike RecipeRegistry.Register, data files must give every recipe a
+// positive duration and at least one output. Anchors, aliases and merge keys
+// (<<) can share lists and fields between recipes.
+func ParseRecipes(name string, data []byte, items *inventory.Registry) ([]*Recipe, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, LoadErrors{{File: name, Msg: err.Error()}}
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil // empty file
+	}
+
+	list := doc.Content[0]
+	if list.Kind == yaml.MappingNode {
+		if list = mappingValue(list, "recipes"); list == nil {
+			return nil, LoadErrors{{File: name, Line: doc.Content[0].Line, Msg: `expected a "recipes" list`}}
+		}
+	}
+	if list.Kind != yaml.SequenceNode {
+		return nil, LoadErrors{{File: name, Line: list.Line, Msg: "expected a list of recipes"}}
+	}
+
+	p := recipeParser{file: name, items: items, seen: make(map[RecipeID]int)}
+	var recipes []*Recipe
+	for _, node := range list.Content {
+		if recipe := p.parse(resolveAlias(node)); recipe != nil {
+			recipes = append(recipes, recipe)
+		}
+	}
+	if len(p.errs) > 0 {
+		return nil, p.errs
+	}
+	return recipes, nil
+}
+
+// Field sets accepted in recipe files; anything else is reported as unknown.
+var (
+	recipeFields = []string{"id", "name", "category", "duration", "inputs", "outputs", "upkeep", "metadata"}
+	inputFields  = []string{"item", "quantity", "consume"}
+	outputFields = []string{"item", "quantity", "probability", "group", "weight", "pity"}
+	upkeepFields = []string{"resource", "rate"}
+)
+
+// recipeDoc is the file form of a Recipe.
+type recipeDoc struct {
+	ID       RecipeID       `yaml:"id"`
+	Name     string         `yaml:"name"`
+	Category string         `yaml:"category"`
+	Duration durationValue  `yaml:"duration"`
+	Inputs   []inputDoc     `yaml:"inputs"`
+	Outputs  []outputDoc    `yaml:"outputs"`
+	Upkeep   []upkeepDoc    `yaml:"upkeep"`
+	Metadata map[string]any `yaml:"metadata"`
+}
+
+type inputDoc struct {
+	Item     inventory.ItemID `yaml:"item"`
+	Quantity int              `yaml:"quantity"`
+	Consume  *bool            `yaml:"consume"`
+}
+
+type outputDoc struct {
+	Item        inventory.ItemID `yaml:"item"`
+	Quantity    int              `yaml:"quantity"`
+	Probability *float64         `yaml:"probability"`
+	Group       string           `yaml:"group"`
+	Weight      float64          `yaml:"weight"`
+	Pity        int              `yaml:"pity"`
+}
+
+type upkeepDoc struct {
+	Resource ResourceID `yaml:"resource"`
+	Rate     float64    `yaml:"rate"`
+}
+
+// durationValue accepts a Go duration string ("1m30s") or a number of seconds.
+type durationValue time.Duration
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *durationValue) UnmarshalYAML(node *yaml.Node) error {
+	if node.Tag == "!!int" || node.Tag == "!!float" {
+		var secs float64
+		if err := node.Decode(&secs); err != nil {
+			return err
+		}
+		*d = durationValue(secs * float64(time.Second))
+		return nil
+	}
+	parsed, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid duration %q", node.Line, node.Value)
+	}
+	*d = durationValue(parsed)
+	return nil
+}
+
+// recipeParser converts recipe nodes and collects errors.
+type recipeParser struct {
+	file  string
+	items *inventory.Registry
+	seen  map[RecipeID]int // recipe ID -> line of first definition
+	errs  LoadErrors
+}
+
+func (p *recipeParser) errorf(node *yaml.Node, id RecipeID, format string, args ...any) {
+	p.errs = append(p.errs, &LoadError{File: p.file, Line: node.Line, Recipe: id, Msg: fmt.Sprintf(format, args...)})
+}
+
+// parse converts one recipe node, or returns nil after recording errors.
+func (p *recipeParser) parse(node *yaml.Node) *Recipe {
+	if node.Kind != yaml.MappingNode {
+		p.errorf(node, "", "expected a recipe mapping")
+		return nil
+	}
+	before := len(p.errs)
+	var id RecipeID
+	if v := mappingValue(node, "id"); v != nil {
+		id = RecipeID(v.Value)
+	}
+	p.checkFields(node, id, recipeFields)
+
+	var doc recipeDoc
+	if err := node.Decode(&doc); err != nil {
+		p.errorf(node, id, "%s", strings.TrimPrefix(err.Error(), "yaml: "))
+		return nil
+	}
+
+	if id == "" {
+		p.errorf(node, "", "missing id")
+	} else if line, dup := p.seen[id]; dup {
+		p.errorf(node, id, "duplicate recipe id (first defined on line %d)", line)
+	} else {
+		p.seen[id] = node.Line
+	}
+
+	if doc.Duration <= 0 {
+		p.errorf(fieldNode(node, "duration"), id, "duration must be positive")
+	}
+
+	inputs := mappingValue(node, "inputs")
+	recipe := &Recipe{
+		ID:       id,
+		Name:     doc.Name,
+		Category: doc.Category,
+		Duration: time.Duration(doc.Duration),
+		Metadata: doc.Metadata,
+	}
+	for i, in := range doc.Inputs {
+		n := resolveAlias(inputs.Content[i])
+		p.checkFields(n, id, inputFields)
+		p.checkItem(n, id, in.Item)
+		if in.Quantity <= 0 {
+			p.errorf(fieldNode(n, "quantity"), id, "input %s: quantity must be positive", in.Item)
+		}
+		recipe.Inputs = append(recipe.Inputs, ItemRequirement{
+			Item:     in.Item,
+			Quantity: in.Quantity,
+			Consume:  in.Consume == nil || *in.Consume,
+		})
+	}
+
+	outputs := mappingValue(node, "outputs")
+	if len(doc.Outputs) == 0 {
+		p.errorf(fieldNode(node, "outputs"), id, "recipe has no outputs")
+	}
+	for i, out := range doc.Outputs {
+		n := resolveAlias(outputs.Content[i])
+		p.checkFields(n, id, outputFields)
+		p.checkItem(n, id, out.Item)
+		if out.Quantity <= 0 {
+			p.errorf(fieldNode(n, "quantity"), id, "output %s: quantity must be positive", out.Item)
+		}
+		prob := 1.0
+		if out.Probability != nil {
+			prob = *out.Probability
+		}
+		if prob <= 0 || prob > 1 {
+			p.errorf(fieldNode(n, "probability"), id, "output %s: probability must be in (0, 1], got %g", out.Item, prob)
+		}
+		if out.Weight < 0 {
+			p.errorf(fieldNode(n, "weight"), id, "output %s: weight cannot be negative", out.Item)
+		}
+		if out.Pity < 0 {
+			p.errorf(fieldNode(n, "pity"), id, "output %s: pity cannot be negative", out.Item)
+		}
+		recipe.Outputs = append(recipe.Outputs, ItemYield{
+			Item:        out.Item,
+			Quantity:    out.Quantity,
+			Probability: prob,
+			Group:       out.Group,
+			Weight:      out.Weight,
+			Pity:        out.Pity,
+		})
+	}
+
+	upkeep := mappingValue(node, "upkeep")
+	for i, u := range doc.Upkeep {
+		n := resolveAlias(upkeep.Content[i])
+		p.checkFields(n, id, upkeepFields)
+		if u.Resource == "" {
+			p.errorf(n, id, "upkeep: missing resource")
+		}
+		if u.Rate <= 0 {
+			p.errorf(fieldNode(n, "rate"), id, "upkeep %s: rate must be positive", u.Resource)
+		}
+		recipe.Upkeep = append(recipe.Upkeep, Upkeep{Resource: u.Resource, Rate: u.Rate})
+	}
+
+	if len(p.errs) > before {
+		return nil
+	}
+	return recipe
+}
+
+// checkItem validates an item reference.
+func (p *recipeParser) checkItem(node *yaml.Node, id RecipeID, item inventory.ItemID) {
+	if item == "" {
+		p.errorf(node, id, "missing item")
+		return
+	}
+	if p.items != nil {
+		if _, ok := p.items.Lookup(item); !ok {
+			p.errorf(fieldNode(node, "item"), id, "unknown item %s", item)
+		}
+	}
+}
+
+// checkFields reports keys of a mapping node that are not in allowed. Keys
+// pulled in by a merge key (<<) are checked as if written in place.
+func (p *recipeParser) checkFields(node *yaml.Node, id RecipeID, allowed []string) {
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if key.Value == mergeKey {
+			for _, m := range mergedMappings(node.Content[i+1]) {
+				p.checkFields(m, id, allowed)
+			}
+			continue
+		}
+		known := false
+		for _, a := range allowed {
+			if key.Value == a {
+				known = true
+				break
+			}
+		}
+		if !known {
+			p.errorf(key, id, "unknown field %q", key.Value)
+		}
+	}
+}
+
+// mergeKey is the YAML merge key, whose value (a mapping or a list of
+// mappings, usually aliases) supplies keys the mapping does not set itself.
+const mergeKey = "<<"
+
+// resolveAlias follows an alias to the node it refers to.
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// mergedMappings returns the mappings named by a merge key's value, in
+// priority order.
+func mergedMappings(value *yaml.Node) []*yaml.Node {
+	value = resolveAlias(value)
+	if value == nil {
+		return nil
+	}
+	if value.Kind == yaml.SequenceNode {
+		var out []*yaml.Node
+		for _, v := range value.Content {
+			if v = resolveAlias(v); v != nil && v.Kind == yaml.MappingNode {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	if value.Kind == yaml.MappingNode {
+		return []*yaml.Node{value}
+	}
+	return nil
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil.
+// Aliases are followed, and keys the mapping does not set are looked up in
+// its merged mappings, as yaml.Node.Decode does.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return resolveAlias(node.Content[i+1])
+		}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != mergeKey {
+			continue
+		}
+		for _, m := range mergedMappings(node.Content[i+1]) {
+			if v := mappingValue(m, key); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// fieldNode returns the value node for key, falling back to the mapping
+// itself so errors about missing fields point at the enclosing entry.
+func fieldNode(node *yaml.Node, key string) *yaml.Node {
+	if v := mappingValue(node, key); v != nil {
+		return v
+	}
+	return node
+}
+
+// Loader keeps a RecipeRegistry in sync with recipe files and reloads them
+// when they change. Jobs already started are unaffected by a reload: they run
+// on the inputs, outputs and duration snapshotted when they started.
+type Loader struct {
+	registry *RecipeRegistry
+	items    *inventory.Registry
+
+	mu    sync.Mutex
+	files map[string]*loadedFile
+	dirs  map[string]bool // Directories re-scanned for new files on Reload
+}
+
+// loadedFile tracks what one file contributed to the registry.
+type loadedFile struct {
+	modTime time.Time
+	size    int64
+	recipes []RecipeID
+}
+
+// NewLoader creates a loader that registers recipes into registry. items
+// may be nil to skip item ID validation.
+func NewLoader(registry *RecipeRegistry, items *inventory.Registry) *Loader {
+	return &Loader{
+		registry: registry,
+		items:    items,
+		files:    make(map[string]*loadedFile),
+		dirs:     make(map[string]bool),
+	}
+}
+
+// LoadFiles loads recipe files (.yaml, .yml or .json) and tracks them for
+// Reload. Files are all-or-nothing: if any file has errors nothing is
+// registered and the errors are returned as LoadErrors.
+func (l *Loader) LoadFiles(paths ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	set := make(map[string]bool, len(l.files)+len(paths))
+	for path := range l.files {
+		set[path] = true
+	}
+	for _, path := range paths {
+		set[filepath.Clean(path)] = true
+	}
+	_, err := l.apply(set, true)
+	return err
+}
+
+// LoadDir loads every recipe file directly inside dir, in name order. The
+// directory is tracked, so Reload also picks up files added to it later.
+func (l *Loader) LoadDir(dir string) error {
+	dir = filepath.Clean(dir)
+	paths, err := recipeFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	set := make(map[string]bool, len(l.files)+len(paths))
+	for path := range l.files {
+		set[path] = true
+	}
+	for _, path := range paths {
+		set[path] = true
+	}
+	if _, err := l.apply(set, true); err != nil {
+		return err
+	}
+	l.dirs[dir] = true
+	return nil
+}
+
+// recipeFiles lists the recipe files directly inside dir.
+func recipeFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".yaml", ".yml", ".json":
+			paths = append(paths, filepath.Join(dir, e.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// Reload re-reads tracked files that changed on disk since the last load,
+// loads files added to tracked directories and reports whether anything was
+// applied. A file that was deleted drops its recipes. On error the previous
+// definitions stay registered.
+func (l *Loader) Reload() (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	set := make(map[string]bool, len(l.files))
+	for path := range l.files {
+		set[path] = true
+	}
+	for dir := range l.dirs {
+		paths, err := recipeFiles(dir)
+		if err != nil {
+			return false, err
+		}
+		for _, path := range paths {
+			set[path] = true
+		}
+	}
+	return l.apply(set, false)
+}
+
+// Watch calls Reload every interval until ctx is done. onError (optional)
+// receives reload errors; onReload (optional) is called after each applied
+// reload.
+func (l *Loader) Watch(ctx context.Context, interval time.Duration, onReload func(), onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := l.Reload()
+			if err != nil && onError != nil {
+				onError(err)
+			}
+			if changed && onReload != nil {
+				onReload()
+			}
+		}
+	}
+}
+
+// apply parses the files in set and, if all of them are valid, replaces
+// their recipes in the registry. Unchanged files are skipped unless force is
+// set (caller must hold lock).
+//
+// The registry is never left without a recipe that survives the reload: new
+// definitions overwrite the old ones in place, and only recipes no file
+// defines any more are removed afterwards.
+func (l *Loader) apply(set map[string]bool, force bool) (bool, error) {
+	paths := make([]string, 0, len(set))
+	for path := range set {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	type update struct {
+		path    string
+		state   *loadedFile // nil if the file was removed
+		recipes []*Recipe
+	}
+	var (
+		updates []update
+		errs    LoadErrors
+	)
+	for _, path := range paths {
+		prev := l.files[path]
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) && prev != nil {
+			updates = append(updates, update{path: path})
+			continue
+		}
+		if err != nil {
+			errs = append(errs, &LoadError{File: path, Msg: err.Error()})
+			continue
+		}
+		if !force && prev != nil && info.ModTime().Equal(prev.modTime) && info.Size() == prev.size {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, &LoadError{File: path, Msg: err.Error()})
+			continue
+		}
+		recipes, err := ParseRecipes(path, data, l.items)
+		if err != nil {
+			var le LoadErrors
+			if errors.As(err, &le) {
+				errs = append(errs, le...)
+			} else {
+				errs = append(errs, &LoadError{File: path, Msg: err.Error()})
+			}
+			continue
+		}
+		state := &loadedFile{modTime: info.ModTime(), size: info.Size()}
+		for _, r := range recipes {
+			state.recipes = append(state.recipes, r.ID)
+		}
+		updates = append(updates, update{path: path, state: state, recipes: recipes})
+	}
+
+	// A recipe ID may only come from one file
+	owner := make(map[RecipeID]string)
+	for path, f := range l.files {
+		for _, id := range f.recipes {
+			owner[id] = path
+		}
+	}
+	for _, u := range updates {
+		if prev := l.files[u.path]; prev != nil {
+			for _, id := range prev.recipes {
+				if owner[id] == u.path {
+					delete(owner, id)
+				}
+			}
+		}
+	}
+	for _, u := range updates {
+		for _, r := range u.recipes {
+			if other, dup := owner[r.ID]; dup && other != u.path {
+				errs = append(errs, &LoadError{File: u.path, Recipe: r.ID, Msg: "recipe also defined in " + other})
+			}
+			owner[r.ID] = u.path
+		}
+	}
+
+	// Check everything Register would before changing anything
+	for _, u := range updates {
+		for _, r := range u.recipes {
+			if err := validateRecipe(r); err != nil {
+				errs = append(errs, &LoadError{File: u.path, Recipe: r.ID, Msg: err.Error()})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return false, errs
+	}
+
+	for _, u := range updates {
+		for _, r := range u.recipes {
+			if err := l.registry.Register(r); err != nil {
+				// Unreachable: validated above
+				return true, fmt.Errorf("%s: %w", u.path, err)
+			}
+		}
+	}
+	// Recipes that moved to another file are still owned and stay
+	for _, u := range updates {
+		if prev := l.files[u.path]; prev != nil {
+			for _, id := range prev.recipes {
+				if _, kept := owner[id]; !kept {
+					l.registry.Remove(id)
+				}
+			}
+		}
+		if u.state == nil {
+			delete(l.files, u.path)
+		} else {
+			l.files[u.path] = u.state
+		}
+	}
+	return len(updates) > 0, nil
+}
@@ -0,0 +1,313 @@
+package production
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gravitas-015/inventory"
+)
+
+const swordYAML = `recipes:
+  - id: iron_sword
+    name: Iron Sword
+    category: smithing
+    duration: 5s
+    inputs:
+      - {item: iron_ingot, quantity: 3}
+      - {item: hammer, quantity: 1, consume: false}
+    outputs:
+      - {item: iron_sword, quantity: 1}
+      - {item: gem, quantity: 1, probability: 0.1, pity: 9}
+    upkeep:
+      - {resource: power, rate: 2.5}
+`
+
+func TestParseRecipes(t *testing.T) {
+	recipes, err := ParseRecipes("sword.yaml", []byte(swordYAML), nil)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(recipes) != 1 {
+		t.Fatalf("expected 1 recipe, got %d", len(recipes))
+	}
+	r := recipes[0]
+	if r.ID != "iron_sword" || r.Duration != 5*time.Second || r.Category != "smithing" {
+		t.Errorf("unexpected recipe %+v", r)
+	}
+	if !r.Inputs[0].Consume || r.Inputs[1].Consume {
+		t.Errorf("consume defaults wrong: %+v", r.Inputs)
+	}
+	if r.Outputs[0].Probability != 1.0 || r.Outputs[1].Pity != 9 {
+		t.Errorf("outputs wrong: %+v", r.Outputs)
+	}
+	if len(r.Upkeep) != 1 || r.Upkeep[0] != (Upkeep{Resource: "power", Rate: 2.5}) {
+		t.Errorf("upkeep wrong: %+v", r.Upkeep)
+	}
+
+	// JSON uses the same format; numeric durations are seconds
+	json := `[{"id": "plank", "duration": 1.5,
+	  "inputs": [{"item": "wood", "quantity": 2}],
+	  "outputs": [{"item": "plank", "quantity": 4}]}]`
+	recipes, err = ParseRecipes("plank.json", []byte(json), nil)
+	if err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if recipes[0].Duration != 1500*time.Millisecond {
+		t.Errorf("json duration %v", recipes[0].Duration)
+	}
+}
+
+func TestParseRecipesErrors(t *testing.T) {
+	items := inventory.NewRegistry(inventory.ItemDetails{ID: "wood"}, inventory.ItemDetails{ID: "plank"})
+	data := `recipes:
+  - id: plank
+    duration: 0s
+    outputs: []
+  - id: beam
+    duration: 2s
+    inputs:
+      - {item: wood, quantity: 0}
+    outputs:
+      - {item: beam, quantity: 1, probability: 1.5}
+    colour: red
+  - id: plank
+    duration: 1s
+    outputs:
+      - {item: plank, quantity: 1}
+`
+	_, err := ParseRecipes("bad.yaml", []byte(data), items)
+	var errs LoadErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected LoadErrors, got %v", err)
+	}
+
+	want := []string{
+		"bad.yaml:3: recipe plank: duration must be positive",
+		"bad.yaml:4: recipe plank: recipe has no outputs",
+		`bad.yaml:11: recipe beam: unknown field "colour"`,
+		"bad.yaml:8: recipe beam: input wood: quantity must be positive",
+		"bad.yaml:10: recipe beam: unknown item beam",
+		"bad.yaml:10: recipe beam: output beam: probability must be in (0, 1], got 1.5",
+		"bad.yaml:12: recipe plank: duplicate recipe id (first defined on line 2)",
+	}
+	got := strings.Split(err.Error(), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("got %d errors:\n%v", len(got), err)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("error %d:\n got %s\nwant %s", i, got[i], want[i])
+		}
+	}
+
+	if _, err := ParseRecipes("broken.yaml", []byte("recipes: [\n  {id: x"), nil); err == nil {
+		t.Error("expected syntax error")
+	}
+}
+
+func TestParseRecipesAliases(t *testing.T) {
+	data := `common_inputs: &common_inputs
+  - {item: wood, quantity: 2}
+base: &base
+  category: carpentry
+  duration: 2s
+  outputs:
+    - &plank {item: plank, quantity: 4}
+recipes:
+  - id: plank
+    <<: *base
+    inputs: *common_inputs
+  - id: beam
+    <<: [*base]
+    duration: 3s
+    inputs: *common_inputs
+    outputs:
+      - *plank
+      - {<<: *plank, quantity: 1, colour: red}
+`
+	_, err := ParseRecipes("alias.yaml", []byte(data), nil)
+	if err == nil || !strings.Contains(err.Error(), `recipe beam: unknown field "colour"`) {
+		t.Fatalf("expected the merged output's unknown field, got %v", err)
+	}
+
+	data = strings.Replace(data, ", colour: red", "", 1)
+	recipes, err := ParseRecipes("alias.yaml", []byte(data), nil)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(recipes) != 2 {
+		t.Fatalf("expected 2 recipes, got %d", len(recipes))
+	}
+	plank, beam := recipes[0], recipes[1]
+	if plank.Category != "carpentry" || plank.Duration != 2*time.Second || len(plank.Inputs) != 1 || len(plank.Outputs) != 1 {
+		t.Errorf("merged recipe wrong: %+v", plank)
+	}
+	if beam.Duration != 3*time.Second || beam.Inputs[0].Item != "wood" {
+		t.Errorf("merge should not override local keys: %+v", beam)
+	}
+	if len(beam.Outputs) != 2 || beam.Outputs[1].Item != "plank" || beam.Outputs[1].Quantity != 1 {
+		t.Errorf("aliased outputs wrong: %+v", beam.Outputs)
+	}
+}
+
+func TestLoaderReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "recipes.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`- {id: plank, duration: 1m, inputs: [{item: wood, quantity: 2}], outputs: [{item: plank, quantity: 1}]}
+- {id: beam, duration: 1m, outputs: [{item: beam, quantity: 1}]}
+`)
+
+	registry := NewRecipeRegistry()
+	loader := NewLoader(registry, nil)
+	if err := loader.LoadDir(dir); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if registry.Count() != 2 {
+		t.Fatalf("expected 2 recipes, got %d", registry.Count())
+	}
+
+	// Start a job on the original definition
+	invProvider := NewSimpleInventoryProvider()
+	inv := inventory.NewVolume("inv", "player1", 1000)
+	inv.AddStack(inventory.Stack{Item: "wood", Owner: "player1", Qty: 10})
+	invProvider.AddInventory(inv)
+	mgr := NewManager("test", registry, invProvider, NewNullEventBus(), nil)
+	jobID, err := mgr.StartProduction("plank", "player1", "inv")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	if changed, err := loader.Reload(); changed || err != nil {
+		t.Errorf("reload of unchanged file: changed=%v err=%v", changed, err)
+	}
+
+	// An invalid edit keeps the old definitions
+	write(`- {id: plank, duration: 0s, outputs: [{item: plank, quantity: 1}]}`)
+	if _, err := loader.Reload(); err == nil {
+		t.Fatal("expected reload error")
+	}
+	if registry.Lookup("beam") == nil {
+		t.Error("failed reload should keep previous recipes")
+	}
+
+	// A valid edit replaces the file's recipes; beam is dropped
+	write(`- {id: plank, duration: 2m, inputs: [{item: wood, quantity: 5}], outputs: [{item: plank, quantity: 3}]}`)
+	if changed, err := loader.Reload(); !changed || err != nil {
+		t.Fatalf("reload: changed=%v err=%v", changed, err)
+	}
+	if registry.Lookup("beam") != nil || registry.Lookup("plank").Duration != 2*time.Minute {
+		t.Error("reload did not apply the new definitions")
+	}
+
+	// The running job keeps its snapshot
+	mgr.Update(time.Now().Add(90 * time.Second))
+	if mgr.GetJob(jobID) != nil || countItem(inv, "plank") != 1 {
+		t.Error("running job should complete on its original recipe")
+	}
+}
+
+func TestLoaderReloadDirectory(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.yaml", `- {id: plank, duration: 1m, outputs: [{item: plank, quantity: 1}]}
+- {id: beam, duration: 1m, outputs: [{item: beam, quantity: 1}]}
+`)
+
+	registry := NewRecipeRegistry()
+	loader := NewLoader(registry, nil)
+	if err := loader.LoadDir(dir); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	// A file added later is loaded, and beam moving into it survives
+	write("b.yaml", `- {id: beam, duration: 2m, outputs: [{item: beam, quantity: 1}]}
+- {id: post, duration: 1m, outputs: [{item: post, quantity: 1}]}
+`)
+	write("a.yaml", `- {id: plank, duration: 1m, outputs: [{item: plank, quantity: 1}]}`)
+	if changed, err := loader.Reload(); !changed || err != nil {
+		t.Fatalf("reload: changed=%v err=%v", changed, err)
+	}
+	if registry.Count() != 3 || registry.Lookup("post") == nil {
+		t.Fatalf("new file not loaded, %d recipes", registry.Count())
+	}
+	if beam := registry.Lookup("beam"); beam == nil || beam.Duration != 2*time.Minute {
+		t.Errorf("beam should come from b.yaml, got %+v", beam)
+	}
+
+	// Removing the file drops what only it defined
+	if err := os.Remove(filepath.Join(dir, "b.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loader.Reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if registry.Lookup("beam") != nil || registry.Lookup("post") != nil || registry.Lookup("plank") == nil {
+		t.Errorf("expected only plank left, got %d recipes", registry.Count())
+	}
+}
+
+func TestLoaderReloadKeepsRecipesVisible(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "recipes.yaml")
+	durations := []string{"1m", "10m"}
+	write := func(i int) {
+		t.Helper()
+		content := `- {id: plank, duration: ` + durations[i%2] + `, outputs: [{item: plank, quantity: 1}]}`
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(0)
+
+	registry := NewRecipeRegistry()
+	loader := NewLoader(registry, nil)
+	if err := loader.LoadDir(dir); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	done := make(chan struct{})
+	missing := make(chan struct{}, 1)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if registry.Lookup("plank") == nil {
+				select {
+				case missing <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	for i := 1; i <= 50; i++ {
+		write(i)
+		if _, err := loader.Reload(); err != nil {
+			t.Fatalf("reload %d: %v", i, err)
+		}
+	}
+	close(done)
+
+	select {
+	case <-missing:
+		t.Error("plank was missing from the registry during a reload")
+	default:
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"container/heap"
 	"errors"
 	"fmt"
-	"math/rand"
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,12 +20,17 @@ type Manager struct {
 	inventories     InventoryProvider
 	eventBus        EventBus
 	modifierSources []ModifierSource
+	clock           Clock
+	upkeep          UpkeepSource
 
 	mu         sync.RWMutex
 	jobs       map[JobID]*Job
 	activeJobs *jobHeap
+	blocked    []*Job // jobs holding buffered outputs, oldest first
 	lastUpdate time.Time
 	nextJobID  int64
+	seed       uint64 // base for job roll seeds
+	seeds      uint64 // number of job seeds handed out
 }
 
 // NewManager creates a new production manager.
@@ -42,12 +47,41 @@ func NewManager(
 		inventories:     inventories,
 		eventBus:        eventBus,
 		modifierSources: modifierSources,
+		clock:           SystemClock{},
 		jobs:            make(map[JobID]*Job),
 		activeJobs:      newJobHeap(),
 		lastUpdate:      time.Now(),
+		seed:            uint64(time.Now().UnixNano()),
 	}
 }
 
+// SetClock replaces the time source (SystemClock by default). Set it before
+// starting jobs: times already recorded on jobs are not converted.
+func (m *Manager) SetClock(clock Clock) {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = clock
+	m.lastUpdate = clock.Now()
+}
+
+// Now returns the current time of the manager's clock.
+func (m *Manager) Now() time.Time {
+	return m.clock.Now()
+}
+
+// SetSeed makes job roll seeds deterministic: jobs started after SetSeed get
+// seeds derived from seed in start order. Without it the base seed is taken
+// from the clock.
+func (m *Manager) SetSeed(seed uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seed = seed
+	m.seeds = 0
+}
+
 // ID returns the manager's identifier.
 func (m *Manager) ID() string {
 	return m.id
@@ -69,14 +103,32 @@ func (m *Manager) StartRepeatingProduction(recipeID RecipeID, ownerID inventory.
 
 // startProductionInternal is the internal implementation for starting production.
 func (m *Manager) startProductionInternal(recipeID RecipeID, ownerID inventory.OwnerID, inventoryID string, repeat bool) (JobID, error) {
+	job := &Job{
+		Recipe:      recipeID,
+		Owner:       ownerID,
+		InventoryID: inventoryID,
+		Repeat:      repeat,
+		Context:     make(map[string]any),
+	}
+	if err := m.startJob(job, nil); err != nil {
+		return "", err
+	}
+	return job.ID, nil
+}
+
+// startJob resolves modifiers for a prepared job, consumes its inputs and
+// schedules it. If prepaid is non-nil the inputs were already taken (e.g. by
+// a production queue) and prepaid is recorded as the input snapshot instead.
+// A job without an ID or roll seed is assigned one.
+func (m *Manager) startJob(job *Job, prepaid []ItemRequirement) error {
 	// 1. Lookup recipe
-	recipe := m.registry.Lookup(recipeID)
+	recipe := m.registry.Lookup(job.Recipe)
 	if recipe == nil {
-		return "", fmt.Errorf("recipe not found: %s", recipeID)
+		return fmt.Errorf("recipe not found: %s", job.Recipe)
 	}
 
 	// 2. Resolve modifiers
-	modifiers := m.resolveModifiers(ownerID, recipeID)
+	modifiers := m.resolveJobModifiers(job)
 
 	// 3. Apply modifiers to calculate effective values
 	effectiveInputs := applyInputModifiers(recipe.Inputs, modifiers.InputCost)
@@ -84,53 +136,75 @@ func (m *Manager) startProductionInternal(recipeID RecipeID, ownerID inventory.O
 	effectiveDuration := time.Duration(applyDurationModifier(int64(recipe.Duration), modifiers.TimeSpeed))
 
 	// 4. Get inventory
-	inv, err := m.inventories.GetInventory(inventoryID)
+	inv, err := m.inventories.GetInventory(job.InventoryID)
 	if err != nil {
-		return "", fmt.Errorf("inventory not found: %w", err)
+		return fmt.Errorf("inventory not found: %w", err)
 	}
 
 	// 5. IMMEDIATELY consume inputs (atomic operation)
-	if err := m.inventories.ConsumeItems(inv, effectiveInputs); err != nil {
-		return "", fmt.Errorf("insufficient resources: %w", err)
+	snapshot := prepaid
+	if prepaid == nil {
+		if err := m.inventories.ConsumeItems(inv, effectiveInputs); err != nil {
+			return fmt.Errorf("insufficient resources: %w", err)
+		}
+		snapshot = effectiveInputs
 	}
 
-	// 6. Create job
-	now := time.Now()
-	jobID := m.generateJobID()
-
-	job := &Job{
-		ID:                jobID,
-		Recipe:            recipeID,
-		Owner:             ownerID,
-		InventoryID:       inventoryID,
-		State:             JobRunning,
-		Progress:          0.0,
-		StartTime:         now,
-		EndTime:           now.Add(effectiveDuration),
-		InputSnapshot:     effectiveInputs,
-		Modifiers:         modifiers,
-		EffectiveInputs:   effectiveInputs,
-		EffectiveOutputs:  effectiveOutputs,
-		EffectiveDuration: effectiveDuration,
-		Repeat:            repeat,
-		CyclesCompleted:   0,
-		Context:           make(map[string]any),
-	}
-
-	// 7. Add to active jobs
+	// 6. Fill in the job
+	now := m.clock.Now()
+	if job.ID == "" {
+		job.ID = m.generateJobID()
+	}
+	if job.Context == nil {
+		job.Context = make(map[string]any)
+	}
+	if job.Seed == 0 {
+		job.Seed = m.nextSeed()
+	}
+	job.Rolls = NewRollState(job.Seed)
+	job.Efficiency = 1.0
+	snapshotRecipe := *recipe
+	job.Snapshot = &snapshotRecipe
+	job.State = JobRunning
+	job.Progress = 0.0
+	job.StartTime = now
+	job.EndTime = now.Add(effectiveDuration)
+	job.InputSnapshot = snapshot
+	job.Modifiers = modifiers
+	job.EffectiveInputs = effectiveInputs
+	job.EffectiveOutputs = effectiveOutputs
+	job.EffectiveDuration = effectiveDuration
+	job.CyclesCompleted = 0
+
+	// 7. Add to active jobs and emit the event under the lock, so Update
+	// cannot complete the job (and publish Completed) before Started
 	m.mu.Lock()
-	m.jobs[jobID] = job
+	defer m.mu.Unlock()
+	m.jobs[job.ID] = job
 	heap.Push(m.activeJobs, job)
-	m.mu.Unlock()
-
-	// 8. Emit event
 	m.eventBus.Publish(Event{
 		Type:      EventJobStarted,
 		Job:       job,
 		Timestamp: now,
 	})
 
-	return jobID, nil
+	return nil
+}
+
+// effectiveInputs returns the inputs job would consume if it started now,
+// after modifiers.
+func (m *Manager) effectiveInputs(job *Job) ([]ItemRequirement, error) {
+	recipe := m.registry.Lookup(job.Recipe)
+	if recipe == nil {
+		return nil, fmt.Errorf("recipe not found: %s", job.Recipe)
+	}
+	modifiers := m.resolveJobModifiers(job)
+	return applyInputModifiers(recipe.Inputs, modifiers.InputCost), nil
+}
+
+// UpdateNow processes completed jobs up to the clock's current time.
+func (m *Manager) UpdateNow() {
+	m.Update(m.clock.Now())
 }
 
 // Update processes completed jobs up to the given time.
@@ -139,8 +213,16 @@ func (m *Manager) Update(now time.Time) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	from := m.lastUpdate
 	m.lastUpdate = now
 
+	// Charge upkeep for the elapsed interval; short jobs fall behind
+	m.drawUpkeep(from, now)
+
+	// Retry jobs blocked on full inventories first, so they deliver before
+	// newly completed jobs compete for the space
+	m.retryBlocked("", now)
+
 	// Process all completed jobs
 	completed := m.activeJobs.processCompletedJobs(now)
 
@@ -171,28 +253,106 @@ func (m *Manager) completeJob(job *Job, now time.Time) {
 		return
 	}
 
-	// Roll probabilistic outputs
-	actualOutputs := m.rollOutputs(job.EffectiveOutputs)
+	// Roll probabilistic outputs and hand them to the inventory
+	job.BufferedOutputs = RollOutputs(job.EffectiveOutputs, &job.Rolls)
+	m.deliverOutputs(job, inv, now)
+}
 
-	// Add outputs to inventory
-	if err := m.inventories.AddItems(inv, actualOutputs); err != nil {
-		// Failed to add items (inventory full, etc.) - mark as failed
-		job.State = JobFailed
-		job.Progress = 1.0
+// deliverOutputs moves a job's buffered outputs into its inventory. Outputs
+// that do not fit stay buffered and the job is blocked until a later retry
+// delivers them; once everything is delivered the job finishes (caller must
+// hold lock).
+func (m *Manager) deliverOutputs(job *Job, inv *inventory.Inventory, now time.Time) {
+	// Deliver one yield at a time so a partial fit keeps what was added
+	for len(job.BufferedOutputs) > 0 {
+		next := job.BufferedOutputs[0]
+		if err := m.inventories.AddItems(inv, []ItemYield{next}); err != nil {
+			if job.State != JobBlocked {
+				job.State = JobBlocked
+				job.Progress = 1.0
+				m.blocked = append(m.blocked, job)
+
+				m.eventBus.Publish(Event{
+					Type:      EventJobBlocked,
+					Job:       job,
+					Timestamp: now,
+					Data: map[string]any{
+						"error":    err.Error(),
+						"buffered": job.BufferedOutputs,
+					},
+				})
+			}
+			return
+		}
+		job.BufferedOutputs = job.BufferedOutputs[1:]
+	}
+	job.BufferedOutputs = nil
 
+	if job.State == JobBlocked {
+		m.removeBlocked(job.ID)
 		m.eventBus.Publish(Event{
-			Type:      EventJobFailed,
+			Type:      EventJobUnblocked,
 			Job:       job,
 			Timestamp: now,
-			Data: map[string]any{
-				"error": err.Error(),
-			},
 		})
+	}
+	m.finishJob(job, now)
+}
 
-		delete(m.jobs, job.ID)
-		return
+// retryBlocked retries delivery for blocked jobs, optionally only those
+// targeting one inventory (caller must hold lock).
+func (m *Manager) retryBlocked(inventoryID string, now time.Time) {
+	// Copy: delivery removes jobs from m.blocked
+	pending := append([]*Job(nil), m.blocked...)
+	for _, job := range pending {
+		if inventoryID != "" && job.InventoryID != inventoryID {
+			continue
+		}
+		inv, err := m.inventories.GetInventory(job.InventoryID)
+		if err != nil {
+			continue
+		}
+		m.deliverOutputs(job, inv, now)
+	}
+}
+
+// removeBlocked drops a job from the blocked list (caller must hold lock).
+func (m *Manager) removeBlocked(jobID JobID) {
+	for i, job := range m.blocked {
+		if job.ID == jobID {
+			m.blocked = append(m.blocked[:i], m.blocked[i+1:]...)
+			return
+		}
+	}
+}
+
+// NotifyInventoryChanged retries delivery of buffered outputs into an
+// inventory right away. Call it when items are removed from an inventory
+// (e.g. the player empties it) instead of waiting for the next Update.
+func (m *Manager) NotifyInventoryChanged(inventoryID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retryBlocked(inventoryID, m.clock.Now())
+}
+
+// BufferedOutputs returns the outputs waiting for space in an inventory,
+// oldest first.
+func (m *Manager) BufferedOutputs(inventoryID string) []ItemYield {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []ItemYield
+	for _, job := range m.blocked {
+		if job.InventoryID == inventoryID {
+			result = append(result, job.BufferedOutputs...)
+		}
 	}
+	return result
+}
 
+// finishJob records a completed cycle and restarts or retires the job
+// (caller must hold lock).
+func (m *Manager) finishJob(job *Job, now time.Time) {
 	// Success - increment cycle counter
 	job.CyclesCompleted++
 	job.State = JobComplete
@@ -240,10 +400,15 @@ func (m *Manager) restartRepeatingJob(job *Job, now time.Time) error {
 		return fmt.Errorf("inventory not found: %w", err)
 	}
 
+	// Each cycle runs on the modifiers active when it starts
+	m.applyModifiers(job, m.resolveJobModifiers(job))
+
 	// Try to consume inputs for next cycle
 	if err := m.inventories.ConsumeItems(inv, job.EffectiveInputs); err != nil {
 		return fmt.Errorf("insufficient resources for next cycle: %w", err)
 	}
+	// A refund returns what this cycle paid, not what the first one did
+	job.InputSnapshot = job.EffectiveInputs
 
 	// Reset job for next cycle
 	job.State = JobRunning
@@ -268,51 +433,43 @@ func (m *Manager) restartRepeatingJob(job *Job, now time.Time) error {
 	return nil
 }
 
-// rollOutputs applies probability to outputs and returns actual items produced.
-func (m *Manager) rollOutputs(outputs []ItemYield) []ItemYield {
-	if len(outputs) == 0 {
-		return nil
-	}
-
-	result := make([]ItemYield, 0, len(outputs))
-
-	for _, output := range outputs {
-		// Roll for probability
-		if output.Probability >= 1.0 || rand.Float64() < output.Probability {
-			result = append(result, output)
-		}
-	}
-
-	return result
-}
-
 // CancelProduction cancels an active job.
 // By default, does NOT refund items (application can implement refund logic separately).
+// Cancelling a blocked job discards its buffered outputs.
 func (m *Manager) CancelProduction(jobID JobID) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	return m.cancelJob(jobID)
+}
 
+// cancelJob cancels a running or blocked job (caller must hold lock).
+func (m *Manager) cancelJob(jobID JobID) error {
 	job, exists := m.jobs[jobID]
 	if !exists {
 		return errors.New("job not found")
 	}
 
-	if job.State != JobRunning {
+	switch job.State {
+	case JobRunning:
+		// Remove from active jobs heap
+		m.activeJobs.Remove(jobID)
+	case JobBlocked:
+		m.removeBlocked(jobID)
+		job.BufferedOutputs = nil
+	default:
 		return fmt.Errorf("job is not running: %s", job.State)
 	}
 
-	// Remove from active jobs heap
-	m.activeJobs.Remove(jobID)
-
 	// Update job state
+	now := m.clock.Now()
+	job.Progress = job.CalculateProgress(now)
 	job.State = JobCancelled
-	job.Progress = job.CalculateProgress(time.Now())
 
 	// Emit event
 	m.eventBus.Publish(Event{
 		Type:      EventJobCancelled,
 		Job:       job,
-		Timestamp: time.Now(),
+		Timestamp: now,
 	})
 
 	delete(m.jobs, jobID)
@@ -321,6 +478,11 @@ func (m *Manager) CancelProduction(jobID JobID) error {
 }
 
 // CancelProductionWithRefund cancels a job and refunds all input items.
+//
+// A blocked job has already finished its cycle: its inputs became the
+// buffered outputs, some of which may have been delivered. Cancelling it
+// discards the buffered outputs and refunds nothing, so no items are
+// created twice.
 func (m *Manager) CancelProductionWithRefund(jobID JobID) error {
 	m.mu.Lock()
 
@@ -330,7 +492,13 @@ func (m *Manager) CancelProductionWithRefund(jobID JobID) error {
 		return errors.New("job not found")
 	}
 
-	if job.State != JobRunning {
+	switch job.State {
+	case JobRunning:
+	case JobBlocked:
+		err := m.cancelJob(jobID)
+		m.mu.Unlock()
+		return err
+	default:
 		m.mu.Unlock()
 		return fmt.Errorf("job is not running: %s", job.State)
 	}
@@ -342,14 +510,24 @@ func (m *Manager) CancelProductionWithRefund(jobID JobID) error {
 	m.mu.Unlock()
 
 	// Refund items (outside lock to avoid potential deadlock with inventory operations)
+	if err := m.refund(inventoryID, inputSnapshot); err != nil {
+		return err
+	}
+
+	// Now cancel the job
+	return m.CancelProduction(jobID)
+}
+
+// refund returns the consumed items in inputs to an inventory.
+func (m *Manager) refund(inventoryID string, inputs []ItemRequirement) error {
 	inv, err := m.inventories.GetInventory(inventoryID)
 	if err != nil {
 		return fmt.Errorf("failed to get inventory for refund: %w", err)
 	}
 
 	// Convert ItemRequirements to ItemYields for refund
-	refundItems := make([]ItemYield, 0, len(inputSnapshot))
-	for _, req := range inputSnapshot {
+	refundItems := make([]ItemYield, 0, len(inputs))
+	for _, req := range inputs {
 		if req.Consume {
 			refundItems = append(refundItems, ItemYield{
 				Item:        req.Item,
@@ -362,9 +540,16 @@ func (m *Manager) CancelProductionWithRefund(jobID JobID) error {
 	if err := m.inventories.AddItems(inv, refundItems); err != nil {
 		return fmt.Errorf("failed to refund items: %w", err)
 	}
+	return nil
+}
 
-	// Now cancel the job
-	return m.CancelProduction(jobID)
+// isActive reports whether a job is still held by the manager (running, or
+// repeating between cycles).
+func (m *Manager) isActive(jobID JobID) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.jobs[jobID]
+	return ok
 }
 
 // GetJob retrieves a job by ID. Returns nil if not found.
@@ -375,21 +560,21 @@ func (m *Manager) GetJob(jobID JobID) *Job {
 	job := m.jobs[jobID]
 	if job != nil {
 		// Update progress before returning
-		job.Progress = job.CalculateProgress(time.Now())
+		job.Progress = job.CalculateProgress(m.clock.Now())
 	}
 	return job
 }
 
-// GetActiveJobs returns all jobs for a specific owner.
+// GetActiveJobs returns all running and blocked jobs for a specific owner.
 func (m *Manager) GetActiveJobs(ownerID inventory.OwnerID) []*Job {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	result := make([]*Job, 0)
-	now := time.Now()
+	now := m.clock.Now()
 
 	for _, job := range m.jobs {
-		if job.Owner == ownerID && job.State == JobRunning {
+		if job.Owner == ownerID && (job.State == JobRunning || job.State == JobBlocked) {
 			// Update progress
 			job.Progress = job.CalculateProgress(now)
 			result = append(result, job)
@@ -405,7 +590,7 @@ func (m *Manager) GetAllJobs() []*Job {
 	defer m.mu.RUnlock()
 
 	result := make([]*Job, 0, len(m.jobs))
-	now := time.Now()
+	now := m.clock.Now()
 
 	for _, job := range m.jobs {
 		// Update progress
@@ -423,18 +608,88 @@ func (m *Manager) JobCount() int {
 	return len(m.jobs)
 }
 
-// resolveModifiers combines all modifier sources for a job.
-func (m *Manager) resolveModifiers(ownerID inventory.OwnerID, recipeID RecipeID) Modifiers {
+// resolveJobModifiers combines all modifier sources for a job. Sources that
+// implement JobModifierSource see the job itself.
+func (m *Manager) resolveJobModifiers(job *Job) Modifiers {
 	result := DefaultModifiers()
 
 	for _, source := range m.modifierSources {
-		mods := source.GetModifiers(ownerID, recipeID)
+		var mods Modifiers
+		if js, ok := source.(JobModifierSource); ok {
+			mods = js.GetJobModifiers(job)
+		} else {
+			mods = source.GetModifiers(job.Owner, job.Recipe)
+		}
 		result = result.Combine(mods)
 	}
 
 	return result
 }
 
+// applyModifiers recomputes a job's effective values from its recipe
+// snapshot under mods (caller must hold lock or own the job).
+func (m *Manager) applyModifiers(job *Job, mods Modifiers) {
+	if job.Snapshot == nil {
+		return
+	}
+	job.Modifiers = mods
+	job.EffectiveInputs = applyInputModifiers(job.Snapshot.Inputs, mods.InputCost)
+	job.EffectiveOutputs = applyOutputModifiers(job.Snapshot.Outputs, mods.OutputYield)
+	job.EffectiveDuration = time.Duration(applyDurationModifier(int64(job.Snapshot.Duration), mods.TimeSpeed))
+}
+
+// RefreshModifiers re-resolves modifiers for the running jobs of owner (all
+// owners if empty), so a buff gained or lost mid-job applies to work in
+// progress. Progress made so far is kept and the remaining time is rescaled
+// to the new speed; the new output yield applies when the job completes and
+// the new input cost from the next cycle (this cycle's inputs are paid).
+func (m *Manager) RefreshModifiers(owner inventory.OwnerID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clock.Now()
+	changed := false
+	for _, job := range *m.activeJobs {
+		if (owner != "" && job.Owner != owner) || job.Snapshot == nil {
+			continue
+		}
+		mods := m.resolveJobModifiers(job)
+		if mods.InputCost == job.Modifiers.InputCost &&
+			mods.OutputYield == job.Modifiers.OutputYield &&
+			mods.TimeSpeed == job.Modifiers.TimeSpeed {
+			continue
+		}
+
+		progress := job.CalculateProgress(now)
+		m.applyModifiers(job, mods)
+		remaining := time.Duration(math.Round(float64(job.EffectiveDuration) * (1 - progress)))
+		job.EndTime = now.Add(remaining)
+		job.StartTime = job.EndTime.Add(-job.EffectiveDuration)
+		changed = true
+
+		m.eventBus.Publish(Event{
+			Type:      EventJobModifiersChanged,
+			Job:       job,
+			Timestamp: now,
+			Data: map[string]any{
+				"progress": progress,
+				"endTime":  job.EndTime,
+			},
+		})
+	}
+	if changed {
+		heap.Init(m.activeJobs)
+	}
+}
+
+// nextSeed returns the roll seed for the next started job.
+func (m *Manager) nextSeed() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seeds++
+	return mix64(m.seed + m.seeds*0x9e3779b97f4a7c15)
+}
+
 // generateJobID generates a unique job ID for this manager.
 func (m *Manager) generateJobID() JobID {
 	id := atomic.AddInt64(&m.nextJobID, 1)
@@ -1,6 +1,7 @@
 package production
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -232,3 +233,176 @@ func TestNonConsumedItems(t *testing.T) {
 		t.Errorf("Expected 1 hammer remaining (tool not consumed), got %d", hammerCount)
 	}
 }
+
+func TestBlockedOutputs(t *testing.T) {
+	registry := NewRecipeRegistry()
+	registry.Register(&Recipe{
+		ID:       "plank",
+		Outputs:  []ItemYield{{Item: "plank", Quantity: 1, Probability: 1.0}},
+		Duration: time.Minute,
+	})
+
+	// A one-cell grid that is already full
+	invProvider := NewSimpleInventoryProvider()
+	inv := inventory.NewGrid("test_inv", "player1", 1, 1)
+	if err := inv.AddStack(inventory.Stack{Item: "junk", Owner: "player1", Qty: 1}); err != nil {
+		t.Fatalf("Failed to fill inventory: %v", err)
+	}
+	invProvider.AddInventory(inv)
+
+	bus := &recordingBus{}
+	mgr := NewManager("test_manager", registry, invProvider, bus, nil)
+
+	jobID, err := mgr.StartRepeatingProduction("plank", "player1", "test_inv")
+	if err != nil {
+		t.Fatalf("Failed to start production: %v", err)
+	}
+
+	later := time.Now().Add(2 * time.Minute)
+	mgr.Update(later)
+
+	job := mgr.GetJob(jobID)
+	if job == nil || job.State != JobBlocked {
+		t.Fatalf("Expected blocked job, got %+v", job)
+	}
+	if got := mgr.BufferedOutputs("test_inv"); len(got) != 1 || got[0].Item != "plank" {
+		t.Errorf("Expected one buffered plank, got %v", got)
+	}
+	if len(mgr.GetActiveJobs("player1")) != 1 {
+		t.Error("Blocked job should be listed as active")
+	}
+
+	// Retrying while still full changes nothing and emits no new event
+	mgr.Update(later.Add(time.Minute))
+	if n := bus.count(EventJobBlocked); n != 1 {
+		t.Errorf("Expected 1 blocked event, got %d", n)
+	}
+
+	// Freeing space delivers the outputs and the repeating job restarts
+	if err := inv.RemoveStack(0, 1); err != nil {
+		t.Fatalf("Failed to empty inventory: %v", err)
+	}
+	mgr.NotifyInventoryChanged("test_inv")
+
+	if job.State != JobRunning || job.CyclesCompleted != 1 {
+		t.Errorf("Expected restarted job after 1 cycle, got %s after %d", job.State, job.CyclesCompleted)
+	}
+	if len(mgr.BufferedOutputs("test_inv")) != 0 {
+		t.Error("Buffer should be empty after delivery")
+	}
+	if bus.count(EventJobUnblocked) != 1 || bus.count(EventJobCompleted) != 1 {
+		t.Errorf("Expected unblocked and completed events, got %d and %d",
+			bus.count(EventJobUnblocked), bus.count(EventJobCompleted))
+	}
+	if countItem(inv, "plank") != 1 {
+		t.Error("Plank was not delivered")
+	}
+}
+
+func TestCancelBlockedWithRefund(t *testing.T) {
+	registry := NewRecipeRegistry()
+	registry.Register(&Recipe{
+		ID:       "plank",
+		Inputs:   []ItemRequirement{{Item: "wood", Quantity: 1, Consume: true}},
+		Outputs:  []ItemYield{{Item: "plank", Quantity: 2, Probability: 1.0}},
+		Duration: time.Minute,
+	})
+
+	// Two planks need two cells, but the remaining wood keeps one
+	invProvider := NewSimpleInventoryProvider()
+	inv := inventory.NewGrid("test_inv", "player1", 2, 1)
+	for i := 0; i < 2; i++ {
+		if err := inv.AddStack(inventory.Stack{Item: "wood", Owner: "player1", Qty: 1}); err != nil {
+			t.Fatalf("Failed to add wood: %v", err)
+		}
+	}
+	invProvider.AddInventory(inv)
+
+	bus := &recordingBus{}
+	mgr := NewManager("test_manager", registry, invProvider, bus, nil)
+	jobID, err := mgr.StartProduction("plank", "player1", "test_inv")
+	if err != nil {
+		t.Fatalf("Failed to start production: %v", err)
+	}
+	mgr.Update(time.Now().Add(2 * time.Minute))
+	if job := mgr.GetJob(jobID); job == nil || job.State != JobBlocked {
+		t.Fatalf("Expected blocked job, got %+v", job)
+	}
+
+	if err := mgr.CancelProductionWithRefund(jobID); err != nil {
+		t.Fatalf("Failed to cancel blocked job: %v", err)
+	}
+	if mgr.GetJob(jobID) != nil || len(mgr.BufferedOutputs("test_inv")) != 0 {
+		t.Error("Cancelled job should be gone with its buffered outputs")
+	}
+	if bus.count(EventJobCancelled) != 1 {
+		t.Errorf("Expected 1 cancelled event, got %d", bus.count(EventJobCancelled))
+	}
+	// The finished cycle's inputs are not refunded
+	if got := countItem(inv, "wood"); got != 1 {
+		t.Errorf("Expected 1 wood (no refund for a finished cycle), got %d", got)
+	}
+}
+
+// updatingBus runs a manager update on another goroutine while a Started
+// event is being published, then passes the event on.
+type updatingBus struct {
+	*SimpleEventBus
+	mgr     *Manager
+	updates sync.WaitGroup
+}
+
+func (b *updatingBus) Publish(event Event) {
+	if event.Type == EventJobStarted {
+		b.updates.Add(1)
+		go func() {
+			defer b.updates.Done()
+			b.mgr.Update(time.Now().Add(time.Hour))
+		}()
+		time.Sleep(5 * time.Millisecond) // Give the update a chance to run first
+	}
+	b.SimpleEventBus.Publish(event)
+}
+
+func TestStartedBeforeCompletedUnderConcurrentUpdate(t *testing.T) {
+	registry := NewRecipeRegistry()
+	registry.Register(&Recipe{
+		ID:       "plank",
+		Outputs:  []ItemYield{{Item: "plank", Quantity: 1, Probability: 0.5}},
+		Duration: time.Millisecond,
+	})
+	invProvider := NewSimpleInventoryProvider()
+	invProvider.AddInventory(inventory.NewVolume("test_inv", "player1", 1000))
+
+	bus := &updatingBus{SimpleEventBus: NewSimpleEventBus()}
+	defer bus.Close()
+	var mu sync.Mutex
+	seen := make(map[JobID][]EventType)
+	bus.Subscribe("player1", func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[e.Job.ID] = append(seen[e.Job.ID], e.Type)
+	})
+
+	mgr := NewManager("test_manager", registry, invProvider, bus, nil)
+	bus.mgr = mgr
+	const jobs = 5
+	for i := 0; i < jobs; i++ {
+		if _, err := mgr.StartProduction("plank", "player1", "test_inv"); err != nil {
+			t.Fatalf("Failed to start production: %v", err)
+		}
+	}
+	bus.updates.Wait()
+	bus.Flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != jobs {
+		t.Fatalf("Expected events for %d jobs, got %d", jobs, len(seen))
+	}
+	for id, types := range seen {
+		if len(types) != 2 || types[0] != EventJobStarted || types[1] != EventJobCompleted {
+			t.Errorf("Job %s: expected Started then Completed, got %v", id, types)
+		}
+	}
+}
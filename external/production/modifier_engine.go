@@ -0,0 +1,351 @@
+package production
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitas-015/inventory"
+)
+
+// StackRule decides how effects sharing a tag combine.
+type StackRule int
+
+const (
+	// StackMultiply multiplies the effects together (the default).
+	StackMultiply StackRule = iota
+	// StackAdd adds the effects' deltas: 1.1 and 1.2 give 1.3, not 1.32.
+	StackAdd
+	// StackMax keeps only the strongest effect per field: the highest
+	// OutputYield and the lowest InputCost and TimeSpeed. Meant for buffs:
+	// a penalty in a max-only tag never wins.
+	StackMax
+)
+
+// String returns the rule name.
+func (r StackRule) String() string {
+	switch r {
+	case StackMultiply:
+		return "Multiply"
+	case StackAdd:
+		return "Add"
+	case StackMax:
+		return "Max"
+	default:
+		return "Unknown"
+	}
+}
+
+// StackPolicy is the stacking rule of one tag, with optional caps. Min and
+// Max bound each combined field of the tag (0 means no bound), e.g.
+// {Rule: StackAdd, Min: 0.5} lets speed buffs add up to at most 2x faster.
+type StackPolicy struct {
+	Rule StackRule
+	Min  float64
+	Max  float64
+}
+
+// ModifierContext is what a Condition sees: the owner, the recipe and, when
+// evaluated for a job, the job's context attributes.
+type ModifierContext struct {
+	Owner  inventory.OwnerID
+	Recipe *Recipe // nil if the recipe is unknown
+	Job    *Job    // nil outside a job (e.g. when planning)
+}
+
+// Attr returns a job context attribute, or nil.
+func (c ModifierContext) Attr(key string) any {
+	if c.Job == nil {
+		return nil
+	}
+	return c.Job.Context[key]
+}
+
+// Condition decides whether an effect applies in a context.
+type Condition func(ctx ModifierContext) bool
+
+// WhenCategory applies an effect to recipes of a category.
+func WhenCategory(category string) Condition {
+	return func(ctx ModifierContext) bool {
+		return ctx.Recipe != nil && ctx.Recipe.Category == category
+	}
+}
+
+// WhenRecipe applies an effect to the given recipes.
+func WhenRecipe(ids ...RecipeID) Condition {
+	return func(ctx ModifierContext) bool {
+		if ctx.Recipe == nil {
+			return false
+		}
+		for _, id := range ids {
+			if ctx.Recipe.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// WhenAttr applies an effect to jobs whose context attribute key equals
+// value, e.g. WhenAttr("building", "forge") or WhenAttr("terrain", "hills").
+func WhenAttr(key string, value any) Condition {
+	return func(ctx ModifierContext) bool {
+		return ctx.Attr(key) == value
+	}
+}
+
+// Effect is one modifier registered with a ModifierEngine.
+type Effect struct {
+	ID        string            // Unique; adding an effect with the same ID replaces it
+	Tag       string            // Stacking group (see SetStacking); empty stacks multiplicatively
+	Owner     inventory.OwnerID // Empty applies to every owner
+	Modifiers Modifiers         // Zero fields count as 1 (no change)
+	Expires   time.Time         // Zero never expires
+	Condition Condition         // Nil always applies
+}
+
+// ModifierEngine is a ModifierSource of timed and conditional effects with
+// per-tag stacking rules. It reads time from the same Clock as the Manager,
+// so expiry follows game time.
+//
+// Effects are evaluated when a job starts. To make changes reach jobs in
+// progress, pass Manager.RefreshModifiers to OnChange and call Update
+// regularly to expire effects.
+type ModifierEngine struct {
+	mu       sync.RWMutex
+	registry *RecipeRegistry
+	clock    Clock
+	effects  map[string]*Effect
+	stacking map[string]StackPolicy
+	onChange func(owner inventory.OwnerID)
+}
+
+// NewModifierEngine creates an engine looking up recipes in registry.
+// A nil clock means SystemClock.
+func NewModifierEngine(registry *RecipeRegistry, clock Clock) *ModifierEngine {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	return &ModifierEngine{
+		registry: registry,
+		clock:    clock,
+		effects:  make(map[string]*Effect),
+		stacking: make(map[string]StackPolicy),
+	}
+}
+
+// OnChange sets a callback run whenever effects of an owner are added,
+// removed or expire. The owner is empty for effects that apply to everyone.
+// It is called without the engine lock held.
+func (e *ModifierEngine) OnChange(fn func(owner inventory.OwnerID)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onChange = fn
+}
+
+// SetStacking sets the stacking policy of a tag.
+func (e *ModifierEngine) SetStacking(tag string, policy StackPolicy) {
+	e.mu.Lock()
+	e.stacking[tag] = policy
+	e.mu.Unlock()
+	e.changed("")
+}
+
+// Add registers an effect, replacing any effect with the same ID.
+func (e *ModifierEngine) Add(effect Effect) error {
+	if effect.ID == "" {
+		return fmt.Errorf("effect ID cannot be empty")
+	}
+	for _, v := range []float64{effect.Modifiers.InputCost, effect.Modifiers.OutputYield, effect.Modifiers.TimeSpeed} {
+		if v < 0 {
+			return fmt.Errorf("effect %s: modifiers cannot be negative", effect.ID)
+		}
+	}
+
+	e.mu.Lock()
+	old := e.effects[effect.ID]
+	e.effects[effect.ID] = &effect
+	e.mu.Unlock()
+
+	if old != nil && old.Owner != effect.Owner {
+		e.changed(old.Owner)
+	}
+	e.changed(effect.Owner)
+	return nil
+}
+
+// AddFor registers an effect that expires after duration.
+func (e *ModifierEngine) AddFor(effect Effect, duration time.Duration) error {
+	effect.Expires = e.clock.Now().Add(duration)
+	return e.Add(effect)
+}
+
+// Remove unregisters an effect. Returns false if it did not exist.
+func (e *ModifierEngine) Remove(id string) bool {
+	e.mu.Lock()
+	effect, exists := e.effects[id]
+	delete(e.effects, id)
+	e.mu.Unlock()
+
+	if exists {
+		e.changed(effect.Owner)
+	}
+	return exists
+}
+
+// Effects returns the unexpired effects that can apply to owner (including
+// effects for everyone), sorted by ID.
+func (e *ModifierEngine) Effects(owner inventory.OwnerID) []Effect {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	now := e.clock.Now()
+	var result []Effect
+	for _, effect := range e.effects {
+		if effect.active(owner, now) {
+			result = append(result, *effect)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// Update removes expired effects and reports the change. Expired effects
+// already stop applying on their own; Update is what retimes running jobs.
+func (e *ModifierEngine) Update() {
+	e.mu.Lock()
+	now := e.clock.Now()
+	owners := make(map[inventory.OwnerID]bool)
+	for id, effect := range e.effects {
+		if !effect.Expires.IsZero() && !now.Before(effect.Expires) {
+			owners[effect.Owner] = true
+			delete(e.effects, id)
+		}
+	}
+	e.mu.Unlock()
+
+	for owner := range owners {
+		e.changed(owner)
+	}
+}
+
+// GetModifiers implements ModifierSource.
+func (e *ModifierEngine) GetModifiers(owner inventory.OwnerID, recipe RecipeID) Modifiers {
+	return e.evaluate(ModifierContext{Owner: owner, Recipe: e.registry.Lookup(recipe)})
+}
+
+// GetJobModifiers implements JobModifierSource. Conditions see the recipe
+// as it was when the job started.
+func (e *ModifierEngine) GetJobModifiers(job *Job) Modifiers {
+	recipe := job.Snapshot
+	if recipe == nil {
+		recipe = e.registry.Lookup(job.Recipe)
+	}
+	return e.evaluate(ModifierContext{Owner: job.Owner, Recipe: recipe, Job: job})
+}
+
+// evaluate combines the effects that apply in ctx: effects sharing a tag
+// stack by the tag's policy, and the tags multiply together.
+func (e *ModifierEngine) evaluate(ctx ModifierContext) Modifiers {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	now := e.clock.Now()
+	groups := make(map[string][]*Effect)
+	for _, effect := range e.effects {
+		if effect.active(ctx.Owner, now) && (effect.Condition == nil || effect.Condition(ctx)) {
+			groups[effect.Tag] = append(groups[effect.Tag], effect)
+		}
+	}
+
+	tags := make([]string, 0, len(groups))
+	for tag := range groups {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	result := DefaultModifiers()
+	var sources []string
+	for _, tag := range tags {
+		effects := groups[tag]
+		sort.Slice(effects, func(i, j int) bool { return effects[i].ID < effects[j].ID })
+		policy := e.stacking[tag]
+
+		var inputs, outputs, speeds []float64
+		for _, effect := range effects {
+			inputs = append(inputs, neutral(effect.Modifiers.InputCost))
+			outputs = append(outputs, neutral(effect.Modifiers.OutputYield))
+			speeds = append(speeds, neutral(effect.Modifiers.TimeSpeed))
+			sources = append(sources, effect.ID)
+		}
+		result.InputCost *= policy.stack(inputs, false)
+		result.OutputYield *= policy.stack(outputs, true)
+		result.TimeSpeed *= policy.stack(speeds, false)
+		if tag != "" {
+			result.Tags = append(result.Tags, tag)
+		}
+	}
+	result.Source = strings.Join(sources, "+")
+	return result
+}
+
+// stack combines the values of one field within a tag. higher says which
+// direction is the stronger effect for StackMax.
+func (p StackPolicy) stack(values []float64, higher bool) float64 {
+	var v float64
+	switch p.Rule {
+	case StackAdd:
+		v = 1.0
+		for _, x := range values {
+			v += x - 1.0
+		}
+		v = max(v, 0)
+	case StackMax:
+		v = 1.0
+		for _, x := range values {
+			if (higher && x > v) || (!higher && x < v) {
+				v = x
+			}
+		}
+	default:
+		v = 1.0
+		for _, x := range values {
+			v *= x
+		}
+	}
+	if p.Min > 0 {
+		v = max(v, p.Min)
+	}
+	if p.Max > 0 {
+		v = min(v, p.Max)
+	}
+	return v
+}
+
+// active reports whether the effect can apply to owner at now.
+func (e *Effect) active(owner inventory.OwnerID, now time.Time) bool {
+	if e.Owner != "" && e.Owner != owner {
+		return false
+	}
+	return e.Expires.IsZero() || now.Before(e.Expires)
+}
+
+// changed runs the change callback.
+func (e *ModifierEngine) changed(owner inventory.OwnerID) {
+	e.mu.RLock()
+	fn := e.onChange
+	e.mu.RUnlock()
+	if fn != nil {
+		fn(owner)
+	}
+}
+
+// neutral maps an unset (zero) modifier field to 1.
+func neutral(v float64) float64 {
+	if v == 0 {
+		return 1.0
+	}
+	return v
+}
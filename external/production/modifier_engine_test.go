@@ -0,0 +1,183 @@
+package production
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gravitas-015/inventory"
+)
+
+func newEngineFixture(t *testing.T) (*ModifierEngine, *TickClock) {
+	t.Helper()
+	registry := newTestRegistry(t,
+		&Recipe{ID: "plank", Category: "wood", Outputs: []ItemYield{{Item: "plank", Quantity: 1, Probability: 1.0}}, Duration: time.Minute},
+		&Recipe{ID: "ingot", Category: "metal", Outputs: []ItemYield{{Item: "ingot", Quantity: 1, Probability: 1.0}}, Duration: time.Minute},
+	)
+	clock := NewTickClock(time.Unix(0, 0), time.Second)
+	return NewModifierEngine(registry, clock), clock
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestModifierStacking(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy StackPolicy
+		want   float64
+	}{
+		{"multiply", StackPolicy{}, 1.1 * 1.2},
+		{"add", StackPolicy{Rule: StackAdd}, 1.3},
+		{"max", StackPolicy{Rule: StackMax}, 1.2},
+		{"capped", StackPolicy{Rule: StackAdd, Max: 1.25}, 1.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, _ := newEngineFixture(t)
+			engine.SetStacking("yield", tt.policy)
+			engine.Add(Effect{ID: "a", Tag: "yield", Modifiers: Modifiers{OutputYield: 1.1}})
+			engine.Add(Effect{ID: "b", Tag: "yield", Modifiers: Modifiers{OutputYield: 1.2}})
+			// A different tag always multiplies in
+			engine.Add(Effect{ID: "c", Tag: "other", Modifiers: Modifiers{OutputYield: 2}})
+
+			mods := engine.GetModifiers("player1", "plank")
+			if !approx(mods.OutputYield, tt.want*2) {
+				t.Errorf("output yield = %v, want %v", mods.OutputYield, tt.want*2)
+			}
+			if mods.InputCost != 1 || mods.TimeSpeed != 1 {
+				t.Errorf("unset fields should stay neutral: %+v", mods)
+			}
+		})
+	}
+}
+
+func TestModifierConditions(t *testing.T) {
+	engine, _ := newEngineFixture(t)
+	engine.Add(Effect{ID: "smith", Modifiers: Modifiers{TimeSpeed: 0.5}, Condition: WhenCategory("metal")})
+	engine.Add(Effect{ID: "forge", Modifiers: Modifiers{InputCost: 0.8}, Condition: WhenAttr("building", "forge")})
+	engine.Add(Effect{ID: "mine", Owner: "player2", Modifiers: Modifiers{OutputYield: 2}})
+
+	if mods := engine.GetModifiers("player1", "plank"); mods.TimeSpeed != 1 || mods.OutputYield != 1 {
+		t.Errorf("no effect should apply to plank for player1: %+v", mods)
+	}
+	if mods := engine.GetModifiers("player1", "ingot"); mods.TimeSpeed != 0.5 {
+		t.Errorf("category effect missing: %+v", mods)
+	}
+
+	job := &Job{Recipe: "ingot", Owner: "player1", Context: map[string]any{"building": "forge"}}
+	if mods := engine.GetJobModifiers(job); mods.InputCost != 0.8 || mods.TimeSpeed != 0.5 {
+		t.Errorf("job in a forge: %+v", mods)
+	}
+	if mods := engine.GetModifiers("player1", "ingot"); mods.InputCost != 1 {
+		t.Errorf("attribute effect applied without a job: %+v", mods)
+	}
+}
+
+func TestModifierExpiry(t *testing.T) {
+	engine, clock := newEngineFixture(t)
+	var changes []inventory.OwnerID
+	engine.OnChange(func(owner inventory.OwnerID) { changes = append(changes, owner) })
+
+	engine.AddFor(Effect{ID: "potion", Owner: "player1", Modifiers: Modifiers{TimeSpeed: 0.5}}, 10*time.Second)
+	engine.Add(Effect{ID: "banner", Owner: "player1", Modifiers: Modifiers{OutputYield: 1.5}, Expires: clock.TimeAt(20)})
+
+	clock.Advance(10)
+	if mods := engine.GetModifiers("player1", "plank"); mods.TimeSpeed != 1 || mods.OutputYield != 1.5 {
+		t.Errorf("potion should have expired: %+v", mods)
+	}
+	engine.Update()
+	if got := engine.Effects("player1"); len(got) != 1 || got[0].ID != "banner" {
+		t.Errorf("expected only banner left, got %v", got)
+	}
+	if len(changes) != 3 {
+		t.Errorf("expected 2 adds and 1 expiry reported, got %v", changes)
+	}
+
+	// Replacing an effect refreshes it
+	engine.AddFor(Effect{ID: "banner", Owner: "player1", Modifiers: Modifiers{OutputYield: 1.5}}, time.Minute)
+	clock.Advance(30)
+	if got := engine.Effects("player1"); len(got) != 1 {
+		t.Errorf("refreshed banner should still be active, got %v", got)
+	}
+}
+
+func TestRefreshModifiersRunningJob(t *testing.T) {
+	engine, clock := newEngineFixture(t)
+
+	mgr, inv, bus := newTestManager(engine.registry, engine)
+	mgr.SetClock(clock)
+	engine.OnChange(mgr.RefreshModifiers)
+
+	id, err := mgr.StartProduction("plank", "player1", "inv")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	// Halfway through, a buff doubles the speed: 30s left become 15s
+	clock.Advance(30)
+	engine.AddFor(Effect{ID: "haste", Owner: "player1", Modifiers: Modifiers{TimeSpeed: 0.5}}, 10*time.Second)
+	job := mgr.GetJob(id)
+	if want := clock.TimeAt(45); !job.EndTime.Equal(want) {
+		t.Fatalf("end time = %v, want %v", job.EndTime, want)
+	}
+	if p := job.CalculateProgress(clock.Now()); !approx(p, 0.5) {
+		t.Errorf("progress should be kept, got %v", p)
+	}
+	if n := bus.count(EventJobModifiersChanged); n != 1 {
+		t.Errorf("expected one modifiers-changed event, got %d", n)
+	}
+
+	// The buff runs out 10s later with 5s (1/6) left: back to 1/6 of 60s
+	clock.Advance(10)
+	engine.Update()
+	if want := clock.TimeAt(50); !mgr.GetJob(id).EndTime.Equal(want) {
+		t.Fatalf("end time after expiry = %v, want %v", mgr.GetJob(id).EndTime, want)
+	}
+
+	clock.Advance(9)
+	mgr.UpdateNow()
+	if countItem(inv, "plank") != 0 {
+		t.Fatal("job finished early")
+	}
+	clock.Advance(1)
+	mgr.UpdateNow()
+	if countItem(inv, "plank") != 1 {
+		t.Error("job did not finish on time")
+	}
+}
+
+func TestRepeatingRefundMatchesCyclePaid(t *testing.T) {
+	engine, clock := newEngineFixture(t)
+	if err := engine.registry.Register(&Recipe{
+		ID:       "beam",
+		Inputs:   []ItemRequirement{{Item: "wood", Quantity: 10, Consume: true}},
+		Outputs:  []ItemYield{{Item: "beam", Quantity: 1, Probability: 1.0}},
+		Duration: time.Minute,
+	}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	mgr, inv, _ := newTestManager(engine.registry, engine)
+	inv.AddStack(inventory.Stack{Item: "wood", Owner: "player1", Qty: 100})
+	mgr.SetClock(clock)
+
+	id, err := mgr.StartRepeatingProduction("beam", "player1", "inv")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	// The second cycle starts under a discount and pays 5 wood instead of 10
+	engine.Add(Effect{ID: "thrift", Owner: "player1", Modifiers: Modifiers{InputCost: 0.5}})
+	clock.Advance(60)
+	mgr.UpdateNow()
+	if got := countItem(inv, "wood"); got != 85 {
+		t.Fatalf("wood after two cycles = %d, want 85", got)
+	}
+
+	if err := mgr.CancelProductionWithRefund(id); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if got := countItem(inv, "wood"); got != 90 {
+		t.Errorf("wood after refund = %d, want 90 (the 5 the cycle paid)", got)
+	}
+}
@@ -0,0 +1,338 @@
+package production
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gravitas-015/inventory"
+)
+
+// CycleError reports a loop in the recipe graph (an item that, through its
+// only usable recipes, requires itself).
+type CycleError struct {
+	Items []inventory.ItemID // the loop, first item repeated at the end
+}
+
+// Error lists the items of the loop.
+func (e *CycleError) Error() string {
+	parts := make([]string, len(e.Items))
+	for i, item := range e.Items {
+		parts[i] = string(item)
+	}
+	return "recipe cycle: " + strings.Join(parts, " -> ")
+}
+
+// PlanStep is one recipe in a build order.
+type PlanStep struct {
+	Recipe   RecipeID         `json:"recipe"`
+	Item     inventory.ItemID `json:"item"`     // the item this step is run for
+	Runs     int              `json:"runs"`     // number of production cycles
+	Produced int              `json:"produced"` // expected units of Item (>= demand)
+	Duration time.Duration    `json:"duration"` // Runs * effective duration
+}
+
+// Plan is a full production chain for a target.
+type Plan struct {
+	Item       inventory.ItemID         `json:"item"`
+	Quantity   int                      `json:"quantity"`
+	Steps      []PlanStep               `json:"steps"`      // build order: every step after the steps it depends on
+	Raw        map[inventory.ItemID]int `json:"raw"`        // raw materials consumed
+	Tools      map[inventory.ItemID]int `json:"tools"`      // non-consumed inputs needed at once
+	Byproducts map[inventory.ItemID]int `json:"byproducts"` // expected surplus, including rounding leftovers
+	TotalTime  time.Duration            `json:"totalTime"`  // sum of all step durations (one producer)
+}
+
+// Planner walks the recipe graph to answer "what does it take to make N of
+// X": the recipes to run in order, the raw-material bill and the total time,
+// with modifiers applied the same way Manager applies them.
+//
+// Probabilistic outputs count at their expected yield. Byproducts are
+// reported but not used to cover other demands.
+type Planner struct {
+	registry *RecipeRegistry
+
+	// Owner and Sources resolve modifiers, as Manager does at job start.
+	Owner   inventory.OwnerID
+	Sources []ModifierSource
+
+	// Cost ranks alternative recipes for an item; the lowest wins and ties
+	// go to the lower recipe ID. The default is effective time per unit.
+	Cost func(recipe *Recipe, mods Modifiers) float64
+
+	// Raw marks items that are gathered rather than produced, even if a
+	// recipe makes them. Items with no recipe are always raw.
+	Raw func(item inventory.ItemID) bool
+}
+
+// NewPlanner creates a planner over registry with no modifiers.
+func NewPlanner(registry *RecipeRegistry) *Planner {
+	return &Planner{registry: registry}
+}
+
+// Planner returns a planner using the manager's recipes and modifier
+// sources on behalf of owner.
+func (m *Manager) Planner(owner inventory.OwnerID) *Planner {
+	p := NewPlanner(m.registry)
+	p.Owner = owner
+	p.Sources = m.modifierSources
+	return p
+}
+
+// Plan computes the production chain for quantity units of item.
+func (p *Planner) Plan(item inventory.ItemID, quantity int) (*Plan, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be positive, got %d", quantity)
+	}
+	if len(p.registry.GetByOutput(item)) == 0 {
+		return nil, fmt.Errorf("no recipe produces %s", item)
+	}
+
+	// 1. Choose a recipe for every item in the chain
+	s := &planState{
+		planner: p,
+		chosen:  make(map[inventory.ItemID]*Recipe),
+		mods:    make(map[RecipeID]Modifiers),
+		onStack: make(map[inventory.ItemID]bool),
+	}
+	if err := s.choose(item, nil, true); err != nil {
+		return nil, err
+	}
+
+	// 2. Propagate demand from the target down, in dependency order
+	order := s.topoOrder(item)
+	plan := &Plan{
+		Item:       item,
+		Quantity:   quantity,
+		Raw:        make(map[inventory.ItemID]int),
+		Tools:      make(map[inventory.ItemID]int),
+		Byproducts: make(map[inventory.ItemID]int),
+	}
+	demand := map[inventory.ItemID]float64{item: float64(quantity)}
+	var steps []PlanStep
+	for _, it := range order {
+		need := demand[it]
+		recipe := s.chosen[it]
+		if recipe == nil {
+			if need > 0 {
+				plan.Raw[it] += int(math.Ceil(need - 1e-9))
+			}
+			continue
+		}
+		if need <= 0 {
+			continue
+		}
+		mods := s.mods[recipe.ID]
+		outputs := applyOutputModifiers(recipe.Outputs, mods.OutputYield)
+		perRun := expectedYield(outputs, it)
+		runs := int(math.Ceil(need/perRun - 1e-9))
+		duration := time.Duration(applyDurationModifier(int64(recipe.Duration), mods.TimeSpeed)) * time.Duration(runs)
+
+		for _, in := range applyInputModifiers(recipe.Inputs, mods.InputCost) {
+			if in.Consume {
+				demand[in.Item] += float64(in.Quantity * runs)
+			} else if in.Quantity > plan.Tools[in.Item] {
+				plan.Tools[in.Item] = in.Quantity
+			}
+		}
+		counted := map[inventory.ItemID]bool{it: true}
+		for _, out := range outputs {
+			if !counted[out.Item] {
+				counted[out.Item] = true
+				plan.Byproducts[out.Item] += int(math.Floor(expectedYield(outputs, out.Item)*float64(runs) + 1e-9))
+			}
+		}
+		produced := int(math.Floor(perRun*float64(runs) + 1e-9))
+		if extra := produced - int(math.Ceil(need-1e-9)); extra > 0 {
+			plan.Byproducts[it] += extra
+		}
+
+		steps = append(steps, PlanStep{Recipe: recipe.ID, Item: it, Runs: runs, Produced: produced, Duration: duration})
+		plan.TotalTime += duration
+	}
+	for item, n := range plan.Byproducts {
+		if n <= 0 {
+			delete(plan.Byproducts, item)
+		}
+	}
+
+	// Demand flowed from dependents to dependencies; build the other way
+	for i := len(steps) - 1; i >= 0; i-- {
+		plan.Steps = append(plan.Steps, steps[i])
+	}
+	return plan, nil
+}
+
+// planState holds the recipe choices of one Plan call.
+type planState struct {
+	planner *Planner
+	chosen  map[inventory.ItemID]*Recipe // nil value: raw item
+	mods    map[RecipeID]Modifiers
+	onStack map[inventory.ItemID]bool
+}
+
+// choose picks a recipe for item and, recursively, for its inputs. path is
+// the chain of items leading here, for cycle reports. The target is never
+// treated as raw.
+func (s *planState) choose(item inventory.ItemID, path []inventory.ItemID, target bool) error {
+	if _, done := s.chosen[item]; done {
+		return nil
+	}
+	path = append(path, item)
+	if s.onStack[item] {
+		for i, it := range path {
+			if it == item {
+				return &CycleError{Items: append([]inventory.ItemID(nil), path[i:]...)}
+			}
+		}
+	}
+
+	candidates := s.candidates(item)
+	if len(candidates) == 0 || (!target && s.planner.Raw != nil && s.planner.Raw(item)) {
+		s.chosen[item] = nil
+		return nil
+	}
+
+	s.onStack[item] = true
+	defer delete(s.onStack, item)
+
+	// Take the cheapest candidate whose inputs can all be planned
+	var firstErr error
+	for _, recipe := range candidates {
+		err := s.chooseInputs(recipe, path)
+		if err == nil {
+			s.chosen[item] = recipe
+			return nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// chooseInputs plans every consumed input of recipe. Choices made for
+// inputs stay even if a later input fails: each is complete and acyclic on
+// its own, so another candidate can reuse it.
+func (s *planState) chooseInputs(recipe *Recipe, path []inventory.ItemID) error {
+	for _, in := range recipe.Inputs {
+		if !in.Consume {
+			continue
+		}
+		if err := s.choose(in.Item, path, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// candidates returns the recipes producing item, cheapest first.
+func (s *planState) candidates(item inventory.ItemID) []*Recipe {
+	type ranked struct {
+		recipe *Recipe
+		cost   float64
+	}
+	var list []ranked
+	for _, id := range s.planner.registry.GetByOutput(item) {
+		recipe := s.planner.registry.Lookup(id)
+		if recipe == nil {
+			continue
+		}
+		mods := s.modifiers(recipe.ID)
+		if expectedYield(applyOutputModifiers(recipe.Outputs, mods.OutputYield), item) <= 0 {
+			continue
+		}
+		list = append(list, ranked{recipe: recipe, cost: s.cost(recipe, item, mods)})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].cost != list[j].cost {
+			return list[i].cost < list[j].cost
+		}
+		return list[i].recipe.ID < list[j].recipe.ID
+	})
+	result := make([]*Recipe, len(list))
+	for i, r := range list {
+		result[i] = r.recipe
+	}
+	return result
+}
+
+// cost ranks a recipe for item with the planner's cost function.
+func (s *planState) cost(recipe *Recipe, item inventory.ItemID, mods Modifiers) float64 {
+	if s.planner.Cost != nil {
+		return s.planner.Cost(recipe, mods)
+	}
+	duration := float64(applyDurationModifier(int64(recipe.Duration), mods.TimeSpeed))
+	return duration / expectedYield(applyOutputModifiers(recipe.Outputs, mods.OutputYield), item)
+}
+
+// modifiers resolves (and caches) the modifiers for a recipe.
+func (s *planState) modifiers(id RecipeID) Modifiers {
+	if mods, ok := s.mods[id]; ok {
+		return mods
+	}
+	mods := DefaultModifiers()
+	for _, source := range s.planner.Sources {
+		mods = mods.Combine(source.GetModifiers(s.planner.Owner, id))
+	}
+	s.mods[id] = mods
+	return mods
+}
+
+// topoOrder returns the chosen items reachable from root with every item
+// before the items it consumes.
+func (s *planState) topoOrder(root inventory.ItemID) []inventory.ItemID {
+	var post []inventory.ItemID
+	visited := make(map[inventory.ItemID]bool)
+	var visit func(item inventory.ItemID)
+	visit = func(item inventory.ItemID) {
+		if visited[item] {
+			return
+		}
+		visited[item] = true
+		if recipe := s.chosen[item]; recipe != nil {
+			for _, in := range recipe.Inputs {
+				if in.Consume {
+					visit(in.Item)
+				}
+			}
+		}
+		post = append(post, item)
+	}
+	visit(root)
+
+	order := make([]inventory.ItemID, len(post))
+	for i, item := range post {
+		order[len(post)-1-i] = item
+	}
+	return order
+}
+
+// expectedYield returns the expected units of item per run of outputs.
+// Group members count by their share of the group's weight.
+func expectedYield(outputs []ItemYield, item inventory.ItemID) float64 {
+	groupTotal := make(map[string]float64)
+	for _, out := range outputs {
+		if out.Group != "" {
+			groupTotal[out.Group] += groupWeight(out)
+		}
+	}
+	total := 0.0
+	for _, out := range outputs {
+		if out.Item != item {
+			continue
+		}
+		if out.Group != "" {
+			total += float64(out.Quantity) * groupWeight(out) / groupTotal[out.Group]
+			continue
+		}
+		prob := out.Probability
+		if prob <= 0 || prob > 1 {
+			prob = 1
+		}
+		total += float64(out.Quantity) * prob
+	}
+	return total
+}
@@ -0,0 +1,177 @@
+package production
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gravitas-015/inventory"
+)
+
+// modifierFunc adapts a function to ModifierSource.
+type modifierFunc func(owner inventory.OwnerID, recipe RecipeID) Modifiers
+
+func (f modifierFunc) GetModifiers(owner inventory.OwnerID, recipe RecipeID) Modifiers {
+	return f(owner, recipe)
+}
+
+func newPlanRegistry(t *testing.T, recipes ...*Recipe) *RecipeRegistry {
+	t.Helper()
+	registry := NewRecipeRegistry()
+	for _, r := range recipes {
+		if err := registry.Register(r); err != nil {
+			t.Fatalf("register %s: %v", r.ID, err)
+		}
+	}
+	return registry
+}
+
+var (
+	smeltRecipe = &Recipe{
+		ID:       "smelt",
+		Inputs:   []ItemRequirement{{Item: "ore", Quantity: 2, Consume: true}},
+		Outputs:  []ItemYield{{Item: "ingot", Quantity: 1}},
+		Duration: 10 * time.Second,
+	}
+	plateRecipe = &Recipe{
+		ID: "press",
+		Inputs: []ItemRequirement{
+			{Item: "ingot", Quantity: 3, Consume: true},
+			{Item: "press_die", Quantity: 1, Consume: false},
+		},
+		Outputs:  []ItemYield{{Item: "plate", Quantity: 2}, {Item: "slag", Quantity: 1}},
+		Duration: 20 * time.Second,
+	}
+	fastSmeltRecipe = &Recipe{
+		ID: "blast",
+		Inputs: []ItemRequirement{
+			{Item: "ore", Quantity: 1, Consume: true},
+			{Item: "coal", Quantity: 1, Consume: true},
+		},
+		Outputs:  []ItemYield{{Item: "ingot", Quantity: 1}},
+		Duration: 5 * time.Second,
+	}
+)
+
+func TestPlannerChain(t *testing.T) {
+	p := NewPlanner(newPlanRegistry(t, smeltRecipe, plateRecipe))
+	plan, err := p.Plan("plate", 10)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+
+	if len(plan.Steps) != 2 || plan.Steps[0].Recipe != "smelt" || plan.Steps[1].Recipe != "press" {
+		t.Fatalf("unexpected build order %+v", plan.Steps)
+	}
+	if plan.Steps[0].Runs != 15 || plan.Steps[1].Runs != 5 {
+		t.Errorf("runs: smelt %d, press %d; want 15 and 5", plan.Steps[0].Runs, plan.Steps[1].Runs)
+	}
+	if plan.Raw["ore"] != 30 || len(plan.Raw) != 1 {
+		t.Errorf("raw bill %v, want 30 ore", plan.Raw)
+	}
+	if plan.Tools["press_die"] != 1 {
+		t.Errorf("tools %v", plan.Tools)
+	}
+	if plan.Byproducts["slag"] != 5 {
+		t.Errorf("byproducts %v", plan.Byproducts)
+	}
+	if plan.TotalTime != 250*time.Second {
+		t.Errorf("total time %v, want 250s", plan.TotalTime)
+	}
+
+	// Rounding up to whole runs leaves a surplus
+	plan, _ = p.Plan("plate", 3)
+	if plan.Steps[1].Runs != 2 || plan.Byproducts["plate"] != 1 || plan.Raw["ore"] != 12 {
+		t.Errorf("odd quantity: %+v", plan)
+	}
+
+	if _, err := p.Plan("ore", 1); err == nil {
+		t.Error("expected error planning a raw item")
+	}
+}
+
+func TestPlannerAlternatives(t *testing.T) {
+	registry := newPlanRegistry(t, smeltRecipe, fastSmeltRecipe, plateRecipe)
+
+	// Default cost (time per unit) prefers the blast furnace
+	plan, err := NewPlanner(registry).Plan("ingot", 4)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if plan.Steps[0].Recipe != "blast" || plan.Raw["coal"] != 4 || plan.Raw["ore"] != 4 {
+		t.Errorf("expected blast furnace plan, got %+v", plan)
+	}
+
+	// A cost function that avoids coal picks the slow smelter
+	p := NewPlanner(registry)
+	p.Cost = func(r *Recipe, _ Modifiers) float64 { return float64(len(r.Inputs)) }
+	plan, _ = p.Plan("ingot", 4)
+	if plan.Steps[0].Recipe != "smelt" || plan.Raw["ore"] != 8 {
+		t.Errorf("expected smelter plan, got %+v", plan)
+	}
+
+	// Raw overrides stop the walk
+	p = NewPlanner(registry)
+	p.Raw = func(item inventory.ItemID) bool { return item == "ingot" }
+	plan, _ = p.Plan("plate", 2)
+	if len(plan.Steps) != 1 || plan.Raw["ingot"] != 3 {
+		t.Errorf("expected ingots as raw, got %+v", plan)
+	}
+}
+
+func TestPlannerModifiers(t *testing.T) {
+	p := NewPlanner(newPlanRegistry(t, smeltRecipe, plateRecipe))
+	p.Sources = []ModifierSource{modifierFunc(func(_ inventory.OwnerID, r RecipeID) Modifiers {
+		mods := DefaultModifiers()
+		if r == "press" {
+			mods.OutputYield = 2.0
+			mods.TimeSpeed = 0.5
+		}
+		return mods
+	})}
+
+	plan, err := p.Plan("plate", 10)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	press := plan.Steps[1]
+	if press.Runs != 3 || press.Produced != 12 || press.Duration != 30*time.Second {
+		t.Errorf("modified press step %+v", press)
+	}
+	if plan.Raw["ore"] != 18 {
+		t.Errorf("raw ore %d, want 18", plan.Raw["ore"])
+	}
+}
+
+func TestPlannerCycles(t *testing.T) {
+	loop := []*Recipe{
+		{ID: "a_from_b", Inputs: []ItemRequirement{{Item: "b", Quantity: 1, Consume: true}}, Outputs: []ItemYield{{Item: "a", Quantity: 1}}, Duration: time.Second},
+		{ID: "b_from_c", Inputs: []ItemRequirement{{Item: "c", Quantity: 1, Consume: true}}, Outputs: []ItemYield{{Item: "b", Quantity: 1}}, Duration: time.Second},
+		{ID: "c_from_a", Inputs: []ItemRequirement{{Item: "a", Quantity: 1, Consume: true}}, Outputs: []ItemYield{{Item: "c", Quantity: 1}}, Duration: time.Second},
+	}
+	registry := newPlanRegistry(t, loop...)
+
+	_, err := NewPlanner(registry).Plan("a", 1)
+	var cycle *CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("expected CycleError, got %v", err)
+	}
+	if want := "recipe cycle: a -> b -> c -> a"; cycle.Error() != want {
+		t.Errorf("got %q, want %q", cycle.Error(), want)
+	}
+
+	// A slower way out of the loop makes it plannable
+	registry.Register(&Recipe{
+		ID:       "c_from_sand",
+		Inputs:   []ItemRequirement{{Item: "sand", Quantity: 1, Consume: true}},
+		Outputs:  []ItemYield{{Item: "c", Quantity: 1}},
+		Duration: time.Minute,
+	})
+	plan, err := NewPlanner(registry).Plan("a", 2)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if plan.Raw["sand"] != 2 || len(plan.Steps) != 3 || plan.Steps[0].Recipe != "c_from_sand" {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+}
@@ -0,0 +1,498 @@
+package production
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gravitas-015/inventory"
+)
+
+// ProducerID identifies a building or other producer that owns a queue.
+type ProducerID string
+
+// InputPolicy controls when a queued job pays for its inputs.
+type InputPolicy int
+
+const (
+	// ConsumeOnStart takes inputs when the job leaves the queue for a slot.
+	// Until then the job holds nothing and waits if inputs are missing.
+	ConsumeOnStart InputPolicy = iota
+	// ReserveOnEnqueue earmarks inputs when the job is queued. Reserved items
+	// stay in the inventory but other queued jobs cannot start with them;
+	// they are consumed when the job starts.
+	ReserveOnEnqueue
+	// ConsumeOnEnqueue takes inputs when the job is queued. They are refunded
+	// if the job is removed before it starts.
+	ConsumeOnEnqueue
+)
+
+// String returns a human-readable representation of the policy.
+func (p InputPolicy) String() string {
+	switch p {
+	case ConsumeOnStart:
+		return "ConsumeOnStart"
+	case ReserveOnEnqueue:
+		return "ReserveOnEnqueue"
+	case ConsumeOnEnqueue:
+		return "ConsumeOnEnqueue"
+	default:
+		return "Unknown"
+	}
+}
+
+// QueueConfig configures a producer's queue.
+type QueueConfig struct {
+	Slots  int         // Number of jobs that may run at once (must be >= 1)
+	Policy InputPolicy // When queued jobs pay for inputs
+	Strict bool        // If true, a waiting job blocks the jobs behind it
+}
+
+// QueueRequest describes a job to add to a producer's queue.
+type QueueRequest struct {
+	Recipe      RecipeID
+	Owner       inventory.OwnerID
+	InventoryID string
+	Priority    int  // Higher priorities are queued ahead of lower ones
+	Repeat      bool // Start as a repeating job (occupies its slot until it stops)
+
+	// Context is copied into Job.Context, e.g. the building type or terrain
+	// for conditional modifiers. The "producer" key is set by the queue.
+	Context map[string]any
+}
+
+// Queues runs production queues on top of a Manager, one per producer.
+// Each producer has a number of slots; queued jobs start in queue order as
+// slots free up and their inputs are available. Queued jobs are regular
+// Jobs in state JobPending and keep their ID once started.
+type Queues struct {
+	mgr *Manager
+
+	mu        sync.Mutex
+	producers map[ProducerID]*producerQueue
+	reserved  map[string]map[inventory.ItemID]int // inventory ID -> reserved quantities
+}
+
+// producerQueue is the queue and running slots of one producer.
+type producerQueue struct {
+	id      ProducerID
+	config  QueueConfig
+	entries []*queueEntry
+	running []JobID
+}
+
+// queueEntry is a queued job and what it holds.
+type queueEntry struct {
+	job      *Job
+	priority int
+	paid     []ItemRequirement // inputs consumed at enqueue (ConsumeOnEnqueue)
+	reserved []ItemRequirement // inputs reserved at enqueue (ReserveOnEnqueue)
+	waiting  bool
+}
+
+// NewQueues creates queues that start their jobs on m.
+func NewQueues(m *Manager) *Queues {
+	return &Queues{
+		mgr:       m,
+		producers: make(map[ProducerID]*producerQueue),
+		reserved:  make(map[string]map[inventory.ItemID]int),
+	}
+}
+
+// Configure creates a producer queue or changes its configuration.
+// Shrinking the slot count does not stop running jobs; it only delays new
+// starts. Changing the input policy applies to jobs queued afterwards.
+func (q *Queues) Configure(producer ProducerID, config QueueConfig) error {
+	if config.Slots < 1 {
+		return fmt.Errorf("producer %s: slots must be at least 1", producer)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pq, exists := q.producers[producer]
+	if !exists {
+		pq = &producerQueue{id: producer}
+		q.producers[producer] = pq
+	}
+	pq.config = config
+	q.fill(pq, q.mgr.Now())
+	return nil
+}
+
+// Enqueue adds a job to the producer's queue and starts it right away if a
+// slot is free and its inputs are available.
+// Under ReserveOnEnqueue and ConsumeOnEnqueue it fails if the inputs are not
+// available now.
+func (q *Queues) Enqueue(producer ProducerID, req QueueRequest) (JobID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pq, exists := q.producers[producer]
+	if !exists {
+		return "", fmt.Errorf("producer not found: %s", producer)
+	}
+
+	entry := &queueEntry{
+		job: &Job{
+			ID:          q.mgr.generateJobID(),
+			Recipe:      req.Recipe,
+			Owner:       req.Owner,
+			InventoryID: req.InventoryID,
+			State:       JobPending,
+			Repeat:      req.Repeat,
+			Context:     make(map[string]any, len(req.Context)+1),
+		},
+		priority: req.Priority,
+	}
+	for k, v := range req.Context {
+		entry.job.Context[k] = v
+	}
+	entry.job.Context["producer"] = producer
+	inputs, err := q.mgr.effectiveInputs(entry.job)
+	if err != nil {
+		return "", err
+	}
+
+	switch pq.config.Policy {
+	case ReserveOnEnqueue:
+		if err := q.affordable(req.InventoryID, inputs); err != nil {
+			return "", fmt.Errorf("insufficient resources: %w", err)
+		}
+		entry.reserved = inputs
+		q.reserve(req.InventoryID, inputs, 1)
+	case ConsumeOnEnqueue:
+		if err := q.affordable(req.InventoryID, inputs); err != nil {
+			return "", fmt.Errorf("insufficient resources: %w", err)
+		}
+		inv, err := q.mgr.inventories.GetInventory(req.InventoryID)
+		if err != nil {
+			return "", fmt.Errorf("inventory not found: %w", err)
+		}
+		if err := q.mgr.inventories.ConsumeItems(inv, inputs); err != nil {
+			return "", fmt.Errorf("insufficient resources: %w", err)
+		}
+		entry.paid = inputs
+	}
+
+	pos := pq.insert(entry)
+
+	now := q.mgr.Now()
+	q.publish(EventJobQueued, entry.job, now, pq.id, pos)
+	q.fill(pq, now)
+	return entry.job.ID, nil
+}
+
+// Remove takes a job out of the queue before it starts, releasing its
+// reservation or refunding its inputs. Running jobs are cancelled through
+// the Manager instead; their slot frees on the next Update.
+func (q *Queues) Remove(producer ProducerID, jobID JobID) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pq, i, err := q.find(producer, jobID)
+	if err != nil {
+		return err
+	}
+	entry := pq.entries[i]
+
+	if entry.paid != nil {
+		if err := q.mgr.refund(entry.job.InventoryID, entry.paid); err != nil {
+			return err
+		}
+	}
+	if entry.reserved != nil {
+		q.reserve(entry.job.InventoryID, entry.reserved, -1)
+	}
+	pq.entries = append(pq.entries[:i], pq.entries[i+1:]...)
+
+	entry.job.State = JobCancelled
+	now := q.mgr.Now()
+	q.publish(EventJobDequeued, entry.job, now, pq.id, i)
+	// A strict queue may have been blocked by the removed job
+	q.fill(pq, now)
+	return nil
+}
+
+// Move places a queued job at position index (clamped to the queue).
+// Its priority is unchanged: Move only reorders it relative to jobs already
+// queued.
+func (q *Queues) Move(producer ProducerID, jobID JobID, index int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pq, i, err := q.find(producer, jobID)
+	if err != nil {
+		return err
+	}
+	entry := pq.entries[i]
+	pq.entries = append(pq.entries[:i], pq.entries[i+1:]...)
+
+	index = max(0, min(index, len(pq.entries)))
+	pq.insertAt(entry, index)
+
+	now := q.mgr.Now()
+	q.publish(EventQueueReordered, entry.job, now, pq.id, index)
+	q.fill(pq, now)
+	return nil
+}
+
+// SetPriority changes a queued job's priority and moves it behind the last
+// job of equal or higher priority.
+func (q *Queues) SetPriority(producer ProducerID, jobID JobID, priority int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pq, i, err := q.find(producer, jobID)
+	if err != nil {
+		return err
+	}
+	entry := pq.entries[i]
+	pq.entries = append(pq.entries[:i], pq.entries[i+1:]...)
+	entry.priority = priority
+	pos := pq.insert(entry)
+
+	now := q.mgr.Now()
+	q.publish(EventQueueReordered, entry.job, now, pq.id, pos)
+	q.fill(pq, now)
+	return nil
+}
+
+// Pending returns the queued jobs of a producer in queue order.
+func (q *Queues) Pending(producer ProducerID) []*Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pq, exists := q.producers[producer]
+	if !exists {
+		return nil
+	}
+	result := make([]*Job, len(pq.entries))
+	for i, e := range pq.entries {
+		result[i] = e.job
+	}
+	return result
+}
+
+// Running returns the IDs of the jobs occupying a producer's slots.
+func (q *Queues) Running(producer ProducerID) []JobID {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pq, exists := q.producers[producer]
+	if !exists {
+		return nil
+	}
+	q.reap(pq)
+	result := make([]JobID, len(pq.running))
+	copy(result, pq.running)
+	return result
+}
+
+// Update advances the Manager to now, then fills every free slot from the
+// queues. Call it instead of Manager.Update when queues are in use.
+func (q *Queues) Update(now time.Time) {
+	q.mgr.Update(now)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Visit producers in a fixed order so reservations resolve the same way
+	// every time when producers share an inventory.
+	ids := make([]ProducerID, 0, len(q.producers))
+	for id := range q.producers {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		q.fill(q.producers[id], now)
+	}
+}
+
+// UpdateNow runs Update at the manager clock's current time.
+func (q *Queues) UpdateNow() {
+	q.Update(q.mgr.Now())
+}
+
+// fill frees the slots of finished jobs and starts queued jobs into free
+// slots (caller must hold lock).
+func (q *Queues) fill(pq *producerQueue, now time.Time) {
+	q.reap(pq)
+
+	for i := 0; i < len(pq.entries) && len(pq.running) < pq.config.Slots; {
+		entry := pq.entries[i]
+		if err := q.start(entry); err != nil {
+			if !entry.waiting {
+				entry.waiting = true
+				q.mgr.eventBus.Publish(Event{
+					Type:      EventJobWaiting,
+					Job:       entry.job,
+					Timestamp: now,
+					Data: map[string]any{
+						"producer": pq.id,
+						"position": i,
+						"error":    err.Error(),
+					},
+				})
+			}
+			if pq.config.Strict {
+				return
+			}
+			i++
+			continue
+		}
+		pq.entries = append(pq.entries[:i], pq.entries[i+1:]...)
+		pq.running = append(pq.running, entry.job.ID)
+	}
+}
+
+// start tries to start a queued job (caller must hold lock).
+func (q *Queues) start(entry *queueEntry) error {
+	job := entry.job
+	if entry.paid != nil {
+		return q.mgr.startJob(job, entry.paid)
+	}
+
+	inputs, err := q.mgr.effectiveInputs(job)
+	if err != nil {
+		return err
+	}
+
+	// The job's own reservation is released before checking what is left,
+	// and restored if it still cannot start.
+	if entry.reserved != nil {
+		q.reserve(job.InventoryID, entry.reserved, -1)
+	}
+	err = q.affordable(job.InventoryID, inputs)
+	if err == nil {
+		err = q.mgr.startJob(job, nil)
+	}
+	if err != nil {
+		if entry.reserved != nil {
+			q.reserve(job.InventoryID, entry.reserved, 1)
+		}
+		job.State = JobPending
+		return err
+	}
+	return nil
+}
+
+// reap drops finished jobs from a producer's slots (caller must hold lock).
+func (q *Queues) reap(pq *producerQueue) {
+	kept := pq.running[:0]
+	for _, id := range pq.running {
+		if q.mgr.isActive(id) {
+			kept = append(kept, id)
+		}
+	}
+	pq.running = kept
+}
+
+// affordable checks that an inventory holds inputs on top of everything
+// reserved by queued jobs (caller must hold lock). Tools are only checked
+// for presence since they are never consumed.
+func (q *Queues) affordable(inventoryID string, inputs []ItemRequirement) error {
+	inv, err := q.mgr.inventories.GetInventory(inventoryID)
+	if err != nil {
+		return fmt.Errorf("inventory not found: %w", err)
+	}
+	reserved := q.reserved[inventoryID]
+	for _, req := range inputs {
+		available := countItem(inv, req.Item)
+		if req.Consume {
+			available -= reserved[req.Item]
+		}
+		if available < req.Quantity {
+			return fmt.Errorf("insufficient %s: have %d unreserved, need %d", req.Item, available, req.Quantity)
+		}
+	}
+	return nil
+}
+
+// reserve adds (sign 1) or releases (sign -1) a reservation of the consumed
+// inputs (caller must hold lock).
+func (q *Queues) reserve(inventoryID string, inputs []ItemRequirement, sign int) {
+	reserved := q.reserved[inventoryID]
+	if reserved == nil {
+		reserved = make(map[inventory.ItemID]int)
+		q.reserved[inventoryID] = reserved
+	}
+	for _, req := range inputs {
+		if !req.Consume {
+			continue
+		}
+		reserved[req.Item] += sign * req.Quantity
+		if reserved[req.Item] <= 0 {
+			delete(reserved, req.Item)
+		}
+	}
+	if len(reserved) == 0 {
+		delete(q.reserved, inventoryID)
+	}
+}
+
+// insert places an entry behind every entry of equal or higher priority and
+// returns its position.
+func (pq *producerQueue) insert(entry *queueEntry) int {
+	pos := len(pq.entries)
+	for i, e := range pq.entries {
+		if e.priority < entry.priority {
+			pos = i
+			break
+		}
+	}
+	pq.insertAt(entry, pos)
+	return pos
+}
+
+// insertAt places an entry at position i.
+func (pq *producerQueue) insertAt(entry *queueEntry, i int) {
+	pq.entries = append(pq.entries, nil)
+	copy(pq.entries[i+1:], pq.entries[i:])
+	pq.entries[i] = entry
+}
+
+// find locates a queued job (caller must hold lock).
+func (q *Queues) find(producer ProducerID, jobID JobID) (*producerQueue, int, error) {
+	pq, exists := q.producers[producer]
+	if !exists {
+		return nil, 0, fmt.Errorf("producer not found: %s", producer)
+	}
+	for i, e := range pq.entries {
+		if e.job.ID == jobID {
+			return pq, i, nil
+		}
+	}
+	for _, id := range pq.running {
+		if id == jobID {
+			return nil, 0, errors.New("job already started")
+		}
+	}
+	return nil, 0, errors.New("job not found in queue")
+}
+
+// publish emits a queue event for a queued job.
+func (q *Queues) publish(t EventType, job *Job, now time.Time, producer ProducerID, position int) {
+	q.mgr.eventBus.Publish(Event{
+		Type:      t,
+		Job:       job,
+		Timestamp: now,
+		Data: map[string]any{
+			"producer": producer,
+			"position": position,
+		},
+	})
+}
+
+// countItem counts the total quantity of an item in an inventory.
+func countItem(inv *inventory.Inventory, itemID inventory.ItemID) int {
+	total := 0
+	for _, stack := range inv.Stacks {
+		if stack.Item == itemID {
+			total += stack.Qty
+		}
+	}
+	return total
+}
@@ -0,0 +1,224 @@
+package production
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitas-015/inventory"
+)
+
+// newQueueFixture returns queues over a manager with a one-plank recipe
+// (2 wood, 1 minute) and an inventory holding wood.
+func newQueueFixture(t *testing.T, wood int, config QueueConfig) (*Queues, *inventory.Inventory, *recordingBus) {
+	t.Helper()
+	mgr, inv, bus := newTestManager(newTestRegistry(t, &Recipe{
+		ID:       "plank",
+		Inputs:   []ItemRequirement{{Item: "wood", Quantity: 2, Consume: true}},
+		Outputs:  []ItemYield{{Item: "plank", Quantity: 1, Probability: 1.0}},
+		Duration: time.Minute,
+	}))
+	if wood > 0 {
+		inv.AddStack(inventory.Stack{Item: "wood", Owner: "player1", Qty: wood})
+	}
+	q := NewQueues(mgr)
+	if err := q.Configure("sawmill", config); err != nil {
+		t.Fatalf("configure: %v", err)
+	}
+	return q, inv, bus
+}
+
+func enqueuePlank(t *testing.T, q *Queues, priority int) JobID {
+	t.Helper()
+	id, err := q.Enqueue("sawmill", QueueRequest{Recipe: "plank", Owner: "player1", InventoryID: "inv", Priority: priority})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	return id
+}
+
+func pendingIDs(q *Queues) []JobID {
+	var ids []JobID
+	for _, job := range q.Pending("sawmill") {
+		ids = append(ids, job.ID)
+	}
+	return ids
+}
+
+func countWood(inv *inventory.Inventory) int { return countItem(inv, "wood") }
+
+func TestQueueSlots(t *testing.T) {
+	q, inv, bus := newQueueFixture(t, 20, QueueConfig{Slots: 2})
+
+	a := enqueuePlank(t, q, 0)
+	b := enqueuePlank(t, q, 0)
+	c := enqueuePlank(t, q, 0)
+
+	if got := q.Running("sawmill"); len(got) != 2 || got[0] != a || got[1] != b {
+		t.Fatalf("expected %s and %s running, got %v", a, b, got)
+	}
+	if got := pendingIDs(q); len(got) != 1 || got[0] != c {
+		t.Fatalf("expected %s queued, got %v", c, got)
+	}
+	// ConsumeOnStart: only the running jobs have paid
+	if got := countWood(inv); got != 16 {
+		t.Errorf("expected 16 wood left, got %d", got)
+	}
+
+	// The started job keeps its queued ID
+	if job := q.mgr.GetJob(a); job == nil || job.State != JobRunning || job.Context["producer"] != ProducerID("sawmill") {
+		t.Errorf("running job %s not found with producer context: %+v", a, job)
+	}
+
+	q.Update(time.Now().Add(2 * time.Minute))
+	if got := q.Running("sawmill"); len(got) != 1 || got[0] != c {
+		t.Errorf("expected %s to take a freed slot, got %v", c, got)
+	}
+	if got := countItem(inv, "plank"); got != 2 {
+		t.Errorf("expected 2 planks, got %d", got)
+	}
+	if n := bus.count(EventJobQueued); n != 3 {
+		t.Errorf("expected 3 queued events, got %d", n)
+	}
+}
+
+func TestQueueOrdering(t *testing.T) {
+	q, _, _ := newQueueFixture(t, 20, QueueConfig{Slots: 1})
+
+	running := enqueuePlank(t, q, 0)
+	low := enqueuePlank(t, q, 0)
+	high := enqueuePlank(t, q, 5)
+	low2 := enqueuePlank(t, q, 0)
+
+	want := []JobID{high, low, low2}
+	if got := pendingIDs(q); !equalIDs(got, want) {
+		t.Fatalf("priority order: got %v, want %v", got, want)
+	}
+
+	if err := q.Move("sawmill", low2, 0); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	want = []JobID{low2, high, low}
+	if got := pendingIDs(q); !equalIDs(got, want) {
+		t.Fatalf("after move: got %v, want %v", got, want)
+	}
+
+	if err := q.SetPriority("sawmill", low, 9); err != nil {
+		t.Fatalf("set priority: %v", err)
+	}
+	want = []JobID{low, low2, high}
+	if got := pendingIDs(q); !equalIDs(got, want) {
+		t.Fatalf("after priority change: got %v, want %v", got, want)
+	}
+
+	if err := q.Remove("sawmill", low2); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := q.Remove("sawmill", running); err == nil {
+		t.Error("expected error removing a running job")
+	}
+	want = []JobID{low, high}
+	if got := pendingIDs(q); !equalIDs(got, want) {
+		t.Fatalf("after remove: got %v, want %v", got, want)
+	}
+}
+
+func TestQueueWaitsForInputs(t *testing.T) {
+	q, inv, bus := newQueueFixture(t, 0, QueueConfig{Slots: 1})
+
+	id := enqueuePlank(t, q, 0)
+	if len(q.Running("sawmill")) != 0 {
+		t.Fatal("job started without inputs")
+	}
+	q.Update(time.Now())
+	if n := bus.count(EventJobWaiting); n != 1 {
+		t.Errorf("expected one waiting event, got %d", n)
+	}
+
+	inv.AddStack(inventory.Stack{Item: "wood", Owner: "player1", Qty: 2})
+	q.Update(time.Now())
+	if got := q.Running("sawmill"); len(got) != 1 || got[0] != id {
+		t.Fatalf("expected %s to start once wood arrived, got %v", id, got)
+	}
+}
+
+func TestQueueStrict(t *testing.T) {
+	// Only 2 wood: the head job needs more than that via a second recipe
+	q, _, _ := newQueueFixture(t, 2, QueueConfig{Slots: 2, Strict: true})
+	q.mgr.registry.Register(&Recipe{
+		ID:       "beam",
+		Inputs:   []ItemRequirement{{Item: "wood", Quantity: 6, Consume: true}},
+		Duration: time.Minute,
+	})
+
+	if _, err := q.Enqueue("sawmill", QueueRequest{Recipe: "beam", Owner: "player1", InventoryID: "inv"}); err != nil {
+		t.Fatalf("enqueue beam: %v", err)
+	}
+	enqueuePlank(t, q, 0)
+	if n := len(q.Running("sawmill")); n != 0 {
+		t.Errorf("strict queue let a job pass the waiting head (%d running)", n)
+	}
+
+	if err := q.Configure("sawmill", QueueConfig{Slots: 2}); err != nil {
+		t.Fatalf("configure: %v", err)
+	}
+	if n := len(q.Running("sawmill")); n != 1 {
+		t.Errorf("relaxed queue should start the plank, %d running", n)
+	}
+}
+
+func TestQueueInputPolicies(t *testing.T) {
+	t.Run("reserve", func(t *testing.T) {
+		q, inv, _ := newQueueFixture(t, 5, QueueConfig{Slots: 1, Policy: ReserveOnEnqueue})
+		enqueuePlank(t, q, 0) // starts: 3 wood left
+		queued := enqueuePlank(t, q, 0)
+		if got := countWood(inv); got != 3 {
+			t.Fatalf("reservation should not consume: %d wood", got)
+		}
+		// 3 wood, 2 reserved: a third job cannot reserve
+		if _, err := q.Enqueue("sawmill", QueueRequest{Recipe: "plank", Owner: "player1", InventoryID: "inv"}); err == nil {
+			t.Fatal("expected reservation to fail")
+		}
+		if err := q.Remove("sawmill", queued); err != nil {
+			t.Fatalf("remove: %v", err)
+		}
+		enqueuePlank(t, q, 0)
+		q.Update(time.Now().Add(2 * time.Minute))
+		if got := countWood(inv); got != 1 {
+			t.Errorf("expected 1 wood after second job starts, got %d", got)
+		}
+	})
+
+	t.Run("consume", func(t *testing.T) {
+		q, inv, _ := newQueueFixture(t, 6, QueueConfig{Slots: 1, Policy: ConsumeOnEnqueue})
+		enqueuePlank(t, q, 0)
+		queued := enqueuePlank(t, q, 0)
+		if got := countWood(inv); got != 2 {
+			t.Fatalf("expected queued job to pay up front: %d wood", got)
+		}
+		if err := q.Remove("sawmill", queued); err != nil {
+			t.Fatalf("remove: %v", err)
+		}
+		if got := countWood(inv); got != 4 {
+			t.Errorf("expected refund on remove: %d wood", got)
+		}
+
+		// A prepaid job starts without paying again
+		enqueuePlank(t, q, 0)
+		q.Update(time.Now().Add(2 * time.Minute))
+		if got := countWood(inv); got != 2 {
+			t.Errorf("expected 2 wood, got %d", got)
+		}
+	})
+}
+
+func equalIDs(a, b []JobID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
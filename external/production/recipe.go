@@ -28,6 +28,44 @@ func NewRecipeRegistry() *RecipeRegistry {
 // Register adds or updates a recipe in the registry.
 // Returns an error if the recipe is invalid.
 func (r *RecipeRegistry) Register(recipe *Recipe) error {
+	if err := validateRecipe(recipe); err != nil {
+		return err
+	}
+
+	// Default probability to 1.0 if not set
+	for i, output := range recipe.Outputs {
+		if output.Probability == 0.0 {
+			recipe.Outputs[i].Probability = 1.0
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Remove old indices if updating existing recipe
+	if existing, exists := r.recipes[recipe.ID]; exists {
+		r.removeIndices(existing)
+	}
+
+	// Store recipe
+	r.recipes[recipe.ID] = recipe
+
+	// Index by category
+	if recipe.Category != "" {
+		r.byCategory[recipe.Category] = append(r.byCategory[recipe.Category], recipe.ID)
+	}
+
+	// Index by output items
+	for _, output := range recipe.Outputs {
+		r.byOutput[output.Item] = append(r.byOutput[output.Item], recipe.ID)
+	}
+
+	return nil
+}
+
+// validateRecipe reports why Register would reject a recipe, without
+// changing it.
+func validateRecipe(recipe *Recipe) error {
 	if recipe == nil {
 		return errors.New("recipe cannot be nil")
 	}
@@ -58,33 +96,23 @@ func (r *RecipeRegistry) Register(recipe *Recipe) error {
 		if output.Probability < 0.0 || output.Probability > 1.0 {
 			return fmt.Errorf("output %d: probability must be between 0.0 and 1.0", i)
 		}
-		// Default probability to 1.0 if not set
-		if output.Probability == 0.0 {
-			recipe.Outputs[i].Probability = 1.0
+		if output.Weight < 0 {
+			return fmt.Errorf("output %d: weight cannot be negative", i)
+		}
+		if output.Pity < 0 {
+			return fmt.Errorf("output %d: pity cannot be negative", i)
 		}
 	}
 
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	// Remove old indices if updating existing recipe
-	if existing, exists := r.recipes[recipe.ID]; exists {
-		r.removeIndices(existing)
-	}
-
-	// Store recipe
-	r.recipes[recipe.ID] = recipe
-
-	// Index by category
-	if recipe.Category != "" {
-		r.byCategory[recipe.Category] = append(r.byCategory[recipe.Category], recipe.ID)
-	}
-
-	// Index by output items
-	for _, output := range recipe.Outputs {
-		r.byOutput[output.Item] = append(r.byOutput[output.Item], recipe.ID)
+	// Validate upkeep
+	for i, upkeep := range recipe.Upkeep {
+		if upkeep.Resource == "" {
+			return fmt.Errorf("upkeep %d: resource ID cannot be empty", i)
+		}
+		if upkeep.Rate <= 0 {
+			return fmt.Errorf("upkeep %d: rate must be positive", i)
+		}
 	}
-
 	return nil
 }
 
@@ -0,0 +1,114 @@
+package production
+
+// RollState is the random state of a job's output rolls. It is stored on the
+// Job so a job's outcomes can be replayed from its seed: NewRollState(seed)
+// followed by one RollOutputs call per cycle reproduces every result.
+type RollState struct {
+	RNG    uint64 `json:"rng"`              // splitmix64 state
+	Misses []int  `json:"misses,omitempty"` // consecutive misses per output index (pity)
+}
+
+// NewRollState returns the initial roll state for a seed.
+func NewRollState(seed uint64) RollState {
+	return RollState{RNG: seed}
+}
+
+// next advances the generator (splitmix64).
+func (s *RollState) next() uint64 {
+	s.RNG += 0x9e3779b97f4a7c15
+	return mix64(s.RNG)
+}
+
+// float64 returns a uniform value in [0, 1).
+func (s *RollState) float64() float64 {
+	return float64(s.next()>>11) / (1 << 53)
+}
+
+// mix64 is the splitmix64 finalizer.
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// RollOutputs rolls one cycle of outputs and advances state.
+//
+// Outputs without a Group are rolled independently against Probability.
+// Outputs sharing a Group form a "one of N" roll: exactly one member is
+// produced, picked by Weight (Probability is ignored). An output with
+// Pity > 0 is guaranteed once it has missed Pity times in a row.
+func RollOutputs(outputs []ItemYield, state *RollState) []ItemYield {
+	if len(outputs) == 0 {
+		return nil
+	}
+	if len(state.Misses) != len(outputs) {
+		state.Misses = make([]int, len(outputs))
+	}
+
+	hit := make([]bool, len(outputs))
+	done := make(map[string]bool)
+	for i, output := range outputs {
+		if output.Group == "" {
+			hit[i] = state.pity(output, i) || output.Probability >= 1.0 || state.float64() < output.Probability
+			continue
+		}
+		if done[output.Group] {
+			continue
+		}
+		done[output.Group] = true
+		hit[state.pickGroup(outputs, output.Group)] = true
+	}
+
+	result := make([]ItemYield, 0, len(outputs))
+	for i, output := range outputs {
+		if hit[i] {
+			state.Misses[i] = 0
+			result = append(result, output)
+		} else {
+			state.Misses[i]++
+		}
+	}
+	return result
+}
+
+// pity reports whether output i is due under bad-luck protection.
+func (s *RollState) pity(output ItemYield, i int) bool {
+	return output.Pity > 0 && s.Misses[i] >= output.Pity
+}
+
+// pickGroup returns the index of the group member produced this cycle. The
+// first member due for pity wins; otherwise one is drawn by weight.
+func (s *RollState) pickGroup(outputs []ItemYield, group string) int {
+	total := 0.0
+	last := -1
+	for i, output := range outputs {
+		if output.Group != group {
+			continue
+		}
+		if s.pity(output, i) {
+			return i
+		}
+		total += groupWeight(output)
+		last = i
+	}
+
+	r := s.float64() * total
+	for i, output := range outputs {
+		if output.Group != group {
+			continue
+		}
+		r -= groupWeight(output)
+		if r < 0 {
+			return i
+		}
+	}
+	return last
+}
+
+// groupWeight returns an output's weight within its group (default 1).
+func groupWeight(output ItemYield) float64 {
+	if output.Weight <= 0 {
+		return 1.0
+	}
+	return output.Weight
+}
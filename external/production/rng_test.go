@@ -0,0 +1,134 @@
+package production
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gravitas-015/inventory"
+)
+
+func TestRollOutputsReproducible(t *testing.T) {
+	outputs := []ItemYield{
+		{Item: "ore", Quantity: 1, Probability: 0.5},
+		{Item: "gem", Quantity: 1, Probability: 0.1, Pity: 5},
+		{Item: "iron", Quantity: 1, Group: "metal", Weight: 3},
+		{Item: "gold", Quantity: 1, Group: "metal"},
+	}
+
+	a, b, c := NewRollState(42), NewRollState(42), NewRollState(43)
+	same := true
+	for cycle := 0; cycle < 200; cycle++ {
+		ra := RollOutputs(outputs, &a)
+		rb := RollOutputs(outputs, &b)
+		rc := RollOutputs(outputs, &c)
+		if len(ra) != len(rb) {
+			t.Fatalf("cycle %d: same seed diverged: %v vs %v", cycle, ra, rb)
+		}
+		for i := range ra {
+			if ra[i].Item != rb[i].Item {
+				t.Fatalf("cycle %d: same seed diverged: %v vs %v", cycle, ra, rb)
+			}
+		}
+		if len(ra) != len(rc) {
+			same = false
+		}
+	}
+	if same {
+		t.Error("different seeds produced the same output counts for 200 cycles")
+	}
+}
+
+func TestRollOutputsDistribution(t *testing.T) {
+	outputs := []ItemYield{
+		{Item: "ore", Quantity: 1, Probability: 0.3},
+		{Item: "iron", Quantity: 1, Group: "metal", Weight: 3},
+		{Item: "gold", Quantity: 1, Group: "metal", Weight: 1},
+	}
+	state := NewRollState(7)
+	counts := map[inventory.ItemID]int{}
+	const n = 20000
+	for i := 0; i < n; i++ {
+		got := RollOutputs(outputs, &state)
+		metals := 0
+		for _, y := range got {
+			counts[y.Item]++
+			if y.Group == "metal" {
+				metals++
+			}
+		}
+		if metals != 1 {
+			t.Fatalf("group yielded %d members", metals)
+		}
+	}
+	if f := float64(counts["ore"]) / n; math.Abs(f-0.3) > 0.02 {
+		t.Errorf("ore rate %.3f, want ~0.3", f)
+	}
+	if f := float64(counts["iron"]) / n; math.Abs(f-0.75) > 0.02 {
+		t.Errorf("iron share %.3f, want ~0.75", f)
+	}
+}
+
+func TestRollOutputsPity(t *testing.T) {
+	outputs := []ItemYield{
+		{Item: "gem", Quantity: 1, Probability: 0, Pity: 3},
+		{Item: "common", Quantity: 1, Group: "g", Weight: 1},
+		{Item: "rare", Quantity: 1, Group: "g", Weight: 1e-9, Pity: 4},
+	}
+	state := NewRollState(1)
+	for cycle := 1; cycle <= 10; cycle++ {
+		got := RollOutputs(outputs, &state)
+		has := map[inventory.ItemID]bool{}
+		for _, y := range got {
+			has[y.Item] = true
+		}
+		// 3 misses, then guaranteed: every 4th cycle
+		if want := cycle%4 == 0; has["gem"] != want {
+			t.Errorf("cycle %d: gem %v, want %v", cycle, has["gem"], want)
+		}
+		if want := cycle%5 == 0; has["rare"] != want {
+			t.Errorf("cycle %d: rare %v, want %v", cycle, has["rare"], want)
+		}
+	}
+}
+
+func TestManagerSeededRolls(t *testing.T) {
+	run := func() *Job {
+		registry := NewRecipeRegistry()
+		registry.Register(&Recipe{
+			ID:       "dig",
+			Outputs:  []ItemYield{{Item: "gem", Quantity: 1, Probability: 0.5}},
+			Duration: time.Minute,
+		})
+		invProvider := NewSimpleInventoryProvider()
+		invProvider.AddInventory(inventory.NewVolume("inv", "player1", 1000))
+		mgr := NewManager("test", registry, invProvider, NewNullEventBus(), nil)
+		mgr.SetSeed(99)
+
+		id, err := mgr.StartRepeatingProduction("dig", "player1", "inv")
+		if err != nil {
+			t.Fatalf("start: %v", err)
+		}
+		job := mgr.GetJob(id)
+		now := time.Now()
+		for i := 0; i < 20; i++ {
+			now = now.Add(2 * time.Minute)
+			mgr.Update(now)
+		}
+		return job
+	}
+
+	a, b := run(), run()
+	if a.Seed != b.Seed || a.Rolls.RNG != b.Rolls.RNG {
+		t.Fatalf("same manager seed gave different jobs: %d/%d vs %d/%d", a.Seed, a.Rolls.RNG, b.Seed, b.Rolls.RNG)
+	}
+
+	// The job's outcome replays from its seed alone
+	replay := NewRollState(a.Seed)
+	for i := 0; i < a.CyclesCompleted; i++ {
+		RollOutputs(a.EffectiveOutputs, &replay)
+	}
+	if replay.RNG != a.Rolls.RNG {
+		t.Errorf("replay from seed diverged after %d cycles", a.CyclesCompleted)
+	}
+}
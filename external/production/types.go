@@ -1,6 +1,8 @@
 package production
 
 import (
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/gravitas-015/inventory"
@@ -20,6 +22,7 @@ type Recipe struct {
 	Inputs   []ItemRequirement   `json:"inputs"`
 	Outputs  []ItemYield         `json:"outputs"`
 	Duration time.Duration       `json:"duration"`
+	Upkeep   []Upkeep            `json:"upkeep,omitempty"` // Continuous drain while a job runs
 	Metadata map[string]any      `json:"metadata,omitempty"`
 }
 
@@ -35,13 +38,16 @@ type ItemYield struct {
 	Item        inventory.ItemID `json:"item"`
 	Quantity    int              `json:"quantity"`
 	Probability float64          `json:"probability"` // 0.0-1.0, default 1.0 (always)
+	Group       string           `json:"group,omitempty"`  // Outputs sharing a group yield exactly one member per cycle
+	Weight      float64          `json:"weight,omitempty"` // Relative weight within the group, default 1.0
+	Pity        int              `json:"pity,omitempty"`   // Guaranteed after this many misses in a row (0 = off)
 }
 
 // JobState represents the current state of a production job.
 type JobState int
 
 const (
-	// JobPending is unused - jobs start immediately when created
+	// JobPending indicates the job is waiting in a producer queue (see Queues)
 	JobPending JobState = iota
 	// JobRunning indicates the job is in progress (inputs already consumed)
 	JobRunning
@@ -51,6 +57,9 @@ const (
 	JobFailed
 	// JobCancelled indicates the job was manually cancelled
 	JobCancelled
+	// JobBlocked indicates the job finished but its outputs are buffered
+	// because the target inventory could not take them; delivery is retried
+	JobBlocked
 )
 
 // String returns a human-readable representation of the job state.
@@ -66,6 +75,8 @@ func (s JobState) String() string {
 		return "Failed"
 	case JobCancelled:
 		return "Cancelled"
+	case JobBlocked:
+		return "Blocked"
 	default:
 		return "Unknown"
 	}
@@ -81,20 +92,40 @@ type Job struct {
 	Progress          float64           `json:"progress"` // 0.0-1.0
 	StartTime         time.Time         `json:"startTime"`
 	EndTime           time.Time         `json:"endTime"`
-	InputSnapshot     []ItemRequirement `json:"inputSnapshot"`     // What was consumed at job start
+	InputSnapshot     []ItemRequirement `json:"inputSnapshot"`     // What the current cycle consumed
 	Modifiers         Modifiers         `json:"modifiers"`
 	EffectiveInputs   []ItemRequirement `json:"effectiveInputs"`   // Inputs after modifiers
 	EffectiveOutputs  []ItemYield       `json:"effectiveOutputs"`  // Outputs after modifiers
 	EffectiveDuration time.Duration     `json:"effectiveDuration"` // Duration after modifiers
+	Snapshot          *Recipe           `json:"snapshot,omitempty"` // Recipe as it was when the job started
 	Repeat            bool              `json:"repeat"`            // If true, job automatically restarts on completion
 	CyclesCompleted   int               `json:"cyclesCompleted"`   // Number of cycles completed (for repeating jobs)
+	BufferedOutputs   []ItemYield       `json:"bufferedOutputs,omitempty"` // Produced items waiting for inventory space (JobBlocked)
+	Seed              uint64            `json:"seed"`                      // Seed of the output rolls; NewRollState(Seed) replays them
+	Rolls             RollState         `json:"rolls"`                     // Current roll state (advances every cycle)
+	Efficiency        float64           `json:"efficiency"`                // Share of upkeep met in the last update (1 = full speed)
 	Context           map[string]any    `json:"context,omitempty"`
 }
 
+// Clone returns a copy of the job that shares no mutable state with it, so
+// it can be read while the manager keeps changing the original. The recipe
+// snapshot is shared; it is never modified.
+func (j *Job) Clone() *Job {
+	c := *j
+	c.InputSnapshot = slices.Clone(j.InputSnapshot)
+	c.Modifiers.Tags = slices.Clone(j.Modifiers.Tags)
+	c.EffectiveInputs = slices.Clone(j.EffectiveInputs)
+	c.EffectiveOutputs = slices.Clone(j.EffectiveOutputs)
+	c.BufferedOutputs = slices.Clone(j.BufferedOutputs)
+	c.Rolls.Misses = slices.Clone(j.Rolls.Misses)
+	c.Context = maps.Clone(j.Context)
+	return &c
+}
+
 // CalculateProgress returns the current progress (0.0 to 1.0) based on time elapsed.
 func (j *Job) CalculateProgress(now time.Time) float64 {
 	if j.State != JobRunning {
-		if j.State == JobComplete {
+		if j.State == JobComplete || j.State == JobBlocked {
 			return 1.0
 		}
 		return 0.0
@@ -170,6 +201,14 @@ type ModifierSource interface {
 	GetModifiers(owner inventory.OwnerID, recipe RecipeID) Modifiers
 }
 
+// JobModifierSource is a ModifierSource that can see the job being modified,
+// e.g. to match on Job.Context attributes such as the building or terrain.
+// Manager prefers GetJobModifiers when a source implements it.
+type JobModifierSource interface {
+	ModifierSource
+	GetJobModifiers(job *Job) Modifiers
+}
+
 // InventoryProvider abstracts inventory access for the production system.
 type InventoryProvider interface {
 	// GetInventory retrieves an inventory by ID.
@@ -0,0 +1,226 @@
+package production
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/gravitas-015/inventory"
+)
+
+// ResourceID identifies a continuous upkeep resource such as power or
+// workers. Upkeep resources are not inventory items.
+type ResourceID string
+
+// Upkeep is a resource a recipe drains continuously while its job runs.
+type Upkeep struct {
+	Resource ResourceID `json:"resource"`
+	Rate     float64    `json:"rate"` // Units per second of running time
+}
+
+// UpkeepSource supplies upkeep to running jobs. Manager.Update checks what
+// each owner has available for the elapsed interval, then draws what the
+// jobs can use.
+type UpkeepSource interface {
+	// Available returns the amount of resource owner could draw now.
+	Available(owner inventory.OwnerID, resource ResourceID) float64
+
+	// Draw takes up to amount of resource from owner and returns the
+	// amount actually taken.
+	Draw(owner inventory.OwnerID, resource ResourceID, amount float64) float64
+}
+
+// ResourcePool is a thread-safe UpkeepSource holding an amount of each
+// resource per owner. It can be shared by several managers so all of an
+// owner's buildings draw from one pool.
+//
+// For stored resources (fuel) Add to the pool as they are produced. For flow
+// resources (power) Set the pool to the supply of one tick before the
+// managers update, so unused supply does not accumulate.
+type ResourcePool struct {
+	mu      sync.Mutex
+	amounts map[inventory.OwnerID]map[ResourceID]float64
+}
+
+// NewResourcePool creates an empty pool.
+func NewResourcePool() *ResourcePool {
+	return &ResourcePool{amounts: make(map[inventory.OwnerID]map[ResourceID]float64)}
+}
+
+// Set replaces the amount of a resource held for owner.
+func (p *ResourcePool) Set(owner inventory.OwnerID, resource ResourceID, amount float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.owner(owner)[resource] = max(amount, 0)
+}
+
+// Add adds to (or, if negative, removes from) the amount held for owner and
+// returns the new amount. The amount never drops below zero.
+func (p *ResourcePool) Add(owner inventory.OwnerID, resource ResourceID, amount float64) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	amounts := p.owner(owner)
+	amounts[resource] = max(amounts[resource]+amount, 0)
+	return amounts[resource]
+}
+
+// Amount returns the amount of a resource held for owner.
+func (p *ResourcePool) Amount(owner inventory.OwnerID, resource ResourceID) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.amounts[owner][resource]
+}
+
+// Available implements UpkeepSource.
+func (p *ResourcePool) Available(owner inventory.OwnerID, resource ResourceID) float64 {
+	return p.Amount(owner, resource)
+}
+
+// Draw implements UpkeepSource.
+func (p *ResourcePool) Draw(owner inventory.OwnerID, resource ResourceID, amount float64) float64 {
+	if amount <= 0 {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	amounts := p.owner(owner)
+	taken := min(amount, amounts[resource])
+	amounts[resource] -= taken
+	return taken
+}
+
+// owner returns the amounts of owner, creating them (caller must hold lock).
+func (p *ResourcePool) owner(owner inventory.OwnerID) map[ResourceID]float64 {
+	amounts, exists := p.amounts[owner]
+	if !exists {
+		amounts = make(map[ResourceID]float64)
+		p.amounts[owner] = amounts
+	}
+	return amounts
+}
+
+// SetUpkeep sets where running jobs draw their recipes' upkeep from. Without
+// a source upkeep is ignored and every job runs at full speed.
+func (m *Manager) SetUpkeep(source UpkeepSource) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.upkeep = source
+}
+
+// upkeepKey is one owner's demand for one resource.
+type upkeepKey struct {
+	owner    inventory.OwnerID
+	resource ResourceID
+}
+
+// drawUpkeep charges running jobs for the upkeep of the interval from..now
+// (caller must hold lock).
+//
+// When an owner's demand for a resource exceeds what the source has
+// available, every job sharing that resource gets the same fraction. A job
+// runs at the lowest fraction over its resources (its Efficiency) and only
+// draws each resource for the time it ran, so a job held back by power does
+// not also use up its full share of workers. The time it did not run is
+// added to its start and end, so a brownout slows it proportionally and an
+// empty pool pauses it.
+func (m *Manager) drawUpkeep(from, now time.Time) {
+	if m.upkeep == nil || !now.After(from) {
+		return
+	}
+
+	// 1. Each job's running time in the interval, and the total demand
+	spans := make(map[*Job]time.Duration)
+	demand := make(map[upkeepKey]float64)
+	for _, job := range *m.activeJobs {
+		if job.Snapshot == nil || len(job.Snapshot.Upkeep) == 0 {
+			continue
+		}
+		start := from
+		if job.StartTime.After(start) {
+			start = job.StartTime
+		}
+		end := now
+		if job.EndTime.Before(end) {
+			end = job.EndTime
+		}
+		if !end.After(start) {
+			continue
+		}
+		span := end.Sub(start)
+		spans[job] = span
+		for _, u := range job.Snapshot.Upkeep {
+			demand[upkeepKey{job.Owner, u.Resource}] += u.Rate * span.Seconds()
+		}
+	}
+	if len(spans) == 0 {
+		return
+	}
+
+	// 2. Work out the share of each demand that is available, and from it
+	// each job's efficiency
+	met := make(map[upkeepKey]float64, len(demand))
+	for key, want := range demand {
+		met[key] = min(m.upkeep.Available(key.owner, key.resource)/want, 1.0)
+	}
+	efficiencies := make(map[*Job]float64, len(spans))
+	charge := make(map[upkeepKey]float64, len(demand))
+	for job, span := range spans {
+		efficiency := 1.0
+		for _, u := range job.Snapshot.Upkeep {
+			efficiency = min(efficiency, met[upkeepKey{job.Owner, u.Resource}])
+		}
+		efficiencies[job] = efficiency
+		for _, u := range job.Snapshot.Upkeep {
+			charge[upkeepKey{job.Owner, u.Resource}] += u.Rate * span.Seconds() * efficiency
+		}
+	}
+
+	// 3. Draw only what the jobs use at that efficiency. A source shared
+	// with another manager may have less by now; jobs then slow down further
+	drawn := make(map[upkeepKey]float64, len(charge))
+	for key, amount := range charge {
+		drawn[key] = 1.0
+		if amount > 0 {
+			drawn[key] = min(m.upkeep.Draw(key.owner, key.resource, amount)/amount, 1.0)
+		}
+	}
+
+	// 4. Hold back jobs by the time they went without upkeep
+	for _, job := range *m.activeJobs {
+		span, charged := spans[job]
+		if !charged {
+			continue
+		}
+		efficiency := efficiencies[job]
+		for _, u := range job.Snapshot.Upkeep {
+			efficiency = min(efficiency, efficiencies[job]*drawn[upkeepKey{job.Owner, u.Resource}])
+		}
+
+		lost := time.Duration(float64(span) * (1 - efficiency))
+		job.StartTime = job.StartTime.Add(lost)
+		job.EndTime = job.EndTime.Add(lost)
+
+		previous := job.Efficiency
+		job.Efficiency = efficiency
+		switch {
+		case efficiency < 1 && previous >= 1:
+			m.publishUpkeep(EventJobStalled, job, now)
+		case efficiency >= 1 && previous < 1:
+			m.publishUpkeep(EventJobRecovered, job, now)
+		}
+	}
+	heap.Init(m.activeJobs)
+}
+
+// publishUpkeep emits a stall or recovery event (caller must hold lock).
+func (m *Manager) publishUpkeep(t EventType, job *Job, now time.Time) {
+	m.eventBus.Publish(Event{
+		Type:      t,
+		Job:       job,
+		Timestamp: now,
+		Data: map[string]any{
+			"efficiency": job.Efficiency,
+			"endTime":    job.EndTime,
+		},
+	})
+}
@@ -0,0 +1,140 @@
+package production
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitas-015/inventory"
+)
+
+// newUpkeepFixture returns a manager on a one-second tick clock with a
+// 10s "smelt" recipe needing upkeep, and an empty resource pool.
+func newUpkeepFixture(t *testing.T, upkeep ...Upkeep) (*Manager, *TickClock, *ResourcePool, *inventory.Inventory, *recordingBus) {
+	t.Helper()
+	mgr, inv, bus := newTestManager(newTestRegistry(t, &Recipe{
+		ID:       "smelt",
+		Outputs:  []ItemYield{{Item: "ingot", Quantity: 1, Probability: 1.0}},
+		Duration: 10 * time.Second,
+		Upkeep:   upkeep,
+	}))
+	clock := NewTickClock(time.Unix(0, 0), time.Second)
+	pool := NewResourcePool()
+	mgr.SetClock(clock)
+	mgr.SetUpkeep(pool)
+	return mgr, clock, pool, inv, bus
+}
+
+// power is the upkeep of most tests: 2 power per second.
+var power = Upkeep{Resource: "power", Rate: 2}
+
+// runTicks advances the clock one tick at a time, calling supply before each
+// manager update.
+func runTicks(mgr *Manager, clock *TickClock, n int, supply func()) {
+	for i := 0; i < n; i++ {
+		clock.Advance(1)
+		if supply != nil {
+			supply()
+		}
+		mgr.UpdateNow()
+	}
+}
+
+func TestUpkeepFullSupply(t *testing.T) {
+	mgr, clock, pool, inv, bus := newUpkeepFixture(t, power)
+	pool.Set("player1", "power", 100)
+
+	if _, err := mgr.StartProduction("smelt", "player1", "inv"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	runTicks(mgr, clock, 10, nil)
+	if got := countItem(inv, "ingot"); got != 1 {
+		t.Errorf("expected the job to finish on time, got %d ingots", got)
+	}
+	if got := pool.Amount("player1", "power"); got != 80 {
+		t.Errorf("expected 20 power drawn, %v left", got)
+	}
+	if n := bus.count(EventJobStalled); n != 0 {
+		t.Errorf("unexpected stall events: %d", n)
+	}
+}
+
+func TestUpkeepBrownout(t *testing.T) {
+	mgr, clock, pool, inv, bus := newUpkeepFixture(t, power)
+
+	id, err := mgr.StartProduction("smelt", "player1", "inv")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	// Half the power needed: half speed
+	half := func() { pool.Set("player1", "power", 1) }
+	runTicks(mgr, clock, 4, half)
+	job := mgr.GetJob(id)
+	if job.Efficiency != 0.5 {
+		t.Errorf("efficiency = %v, want 0.5", job.Efficiency)
+	}
+	if p := job.CalculateProgress(clock.Now()); p != 0.2 {
+		t.Errorf("progress = %v, want 0.2", p)
+	}
+	if n := bus.count(EventJobStalled); n != 1 {
+		t.Errorf("expected one stall event, got %d", n)
+	}
+
+	// No power: paused
+	runTicks(mgr, clock, 5, func() { pool.Set("player1", "power", 0) })
+	if p := mgr.GetJob(id).CalculateProgress(clock.Now()); p != 0.2 {
+		t.Errorf("progress moved without power: %v", p)
+	}
+
+	// Full power again: the remaining 8s run at full speed
+	full := func() { pool.Set("player1", "power", 2) }
+	runTicks(mgr, clock, 7, full)
+	if got := countItem(inv, "ingot"); got != 0 {
+		t.Fatal("job finished early")
+	}
+	if n := bus.count(EventJobRecovered); n != 1 {
+		t.Errorf("expected one recovery event, got %d", n)
+	}
+	runTicks(mgr, clock, 1, full)
+	if got := countItem(inv, "ingot"); got != 1 {
+		t.Error("job did not finish after recovering")
+	}
+}
+
+func TestUpkeepSharedFairly(t *testing.T) {
+	mgr, clock, pool, _, _ := newUpkeepFixture(t, power)
+
+	a, _ := mgr.StartProduction("smelt", "player1", "inv")
+	b, _ := mgr.StartProduction("smelt", "player1", "inv")
+
+	// 2 power per tick for two jobs needing 4: both at half speed
+	runTicks(mgr, clock, 2, func() { pool.Set("player1", "power", 2) })
+	for _, id := range []JobID{a, b} {
+		if e := mgr.GetJob(id).Efficiency; e != 0.5 {
+			t.Errorf("job %s efficiency = %v, want 0.5", id, e)
+		}
+	}
+}
+
+func TestUpkeepChargesOnlyWhatRuns(t *testing.T) {
+	mgr, clock, pool, _, _ := newUpkeepFixture(t, power, Upkeep{Resource: "workers", Rate: 4})
+	id, err := mgr.StartProduction("smelt", "player1", "inv")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	// Power is at half, workers are plentiful: the job runs half the tick
+	// and only uses half its workers
+	pool.Set("player1", "power", 1)
+	pool.Set("player1", "workers", 100)
+	runTicks(mgr, clock, 1, nil)
+	if e := mgr.GetJob(id).Efficiency; e != 0.5 {
+		t.Errorf("efficiency = %v, want 0.5", e)
+	}
+	if got := pool.Amount("player1", "power"); got != 0 {
+		t.Errorf("expected all power drawn, %v left", got)
+	}
+	if got := pool.Amount("player1", "workers"); got != 98 {
+		t.Errorf("expected 2 workers drawn, %v left", got)
+	}
+}
@@ -0,0 +1,292 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gravitas-games/mmorts/internal/network"
+)
+
+// Counter names recorded by bots
+const (
+	CounterConnected    = "connected"
+	CounterConnectError = "connect_errors"
+	CounterSent         = "sent"
+	CounterReceived     = "received"
+	CounterServerError  = "server_errors"
+	CounterDisconnected = "disconnected"
+	CounterLostPings    = "lost_pings"
+)
+
+// Bot is a headless client driving one WebSocket connection
+type Bot struct {
+	ID    int
+	url   string
+	token string
+	stats *Stats
+	rng   *rand.Rand
+
+	ws      *websocket.Conn
+	writeMu sync.Mutex
+	done    chan struct{}
+
+	// Round-trip bookkeeping (guarded by mu)
+	mu          sync.Mutex
+	playerID    string
+	joinSent    time.Time
+	pendingPing []time.Time
+	pendingChat map[string]time.Time
+	chatSeq     int
+}
+
+// New creates a bot that will connect to url using token
+func New(id int, url, token string, stats *Stats) *Bot {
+	return &Bot{
+		ID:          id,
+		url:         url,
+		token:       token,
+		stats:       stats,
+		rng:         rand.New(rand.NewSource(int64(id))),
+		done:        make(chan struct{}),
+		pendingChat: make(map[string]time.Time),
+	}
+}
+
+// Connect dials the server, authenticating with the access_token subprotocol
+func (b *Bot) Connect(ctx context.Context) error {
+	dialer := websocket.Dialer{
+		HandshakeTimeout: 10 * time.Second,
+		Subprotocols:     []string{"access_token", b.token},
+	}
+
+	ws, resp, err := dialer.DialContext(ctx, b.url, http.Header{})
+	if err != nil {
+		b.stats.Inc(CounterConnectError)
+		if resp != nil {
+			return fmt.Errorf("bot %d: dial failed with status %d: %w", b.ID, resp.StatusCode, err)
+		}
+		return fmt.Errorf("bot %d: dial failed: %w", b.ID, err)
+	}
+
+	b.ws = ws
+	b.stats.Inc(CounterConnected)
+	go b.readLoop()
+	return nil
+}
+
+// Run executes the setup steps once, then the steps (repeatedly if the
+// script loops) until they finish, the context ends or the connection drops
+func (b *Bot) Run(ctx context.Context, script *Script) error {
+	for _, step := range script.Setup {
+		if err := b.runStep(ctx, step); err != nil {
+			return err
+		}
+	}
+	for {
+		for _, step := range script.Steps {
+			if err := b.runStep(ctx, step); err != nil {
+				return err
+			}
+		}
+		if !script.Loop {
+			return nil
+		}
+	}
+}
+
+// runStep performs one step, honouring its repeat count and interval
+func (b *Bot) runStep(ctx context.Context, step Step) error {
+	repeat := step.Repeat
+	if repeat == 0 {
+		repeat = 1
+	}
+
+	for i := 0; i < repeat; i++ {
+		if i > 0 {
+			delay := step.Interval
+			if step.Jitter > 0 {
+				delay += time.Duration(b.rng.Int63n(int64(step.Jitter)))
+			}
+			if err := b.sleep(ctx, delay); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		switch step.Action {
+		case ActionJoin:
+			err = b.Join()
+		case ActionLeave:
+			err = b.send(network.MsgTypeLeave, struct{}{})
+		case ActionChat:
+			err = b.Chat(step.Message)
+		case ActionPing:
+			err = b.Ping()
+		case ActionWait:
+			err = b.sleep(ctx, step.Duration)
+		case ActionSend:
+			err = b.send(step.Type, step.Payload)
+		default:
+			err = fmt.Errorf("unknown action %q", step.Action)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sleep waits for d unless the context ends or the connection drops first
+func (b *Bot) sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.done:
+		return fmt.Errorf("bot %d: connection closed", b.ID)
+	}
+}
+
+// Join sends a join request and starts timing the welcome round trip
+func (b *Bot) Join() error {
+	b.mu.Lock()
+	b.joinSent = time.Now()
+	b.mu.Unlock()
+	return b.send(network.MsgTypeJoin, network.JoinPayload{})
+}
+
+// Ping sends an application-level ping. Pongs are matched in order because
+// the server answers each connection's messages sequentially.
+func (b *Bot) Ping() error {
+	b.mu.Lock()
+	b.pendingPing = append(b.pendingPing, time.Now())
+	b.mu.Unlock()
+	return b.send(network.MsgTypePing, struct{}{})
+}
+
+// Chat sends a chat message tagged with a per-bot sequence number so the
+// echoed broadcast can be matched to it
+func (b *Bot) Chat(message string) error {
+	b.mu.Lock()
+	b.chatSeq++
+	tagged := fmt.Sprintf("%s [bot %d #%d]", message, b.ID, b.chatSeq)
+	b.pendingChat[tagged] = time.Now()
+	b.mu.Unlock()
+	return b.send(network.MsgTypeChat, network.ChatPayload{Message: tagged})
+}
+
+// send encodes and writes a client message
+func (b *Bot) send(msgType string, payload interface{}) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %w", msgType, err)
+	}
+
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	if err := b.ws.WriteJSON(network.ClientMessage{Type: msgType, Payload: raw}); err != nil {
+		return fmt.Errorf("bot %d: write failed: %w", b.ID, err)
+	}
+	b.stats.Inc(CounterSent)
+	return nil
+}
+
+// incomingMessage is a server message with its payload left undecoded
+type incomingMessage struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// readLoop reads server messages and completes pending round trips
+func (b *Bot) readLoop() {
+	defer close(b.done)
+
+	for {
+		var msg incomingMessage
+		if err := b.ws.ReadJSON(&msg); err != nil {
+			b.stats.Inc(CounterDisconnected)
+			return
+		}
+		b.stats.Inc(CounterReceived)
+		b.handleMessage(&msg, time.Now())
+	}
+}
+
+// handleMessage records latency for replies the bot is waiting on
+func (b *Bot) handleMessage(msg *incomingMessage, received time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch msg.Type {
+	case network.MsgTypeWelcome:
+		var welcome network.WelcomePayload
+		if err := json.Unmarshal(msg.Payload, &welcome); err == nil {
+			b.playerID = welcome.PlayerID
+		}
+		if !b.joinSent.IsZero() {
+			b.stats.Record(MetricJoin, received.Sub(b.joinSent))
+			b.joinSent = time.Time{}
+		}
+
+	case network.MsgTypePong:
+		if len(b.pendingPing) > 0 {
+			b.stats.Record(MetricPing, received.Sub(b.pendingPing[0]))
+			b.pendingPing = b.pendingPing[1:]
+		}
+
+	case network.MsgTypeChatBroadcast:
+		var chat network.ChatBroadcastPayload
+		if err := json.Unmarshal(msg.Payload, &chat); err != nil || chat.PlayerID != b.playerID {
+			return
+		}
+		if sent, ok := b.pendingChat[chat.Message]; ok {
+			b.stats.Record(MetricChat, received.Sub(sent))
+			delete(b.pendingChat, chat.Message)
+		}
+
+	case network.MsgTypeError:
+		var errPayload network.ErrorPayload
+		json.Unmarshal(msg.Payload, &errPayload)
+		log.Printf("bot %d: server error %s: %s", b.ID, errPayload.Code, errPayload.Message)
+		b.stats.Inc(CounterServerError)
+	}
+}
+
+// Close sends a close frame, waits briefly for the server to hang up and
+// counts pings that never got an answer
+func (b *Bot) Close() {
+	if b.ws == nil {
+		return
+	}
+
+	b.writeMu.Lock()
+	b.ws.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second))
+	b.writeMu.Unlock()
+
+	select {
+	case <-b.done:
+	case <-time.After(2 * time.Second):
+	}
+	b.ws.Close()
+
+	b.mu.Lock()
+	for range b.pendingPing {
+		b.stats.Inc(CounterLostPings)
+	}
+	b.pendingPing = nil
+	b.mu.Unlock()
+}
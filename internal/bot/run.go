@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// RunConfig describes a load test run
+type RunConfig struct {
+	URL        string        // WebSocket endpoint, e.g. ws://localhost:8080/ws
+	Bots       int           // Number of concurrent connections
+	RampUp     time.Duration // Time over which connections are opened
+	Duration   time.Duration // Total run time once the first bot starts
+	UserIDBase int64         // First user ID handed to generated tokens
+	Script     *Script
+	Issuer     *TokenIssuer
+}
+
+// Run starts cfg.Bots bots, runs the script on each until cfg.Duration has
+// elapsed and returns the collected stats
+func Run(ctx context.Context, cfg RunConfig) (*Stats, error) {
+	if cfg.Bots <= 0 {
+		return nil, fmt.Errorf("bot count must be positive")
+	}
+	if cfg.Script == nil {
+		cfg.Script = DefaultScript()
+	}
+	if err := cfg.Script.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	stats := NewStats()
+	var spacing time.Duration
+	if cfg.Bots > 1 {
+		spacing = cfg.RampUp / time.Duration(cfg.Bots-1)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Bots; i++ {
+		if i > 0 && spacing > 0 {
+			select {
+			case <-time.After(spacing):
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		userID := cfg.UserIDBase + int64(i)
+		token, err := cfg.Issuer.Issue(userID, fmt.Sprintf("loadbot%d", userID))
+		if err != nil {
+			return nil, err
+		}
+
+		wg.Add(1)
+		go func(id int, token string) {
+			defer wg.Done()
+			runBot(ctx, id, cfg, token, stats)
+		}(i, token)
+	}
+
+	wg.Wait()
+	return stats, nil
+}
+
+// runBot drives a single bot for the lifetime of the run
+func runBot(ctx context.Context, id int, cfg RunConfig, token string, stats *Stats) {
+	b := New(id, cfg.URL, token, stats)
+	if err := b.Connect(ctx); err != nil {
+		log.Printf("%v", err)
+		return
+	}
+	defer b.Close()
+
+	if err := b.Run(ctx, cfg.Script); err != nil && ctx.Err() == nil {
+		log.Printf("bot %d stopped: %v", id, err)
+	}
+}
@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Step actions understood by a Bot
+const (
+	ActionJoin  = "join"
+	ActionLeave = "leave"
+	ActionChat  = "chat"
+	ActionPing  = "ping"
+	ActionWait  = "wait"
+	ActionSend  = "send" // Raw message, for game commands the bot does not know about
+)
+
+// Script is the sequence of steps each bot performs
+type Script struct {
+	Name  string `yaml:"name"`
+	Setup []Step `yaml:"setup"` // Run once before the steps, e.g. join
+	Steps []Step `yaml:"steps"`
+	Loop  bool   `yaml:"loop"` // Repeat the steps (not the setup) until the run ends
+}
+
+// Step is a single scripted action
+type Step struct {
+	Action   string        `yaml:"action"`
+	Repeat   int           `yaml:"repeat"`   // Number of times to perform the action (default 1)
+	Interval time.Duration `yaml:"interval"` // Delay between repeats
+	Jitter   time.Duration `yaml:"jitter"`   // Random extra delay added to each interval
+
+	// Chat
+	Message string `yaml:"message"`
+
+	// Wait
+	Duration time.Duration `yaml:"duration"`
+
+	// Send
+	Type    string                 `yaml:"type"`
+	Payload map[string]interface{} `yaml:"payload"`
+}
+
+// DefaultScript joins, then pings and chats for as long as the run lasts
+func DefaultScript() *Script {
+	return &Script{
+		Name: "default",
+		Loop: true,
+		Setup: []Step{
+			{Action: ActionJoin},
+		},
+		Steps: []Step{
+			{Action: ActionPing, Repeat: 5, Interval: time.Second},
+			{Action: ActionChat, Message: "hello from loadbot", Repeat: 1},
+			{Action: ActionWait, Duration: 2 * time.Second},
+		},
+	}
+}
+
+// LoadScript reads a YAML script file
+func LoadScript(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+
+	var script Script
+	if err := yaml.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("failed to parse script: %w", err)
+	}
+
+	if err := script.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid script %s: %w", path, err)
+	}
+	return &script, nil
+}
+
+// Validate checks every step for a known action and its required fields,
+// and that a looping script waits between iterations
+func (s *Script) Validate() error {
+	if len(s.Setup) == 0 && len(s.Steps) == 0 {
+		return fmt.Errorf("script has no steps")
+	}
+	if err := validateSteps("setup step", s.Setup); err != nil {
+		return err
+	}
+	if err := validateSteps("step", s.Steps); err != nil {
+		return err
+	}
+
+	if s.Loop && loopDelay(s.Steps) <= 0 {
+		return fmt.Errorf("looping steps need a wait or a repeat interval, or the bot busy-spins")
+	}
+	return nil
+}
+
+// validateSteps checks each step of a list; kind names the list in errors
+func validateSteps(kind string, steps []Step) error {
+	for i, step := range steps {
+		switch step.Action {
+		case ActionJoin, ActionLeave, ActionPing:
+		case ActionChat:
+			if step.Message == "" {
+				return fmt.Errorf("%s %d: chat requires a message", kind, i)
+			}
+		case ActionWait:
+			if step.Duration <= 0 {
+				return fmt.Errorf("%s %d: wait requires a positive duration", kind, i)
+			}
+		case ActionSend:
+			if step.Type == "" {
+				return fmt.Errorf("%s %d: send requires a message type", kind, i)
+			}
+			if _, err := json.Marshal(step.Payload); err != nil {
+				return fmt.Errorf("%s %d: payload is not JSON-encodable: %w", kind, i, err)
+			}
+		default:
+			return fmt.Errorf("%s %d: unknown action %q", kind, i, step.Action)
+		}
+
+		if step.Repeat < 0 {
+			return fmt.Errorf("%s %d: repeat must not be negative", kind, i)
+		}
+	}
+	return nil
+}
+
+// loopDelay is the least time one pass over steps takes, ignoring jitter
+func loopDelay(steps []Step) time.Duration {
+	var total time.Duration
+	for _, step := range steps {
+		repeat := max(step.Repeat, 1)
+		total += time.Duration(repeat-1) * step.Interval
+		if step.Action == ActionWait {
+			total += time.Duration(repeat) * step.Duration
+		}
+	}
+	return total
+}
@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metric names recorded by bots
+const (
+	MetricJoin = "join" // join → welcome
+	MetricPing = "ping" // ping → pong
+	MetricChat = "chat" // chat → own chat broadcast
+)
+
+// Stats collects latency samples and counters shared by all bots of a run
+type Stats struct {
+	mu       sync.Mutex
+	samples  map[string][]time.Duration
+	counters map[string]int64
+}
+
+// NewStats creates an empty stats collector
+func NewStats() *Stats {
+	return &Stats{
+		samples:  make(map[string][]time.Duration),
+		counters: make(map[string]int64),
+	}
+}
+
+// Record adds a latency sample for a metric
+func (s *Stats) Record(metric string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples[metric] = append(s.samples[metric], d)
+}
+
+// Inc increments a named counter (e.g. "sent", "errors")
+func (s *Stats) Inc(counter string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[counter]++
+}
+
+// Counter returns the current value of a named counter
+func (s *Stats) Counter(counter string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counters[counter]
+}
+
+// LatencySummary summarizes the samples of one metric
+type LatencySummary struct {
+	Metric string
+	Count  int
+	Min    time.Duration
+	P50    time.Duration
+	P90    time.Duration
+	P99    time.Duration
+	Max    time.Duration
+}
+
+// Summary computes percentiles for a metric
+func (s *Stats) Summary(metric string) LatencySummary {
+	s.mu.Lock()
+	sorted := append([]time.Duration(nil), s.samples[metric]...)
+	s.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	summary := LatencySummary{Metric: metric, Count: len(sorted)}
+	if len(sorted) == 0 {
+		return summary
+	}
+
+	summary.Min = sorted[0]
+	summary.P50 = percentile(sorted, 50)
+	summary.P90 = percentile(sorted, 90)
+	summary.P99 = percentile(sorted, 99)
+	summary.Max = sorted[len(sorted)-1]
+	return summary
+}
+
+// percentile returns the nearest-rank percentile of sorted samples
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// WriteReport prints latency percentiles and counters
+func (s *Stats) WriteReport(w io.Writer) {
+	s.mu.Lock()
+	metrics := make([]string, 0, len(s.samples))
+	for metric := range s.samples {
+		metrics = append(metrics, metric)
+	}
+	counters := make([]string, 0, len(s.counters))
+	for counter := range s.counters {
+		counters = append(counters, counter)
+	}
+	s.mu.Unlock()
+
+	sort.Strings(metrics)
+	sort.Strings(counters)
+
+	fmt.Fprintf(w, "%-8s %8s %10s %10s %10s %10s %10s\n", "metric", "count", "min", "p50", "p90", "p99", "max")
+	for _, metric := range metrics {
+		sum := s.Summary(metric)
+		fmt.Fprintf(w, "%-8s %8d %10s %10s %10s %10s %10s\n",
+			sum.Metric, sum.Count, round(sum.Min), round(sum.P50), round(sum.P90), round(sum.P99), round(sum.Max))
+	}
+
+	for _, counter := range counters {
+		fmt.Fprintf(w, "%s: %d\n", counter, s.Counter(counter))
+	}
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatsSummary(t *testing.T) {
+	stats := NewStats()
+	for i := 1; i <= 100; i++ {
+		stats.Record(MetricPing, time.Duration(i)*time.Millisecond)
+	}
+
+	sum := stats.Summary(MetricPing)
+	if sum.Count != 100 {
+		t.Fatalf("expected 100 samples, got %d", sum.Count)
+	}
+
+	tests := []struct {
+		name string
+		got  time.Duration
+		want time.Duration
+	}{
+		{"min", sum.Min, 1 * time.Millisecond},
+		{"p50", sum.P50, 50 * time.Millisecond},
+		{"p90", sum.P90, 90 * time.Millisecond},
+		{"p99", sum.P99, 99 * time.Millisecond},
+		{"max", sum.Max, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, tt.got)
+		}
+	}
+
+	if empty := stats.Summary(MetricChat); empty.Count != 0 || empty.Max != 0 {
+		t.Errorf("expected empty summary for unrecorded metric, got %+v", empty)
+	}
+}
+
+func TestScriptValidate(t *testing.T) {
+	if err := DefaultScript().Validate(); err != nil {
+		t.Fatalf("default script should be valid: %v", err)
+	}
+
+	bad := []*Script{
+		{},
+		{Steps: []Step{{Action: "dance"}}},
+		{Steps: []Step{{Action: ActionChat}}},
+		{Steps: []Step{{Action: ActionWait}}},
+		{Steps: []Step{{Action: ActionSend}}},
+		{Steps: []Step{{Action: ActionPing, Repeat: -1}}},
+		{Setup: []Step{{Action: ActionChat}}, Steps: []Step{{Action: ActionPing}}},
+		{Loop: true, Setup: []Step{{Action: ActionJoin}}, Steps: []Step{{Action: ActionPing, Repeat: 3}}}, // Busy loop
+	}
+	for i, script := range bad {
+		if err := script.Validate(); err == nil {
+			t.Errorf("script %d: expected validation error", i)
+		}
+	}
+
+	good := []*Script{
+		{Setup: []Step{{Action: ActionJoin}}},
+		{Loop: true, Steps: []Step{{Action: ActionPing, Repeat: 2, Interval: time.Second}}},
+		{Loop: true, Steps: []Step{{Action: ActionPing}, {Action: ActionWait, Duration: time.Second}}},
+	}
+	for i, script := range good {
+		if err := script.Validate(); err != nil {
+			t.Errorf("good script %d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenIssuer signs development JWTs that the server's JWTValidator accepts.
+// The server must be configured to fetch its public key from an issuer it
+// trusts, so the issuer can serve its own public key over HTTP.
+type TokenIssuer struct {
+	key    *ecdsa.PrivateKey
+	issuer string
+	ttl    time.Duration
+}
+
+// NewTokenIssuer creates an issuer with a freshly generated P-256 key
+func NewTokenIssuer(issuer string, ttl time.Duration) (*TokenIssuer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return &TokenIssuer{key: key, issuer: issuer, ttl: ttl}, nil
+}
+
+// LoadTokenIssuer creates an issuer from a PEM-encoded EC private key file
+func LoadTokenIssuer(path, issuer string, ttl time.Duration) (*TokenIssuer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
+
+	// Accept both SEC 1 ("EC PRIVATE KEY") and PKCS #8 encodings
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return &TokenIssuer{key: key, issuer: issuer, ttl: ttl}, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not ECDSA")
+	}
+	return &TokenIssuer{key: key, issuer: issuer, ttl: ttl}, nil
+}
+
+// Issue signs a token for the given user with the claims GoLoginServer uses
+func (t *TokenIssuer) Issue(userID int64, username string) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"user_id":     userID,
+		"email":       fmt.Sprintf("%s@loadbot.local", username),
+		"username":    username,
+		"user_type":   "user",
+		"auth_method": "password",
+		"permissions": 0,
+		"activated":   now.UnixNano(),
+		"iss":         t.issuer,
+		"iat":         now.Unix(),
+		"exp":         now.Add(t.ttl).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	signed, err := token.SignedString(t.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signed, nil
+}
+
+// PublicKeyPEM returns the PEM-encoded public key in the format the
+// server's JWTValidator expects
+func (t *TokenIssuer) PublicKeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(&t.key.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// ServeHTTP serves the public key so a development server can point
+// jwt.public_key_url at the load bot
+func (t *TokenIssuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := t.PublicKeyPEM()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	w.Write(data)
+}
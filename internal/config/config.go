@@ -15,6 +15,8 @@ type Config struct {
 	Session  SessionConfig  `yaml:"session"`
 	Chat     ChatConfig     `yaml:"chat"`
 	Database DatabaseConfig `yaml:"database"`
+
+	Production ProductionConfig `yaml:"production"`
 }
 
 // ServerConfig holds server-specific settings
@@ -43,6 +45,18 @@ type RedisConfig struct {
 type SessionConfig struct {
 	MaxPlayers       int `yaml:"max_players"`
 	InitialMapRadius int `yaml:"initial_map_radius"` // Number of hex chunks from origin
+
+	// Lifecycle rules
+	AutoStartPlayers int   `yaml:"auto_start_players"`   // Start once this many players joined (0 = admin start only)
+	PauseWhenEmpty   bool  `yaml:"pause_when_empty"`     // Pause while no players are connected
+	EndingGraceSecs  int   `yaml:"ending_grace_seconds"` // Time spent in "ending" before the session closes (0 = close at once)
+	AdminPermission  int64 `yaml:"admin_permission"`     // Permission bit(s) required for session_control
+
+	// Presence
+	IdleAfterSecs      int `yaml:"idle_after_seconds"`      // Inactivity before a player shows as idle (0 = never)
+	AwayAfterSecs      int `yaml:"away_after_seconds"`      // Inactivity before a player shows as away (0 = never)
+	ReconnectGraceSecs int `yaml:"reconnect_grace_seconds"` // Seat held after an unclean disconnect (0 = leave immediately)
+	PresenceSweepSecs  int `yaml:"presence_sweep_seconds"`  // How often presence is recomputed and held seats expire (0 = every second)
 }
 
 // ChatConfig holds chat system settings
@@ -51,6 +65,12 @@ type ChatConfig struct {
 	RateLimit        int `yaml:"rate_limit"` // messages per minute
 }
 
+// ProductionConfig holds crafting settings
+type ProductionConfig struct {
+	RecipesDir        string `yaml:"recipes_dir"`        // YAML/JSON recipe files (empty = no recipes)
+	InventoryCapacity int    `yaml:"inventory_capacity"` // Volume of each player's inventory
+}
+
 // DatabaseConfig holds database connection settings
 type DatabaseConfig struct {
 	Host     string `yaml:"host"`
@@ -67,7 +87,16 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var cfg Config
+	// Defaults for settings where 0 is meaningful are set before parsing,
+	// so only a missing key gets the default and an explicit 0 is kept
+	cfg := Config{
+		Session: SessionConfig{
+			EndingGraceSecs:    30,
+			IdleAfterSecs:      60,
+			AwayAfterSecs:      300,
+			ReconnectGraceSecs: 30,
+		},
+	}
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
@@ -91,6 +120,9 @@ func Load(path string) (*Config, error) {
 	if cfg.Session.InitialMapRadius == 0 {
 		cfg.Session.InitialMapRadius = 5
 	}
+	if cfg.Production.InventoryCapacity == 0 {
+		cfg.Production.InventoryCapacity = 1000
+	}
 
 	return &cfg, nil
 }
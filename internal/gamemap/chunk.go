@@ -1,33 +1,36 @@
 package gamemap
 
 import (
+	"github.com/gravitas-015/hexcore/chunk/dense"
 	"github.com/gravitas-015/hexcore/hex"
+	"github.com/gravitas-015/hexcore/terrain"
 )
 
 // HexChunk represents a chunk of hexes in the game world
 // Each chunk is a hex-shaped region with a configurable radius
 type HexChunk struct {
-	ChunkPos  hex.Axial          // Position in chunk grid
-	Hexes     map[hex.Axial]*Hex // Local hex positions within chunk
+	ChunkPos  hex.Axial        // Position in chunk grid
+	Hexes     *dense.Grid[Hex] // Hexes by local position, stored densely
 	Generated bool
 	Radius    int // Hex radius of this chunk (default 9)
 }
 
 // Hex represents a single hex cell in the world
 type Hex struct {
-	WorldPos hex.Axial // World position
-	Terrain  string    // Terrain type: "plains", "forest", etc.
+	WorldPos     hex.Axial // World position
+	terrain.Cell           // Terrain, elevation, deposit and ownership layers
 }
 
+// defaultChunkRadius is the hex radius of every chunk
+const defaultChunkRadius = 9 // From architecture: radius 9 = 169 hexes
+
 // NewHexChunk creates a new hex chunk at the specified position
 func NewHexChunk(chunkPos hex.Axial) *HexChunk {
-	const defaultRadius = 9 // From architecture: radius 9 = 169 hexes
-
 	chunk := &HexChunk{
 		ChunkPos:  chunkPos,
-		Hexes:     make(map[hex.Axial]*Hex),
+		Hexes:     dense.NewGrid[Hex](hex.Axial{}, defaultChunkRadius),
 		Generated: false,
-		Radius:    defaultRadius,
+		Radius:    defaultChunkRadius,
 	}
 
 	// Generate blank hexes for this chunk
@@ -39,43 +42,67 @@ func NewHexChunk(chunkPos hex.Axial) *HexChunk {
 // generateHexes creates all hexes within this chunk
 // For Phase 1, all hexes are blank "plains"
 func (c *HexChunk) generateHexes() {
-	// Generate hexes in a hex radius pattern
-	for q := -c.Radius; q <= c.Radius; q++ {
-		r1 := maxInt(-c.Radius, -q-c.Radius)
-		r2 := minInt(c.Radius, -q+c.Radius)
-
-		for r := r1; r <= r2; r++ {
-			localPos := hex.Axial{Q: q, R: r}
-
-			// Calculate world position
-			// TODO: Proper world coordinate calculation based on chunk position
-			worldPos := hex.Axial{
-				Q: c.ChunkPos.Q*c.Radius + localPos.Q,
-				R: c.ChunkPos.R*c.Radius + localPos.R,
-			}
+	// Every cell of the dense grid is one hex of the chunk disk
+	c.Hexes.Each(func(localPos hex.Axial, h *Hex) {
+		// Calculate world position
+		// TODO: Proper world coordinate calculation based on chunk position
+		h.WorldPos = ChunkOrigin(c.ChunkPos, c.Radius).Add(localPos)
+		h.Cell = terrain.Cell{Terrain: terrain.Plains} // All blank for Phase 1
+	})
+
+	c.Generated = true
+}
+
+// ChunkOrigin returns the world position of local (0, 0) in the chunk at
+// chunkPos
+func ChunkOrigin(chunkPos hex.Axial, radius int) hex.Axial {
+	return chunkPos.Mul(radius)
+}
 
-			c.Hexes[localPos] = &Hex{
-				WorldPos: worldPos,
-				Terrain:  "plains", // All blank for Phase 1
+// ChunkPosOf returns the position of the chunk a world hex belongs to: the
+// chunk whose origin is nearest, ties to the smallest (q, r). Chunk disks
+// overlap, so a hex can lie in several chunks; this picks exactly one, and
+// the hex is always inside its disk.
+func ChunkPosOf(world hex.Axial, radius int) hex.Axial {
+	if radius <= 0 {
+		return world
+	}
+	// The nearest origin is a corner of the lattice cell containing world
+	q0, r0 := floorDiv(world.Q, radius), floorDiv(world.R, radius)
+	var best hex.Axial
+	bestDist := -1
+	for dq := 0; dq <= 1; dq++ {
+		for dr := 0; dr <= 1; dr++ {
+			pos := hex.Axial{Q: q0 + dq, R: r0 + dr}
+			d := hex.DistanceAxial(world, ChunkOrigin(pos, radius))
+			if bestDist < 0 || d < bestDist || (d == bestDist && axialLess(pos, best)) {
+				best, bestDist = pos, d
 			}
 		}
 	}
-
-	c.Generated = true
+	return best
 }
 
 // GetHex retrieves a hex at the local position within this chunk
 func (c *HexChunk) GetHex(localPos hex.Axial) (*Hex, bool) {
-	hex, exists := c.Hexes[localPos]
-	return hex, exists
+	h := c.Hexes.Ptr(localPos)
+	return h, h != nil
 }
 
 // HexCount returns the number of hexes in this chunk
 func (c *HexChunk) HexCount() int {
-	return len(c.Hexes)
+	return c.Hexes.Len()
 }
 
 // Utility functions
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
 func minInt(a, b int) int {
 	if a < b {
 		return a
@@ -5,12 +5,15 @@ import (
 	"log"
 
 	"github.com/gravitas-015/hexcore/hex"
+	"github.com/gravitas-015/hexcore/terrain"
 )
 
 // GameMap represents the game world map
 type GameMap struct {
 	Chunks      map[hex.Axial]*HexChunk
-	ChunkRadius int // Number of chunks from origin
+	ChunkRadius int               // Number of chunks from origin
+	Terrain     *terrain.Registry // Terrain types referenced by hexes
+	Spatial     *SpatialIndex     // Entity positions, separate from tiles
 }
 
 // New creates a new game map with the specified chunk radius
@@ -20,6 +23,8 @@ func New(chunkRadius int) (*GameMap, error) {
 	gm := &GameMap{
 		Chunks:      make(map[hex.Axial]*HexChunk),
 		ChunkRadius: chunkRadius,
+		Terrain:     terrain.DefaultRegistry(),
+		Spatial:     NewSpatialIndex(defaultChunkRadius),
 	}
 
 	// Generate initial chunks in a hex pattern around origin
@@ -55,6 +60,11 @@ func (gm *GameMap) GetChunk(pos hex.Axial) (*HexChunk, bool) {
 	return chunk, exists
 }
 
+// TerrainType returns the terrain type of a hex
+func (gm *GameMap) TerrainType(h *Hex) (*terrain.Type, bool) {
+	return gm.Terrain.Get(h.Terrain)
+}
+
 // GetHex retrieves a hex at the specified world position
 // This converts world coordinates to chunk + local coordinates
 func (gm *GameMap) GetHex(worldPos hex.Axial) (*Hex, error) {
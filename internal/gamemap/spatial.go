@@ -0,0 +1,270 @@
+package gamemap
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/gravitas-015/hexcore/hex"
+)
+
+// EntityID identifies an entity (unit, building, resource) on the map
+type EntityID uint64
+
+// SpatialIndex tracks which entities stand on which hex and which chunk.
+// Entities are stored separately from map tiles so that range queries for
+// vision, sync and combat do not have to scan every entity.
+//
+// All query results are deterministic: hex queries list entities nearest
+// first, then by (q, r), then by ID; chunk queries list entities by ID.
+type SpatialIndex struct {
+	mu          sync.RWMutex
+	chunkRadius int
+	positions   map[EntityID]hex.Axial
+	cells       map[hex.Axial][]EntityID // sorted by ID
+	chunks      map[hex.Axial][]EntityID // by chunk position, sorted by ID
+}
+
+// NewSpatialIndex creates an empty index for chunks of the given radius
+func NewSpatialIndex(chunkRadius int) *SpatialIndex {
+	return &SpatialIndex{
+		chunkRadius: chunkRadius,
+		positions:   make(map[EntityID]hex.Axial),
+		cells:       make(map[hex.Axial][]EntityID),
+		chunks:      make(map[hex.Axial][]EntityID),
+	}
+}
+
+// ChunkOf returns the position of the chunk containing a, as used for
+// HexChunk.ChunkPos and the keys of GameMap.Chunks
+func (s *SpatialIndex) ChunkOf(a hex.Axial) hex.Axial {
+	return ChunkPosOf(a, s.chunkRadius)
+}
+
+// Insert places an entity at a, moving it if it is already indexed
+func (s *SpatialIndex) Insert(id EntityID, a hex.Axial) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.positions[id]; ok {
+		s.unlink(id, old)
+	}
+	s.link(id, a)
+}
+
+// Move relocates an indexed entity. Returns false if id is unknown.
+func (s *SpatialIndex) Move(id EntityID, to hex.Axial) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	from, ok := s.positions[id]
+	if !ok {
+		return false
+	}
+	if from != to {
+		s.unlink(id, from)
+		s.link(id, to)
+	}
+	return true
+}
+
+// Remove drops an entity from the index. Returns false if id is unknown.
+func (s *SpatialIndex) Remove(id EntityID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.positions[id]
+	if ok {
+		s.unlink(id, a)
+	}
+	return ok
+}
+
+// Position returns where an entity is
+func (s *SpatialIndex) Position(id EntityID) (hex.Axial, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.positions[id]
+	return a, ok
+}
+
+// Len returns the number of indexed entities
+func (s *SpatialIndex) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.positions)
+}
+
+// At returns the entities on a single hex
+func (s *SpatialIndex) At(a hex.Axial) []EntityID {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]EntityID(nil), s.cells[a]...)
+}
+
+// InDisk returns the entities within distance r of center
+func (s *SpatialIndex) InDisk(center hex.Axial, r int) []EntityID {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.collect(center, r, func(d int) bool { return d <= r }, func() []hex.Axial { return hex.Disk(center, r) })
+}
+
+// InRing returns the entities at exactly distance r from center
+func (s *SpatialIndex) InRing(center hex.Axial, r int) []EntityID {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.collect(center, r, func(d int) bool { return d == r }, func() []hex.Axial { return hex.Ring(center, r) })
+}
+
+// InChunk returns the entities in the chunk at chunkPos
+func (s *SpatialIndex) InChunk(chunkPos hex.Axial) []EntityID {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]EntityID(nil), s.chunks[chunkPos]...)
+}
+
+// OccupiedChunks returns the positions of chunks containing at least one
+// entity, sorted by (q, r), so systems can skip empty chunks
+func (s *SpatialIndex) OccupiedChunks() []hex.Axial {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]hex.Axial, 0, len(s.chunks))
+	for c := range s.chunks {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return axialLess(out[i], out[j]) })
+	return out
+}
+
+// Nearest returns the closest entity within maxRange of center for which
+// match returns true (nil matches anything). Ties are broken by (q, r),
+// then by ID.
+func (s *SpatialIndex) Nearest(center hex.Axial, maxRange int, match func(id EntityID, at hex.Axial) bool) (EntityID, hex.Axial, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if match == nil {
+		match = func(EntityID, hex.Axial) bool { return true }
+	}
+
+	if s.scanAll(maxRange) {
+		var best spatialHit
+		found := false
+		for id, a := range s.positions {
+			d := hex.DistanceAxial(center, a)
+			if d > maxRange || !match(id, a) {
+				continue
+			}
+			hit := spatialHit{id: id, at: a, dist: d}
+			if !found || hit.less(best) {
+				best, found = hit, true
+			}
+		}
+		return best.id, best.at, found
+	}
+
+	for r := 0; r <= maxRange; r++ {
+		ring := hex.Ring(center, r)
+		sort.Slice(ring, func(i, j int) bool { return axialLess(ring[i], ring[j]) })
+		for _, a := range ring {
+			for _, id := range s.cells[a] {
+				if match(id, a) {
+					return id, a, true
+				}
+			}
+		}
+	}
+	return 0, hex.Axial{}, false
+}
+
+// scanAll reports whether a query over a disk of radius r is cheaper as a
+// scan over all entities than as a walk over the disk's hexes
+func (s *SpatialIndex) scanAll(r int) bool {
+	return 1+3*r*(r+1) > 4*len(s.positions)
+}
+
+type spatialHit struct {
+	id   EntityID
+	at   hex.Axial
+	dist int
+}
+
+func (h spatialHit) less(o spatialHit) bool {
+	if h.dist != o.dist {
+		return h.dist < o.dist
+	}
+	if h.at != o.at {
+		return axialLess(h.at, o.at)
+	}
+	return h.id < o.id
+}
+
+// collect gathers entities on hexes (or, when cheaper, scans every
+// entity with the keep predicate on distance) in canonical order
+func (s *SpatialIndex) collect(center hex.Axial, r int, keep func(d int) bool, hexes func() []hex.Axial) []EntityID {
+	var hits []spatialHit
+	if s.scanAll(r) {
+		for id, a := range s.positions {
+			if d := hex.DistanceAxial(center, a); keep(d) {
+				hits = append(hits, spatialHit{id: id, at: a, dist: d})
+			}
+		}
+	} else {
+		for _, a := range hexes() {
+			for _, id := range s.cells[a] {
+				hits = append(hits, spatialHit{id: id, at: a, dist: hex.DistanceAxial(center, a)})
+			}
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].less(hits[j]) })
+	out := make([]EntityID, len(hits))
+	for i, h := range hits {
+		out[i] = h.id
+	}
+	return out
+}
+
+func (s *SpatialIndex) link(id EntityID, a hex.Axial) {
+	s.positions[id] = a
+	s.cells[a] = insertID(s.cells[a], id)
+	c := s.ChunkOf(a)
+	s.chunks[c] = insertID(s.chunks[c], id)
+}
+
+func (s *SpatialIndex) unlink(id EntityID, a hex.Axial) {
+	delete(s.positions, id)
+	if ids := removeID(s.cells[a], id); len(ids) > 0 {
+		s.cells[a] = ids
+	} else {
+		delete(s.cells, a)
+	}
+	c := s.ChunkOf(a)
+	if ids := removeID(s.chunks[c], id); len(ids) > 0 {
+		s.chunks[c] = ids
+	} else {
+		delete(s.chunks, c)
+	}
+}
+
+// insertID adds id to a sorted slice
+func insertID(ids []EntityID, id EntityID) []EntityID {
+	i := sort.Search(len(ids), func(i int) bool { return ids[i] >= id })
+	if i < len(ids) && ids[i] == id {
+		return ids
+	}
+	ids = append(ids, 0)
+	copy(ids[i+1:], ids[i:])
+	ids[i] = id
+	return ids
+}
+
+// removeID deletes id from a sorted slice
+func removeID(ids []EntityID, id EntityID) []EntityID {
+	i := sort.Search(len(ids), func(i int) bool { return ids[i] >= id })
+	if i < len(ids) && ids[i] == id {
+		return append(ids[:i], ids[i+1:]...)
+	}
+	return ids
+}
+
+func axialLess(a, b hex.Axial) bool {
+	if a.Q != b.Q {
+		return a.Q < b.Q
+	}
+	return a.R < b.R
+}
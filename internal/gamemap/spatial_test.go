@@ -0,0 +1,201 @@
+package gamemap
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gravitas-015/hexcore/hex"
+)
+
+// bruteForce returns the IDs of entities whose distance from center passes
+// keep, in the index's canonical order
+func bruteForce(pos map[EntityID]hex.Axial, center hex.Axial, keep func(d int) bool) []EntityID {
+	var hits []spatialHit
+	for id, a := range pos {
+		if d := hex.DistanceAxial(center, a); keep(d) {
+			hits = append(hits, spatialHit{id: id, at: a, dist: d})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].less(hits[j]) })
+	out := make([]EntityID, len(hits))
+	for i, h := range hits {
+		out[i] = h.id
+	}
+	return out
+}
+
+func TestSpatialInsertMoveRemove(t *testing.T) {
+	s := NewSpatialIndex(9)
+	a, b := hex.Axial{Q: 1, R: 1}, hex.Axial{Q: 30, R: -4}
+
+	s.Insert(3, a)
+	s.Insert(1, a)
+	s.Insert(2, b)
+	if got := s.At(a); !reflect.DeepEqual(got, []EntityID{1, 3}) {
+		t.Errorf("At(a) = %v, want [1 3]", got)
+	}
+
+	if !s.Move(3, b) || s.Move(99, b) {
+		t.Errorf("Move result wrong")
+	}
+	if got := s.At(b); !reflect.DeepEqual(got, []EntityID{2, 3}) {
+		t.Errorf("At(b) after move = %v", got)
+	}
+	if got := s.InChunk(s.ChunkOf(b)); !reflect.DeepEqual(got, []EntityID{2, 3}) {
+		t.Errorf("InChunk(b) = %v", got)
+	}
+	if got := s.OccupiedChunks(); len(got) != 2 {
+		t.Errorf("expected 2 occupied chunks, got %v", got)
+	}
+
+	// re-inserting moves rather than duplicating
+	s.Insert(1, b)
+	if s.Len() != 3 || len(s.At(a)) != 0 {
+		t.Errorf("re-insert duplicated entity")
+	}
+	if got := s.OccupiedChunks(); !reflect.DeepEqual(got, []hex.Axial{s.ChunkOf(b)}) {
+		t.Errorf("empty chunk not dropped: %v", got)
+	}
+
+	if !s.Remove(2) || s.Remove(2) {
+		t.Errorf("Remove result wrong")
+	}
+	if _, ok := s.Position(2); ok {
+		t.Errorf("removed entity still has a position")
+	}
+}
+
+func TestSpatialQueries(t *testing.T) {
+	// a sparse and a dense index exercise both the hex walk and the
+	// full scan paths
+	for _, n := range []int{15, 400} {
+		rng := rand.New(rand.NewSource(int64(n)))
+		s := NewSpatialIndex(4)
+		pos := map[EntityID]hex.Axial{}
+		for id := EntityID(1); id <= EntityID(n); id++ {
+			a := hex.Axial{Q: rng.Intn(21) - 10, R: rng.Intn(21) - 10}
+			s.Insert(id, a)
+			pos[id] = a
+		}
+
+		same := func(got, want []EntityID) bool {
+			return len(got) == len(want) && (len(want) == 0 || reflect.DeepEqual(got, want))
+		}
+		for i := 0; i < 20; i++ {
+			center := hex.Axial{Q: rng.Intn(11) - 5, R: rng.Intn(11) - 5}
+			r := rng.Intn(7)
+			want := bruteForce(pos, center, func(d int) bool { return d <= r })
+			if got := s.InDisk(center, r); !same(got, want) {
+				t.Fatalf("n=%d InDisk(%v, %d) = %v, want %v", n, center, r, got, want)
+			}
+			want = bruteForce(pos, center, func(d int) bool { return d == r })
+			if got := s.InRing(center, r); !same(got, want) {
+				t.Fatalf("n=%d InRing(%v, %d) = %v, want %v", n, center, r, got, want)
+			}
+
+			even := func(id EntityID, _ hex.Axial) bool { return id%2 == 0 }
+			wantNear := bruteForce(pos, center, func(d int) bool { return d <= r })
+			var wantID EntityID
+			for _, id := range wantNear {
+				if id%2 == 0 {
+					wantID = id
+					break
+				}
+			}
+			id, at, ok := s.Nearest(center, r, even)
+			if ok != (wantID != 0) || id != wantID || (ok && at != pos[id]) {
+				t.Fatalf("n=%d Nearest(%v, %d) = %d %v %v, want %d", n, center, r, id, at, ok, wantID)
+			}
+		}
+
+		for _, c := range s.OccupiedChunks() {
+			for _, id := range s.InChunk(c) {
+				if s.ChunkOf(pos[id]) != c {
+					t.Fatalf("entity %d listed in wrong chunk", id)
+				}
+			}
+		}
+	}
+}
+
+func TestChunkPosOfNearestOrigin(t *testing.T) {
+	const R = 9
+	for q := -40; q <= 40; q++ {
+		for r := -40; r <= 40; r++ {
+			a := hex.Axial{Q: q, R: r}
+			got := ChunkPosOf(a, R)
+			d := hex.DistanceAxial(a, ChunkOrigin(got, R))
+			if d > R {
+				t.Fatalf("%v assigned to chunk %v outside its disk (distance %d)", a, got, d)
+			}
+			// No chunk origin anywhere near is closer
+			for cq := got.Q - 3; cq <= got.Q+3; cq++ {
+				for cr := got.R - 3; cr <= got.R+3; cr++ {
+					if hex.DistanceAxial(a, ChunkOrigin(hex.Axial{Q: cq, R: cr}, R)) < d {
+						t.Fatalf("%v: chunk %v is closer than %v", a, hex.Axial{Q: cq, R: cr}, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSpatialChunksMatchMap(t *testing.T) {
+	gm, err := New(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Index an entity on every hex of the map; every hex sits in the chunk
+	// its key names
+	var id EntityID
+	for _, chunk := range gm.Chunks {
+		chunk.Hexes.Each(func(local hex.Axial, h *Hex) {
+			id++
+			gm.Spatial.Insert(id, h.WorldPos)
+		})
+	}
+
+	for _, key := range gm.Spatial.OccupiedChunks() {
+		owner, ok := gm.GetChunk(key)
+		if !ok {
+			// Edge hexes of the outer ring may belong to the next ring of
+			// chunks, which is not generated yet
+			if hex.DistanceAxial(key, hex.Axial{}) != gm.ChunkRadius+1 {
+				t.Errorf("occupied chunk %v has no map chunk", key)
+			}
+			continue
+		}
+		for _, eid := range gm.Spatial.InChunk(key) {
+			at, _ := gm.Spatial.Position(eid)
+			if _, inside := owner.GetHex(at.Subtract(ChunkOrigin(key, owner.Radius))); !inside {
+				t.Fatalf("hex %v keyed to chunk %v that does not contain it", at, key)
+			}
+		}
+	}
+	if len(gm.Spatial.InChunk(hex.Axial{})) == 0 {
+		t.Error("no entities indexed in the origin chunk")
+	}
+}
+
+func TestHexTerrainByName(t *testing.T) {
+	gm, err := New(1)
+	if err != nil {
+		t.Fatalf("failed to create map: %v", err)
+	}
+	chunk, ok := gm.GetChunk(hex.Axial{})
+	if !ok {
+		t.Fatal("origin chunk missing")
+	}
+	data, err := json.Marshal(chunk.Hexes.Ptr(chunk.Hexes.Center))
+	if err != nil {
+		t.Fatalf("failed to marshal hex: %v", err)
+	}
+	var wire struct{ Terrain string }
+	if err := json.Unmarshal(data, &wire); err != nil || wire.Terrain != "plains" {
+		t.Errorf("expected terrain sent by name, got %s", data)
+	}
+}
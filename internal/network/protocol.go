@@ -1,6 +1,10 @@
 package network
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/gravitas-games/mmorts/pkg/models"
+)
 
 // Message types - Client → Server
 const (
@@ -8,6 +12,17 @@ const (
 	MsgTypeLeave = "leave"
 	MsgTypeChat  = "chat"
 	MsgTypePing  = "ping"
+
+	MsgTypeSessionControl = "session_control" // Admin only
+
+	MsgTypePlayerListRequest    = "player_list"
+	MsgTypePlayerProfileRequest = "player_profile"
+
+	MsgTypeStartProduction          = "start_production"
+	MsgTypeStartRepeatingProduction = "start_repeating_production"
+	MsgTypeCancelProduction         = "cancel_production" // Refunds the job's inputs
+	MsgTypeJobListRequest           = "job_list"
+	MsgTypeRecipeListRequest        = "recipe_list"
 )
 
 // Message types - Server → Client
@@ -19,6 +34,16 @@ const (
 	MsgTypeSessionStatus = "session_status"
 	MsgTypeError         = "error"
 	MsgTypePong          = "pong"
+	MsgTypePlayerList    = "player_list"
+	MsgTypePlayerProfile = "player_profile"
+	MsgTypePresence      = "presence"
+	MsgTypeJobList       = "job_list"
+	MsgTypeRecipeList    = "recipe_list"
+	MsgTypeJobStarted    = "job_started"
+	MsgTypeJobCompleted  = "job_completed"
+	MsgTypeJobFailed     = "job_failed"
+	MsgTypeJobCancelled  = "job_cancelled"
+	MsgTypeJobUpdated    = "job_updated" // Any other job event, named in the payload
 )
 
 // ClientMessage represents any message from client to server
@@ -46,6 +71,27 @@ type ChatPayload struct {
 	Message string `json:"message"`
 }
 
+// SessionControlPayload is sent by an admin to drive the session lifecycle
+type SessionControlPayload struct {
+	Action string `json:"action"` // "start", "pause", "resume", "end"
+}
+
+// PlayerProfileRequestPayload asks for another player's public profile
+type PlayerProfileRequestPayload struct {
+	PlayerID string `json:"player_id"`
+}
+
+// StartProductionPayload starts a job (start_production and
+// start_repeating_production) in the player's own inventory
+type StartProductionPayload struct {
+	RecipeID string `json:"recipe_id"`
+}
+
+// CancelProductionPayload cancels one of the player's jobs
+type CancelProductionPayload struct {
+	JobID string `json:"job_id"`
+}
+
 // --- Server Message Payloads ---
 
 // WelcomePayload is sent to client after successful connection
@@ -56,11 +102,10 @@ type WelcomePayload struct {
 	SessionStatus SessionStatus `json:"session_status"`
 }
 
-// PlayerJoinedPayload notifies clients when a player joins
+// PlayerJoinedPayload notifies clients when a player joins. It carries
+// only the player's public profile.
 type PlayerJoinedPayload struct {
-	PlayerID string `json:"player_id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
+	models.PublicProfile
 }
 
 // PlayerLeftPayload notifies clients when a player leaves
@@ -69,6 +114,31 @@ type PlayerLeftPayload struct {
 	Username string `json:"username"`
 }
 
+// PlayerInfo is one entry of the player list: a public profile plus
+// presence
+type PlayerInfo struct {
+	models.PublicProfile
+	Presence string `json:"presence"` // "online", "idle", "away", "reconnecting"
+}
+
+// PlayerListPayload is sent right after welcome and on request, listing
+// everyone currently in the session
+type PlayerListPayload struct {
+	Players []PlayerInfo `json:"players"`
+}
+
+// PlayerProfilePayload answers a player_profile query
+type PlayerProfilePayload struct {
+	PlayerInfo
+	OnlineSince int64 `json:"online_since"` // Unix timestamp of the current connection
+}
+
+// PresencePayload notifies clients when a player's presence changes
+type PresencePayload struct {
+	PlayerID string `json:"player_id"`
+	Presence string `json:"presence"`
+}
+
 // ChatBroadcastPayload broadcasts a chat message to all clients
 type ChatBroadcastPayload struct {
 	PlayerID  string `json:"player_id"`
@@ -77,9 +147,10 @@ type ChatBroadcastPayload struct {
 	Timestamp int64  `json:"timestamp"` // Unix timestamp
 }
 
-// SessionStatus represents the current session state
+// SessionStatus represents the current session state. It is included in
+// welcome and pushed as session_status on every lifecycle transition.
 type SessionStatus struct {
-	State       string `json:"state"`
+	State       string `json:"state"` // "waiting", "running", "paused", "ending", "closed"
 	PlayerCount int    `json:"player_count"`
 	MaxPlayers  int    `json:"max_players"`
 	ServerTick  int64  `json:"server_tick"`
@@ -91,3 +162,52 @@ type ErrorPayload struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 }
+
+// JobInfo describes a production job. Durations are game time, which only
+// advances while the session is running.
+type JobInfo struct {
+	JobID       string  `json:"job_id"`
+	RecipeID    string  `json:"recipe_id"`
+	State       string  `json:"state"`    // "running", "blocked", "complete", "failed", "cancelled"
+	Progress    float64 `json:"progress"` // 0.0-1.0
+	DurationMs  int64   `json:"duration_ms"`
+	RemainingMs int64   `json:"remaining_ms"`
+	Repeat      bool    `json:"repeat"`
+	Cycles      int     `json:"cycles"` // Completed cycles of a repeating job
+}
+
+// JobEventPayload is sent with every job_* message. Event names the change
+// for job_updated ("blocked", "unblocked", "stalled", "recovered", ...).
+type JobEventPayload struct {
+	JobInfo
+	Event  string `json:"event"`
+	Reason string `json:"reason,omitempty"` // Why a job failed
+}
+
+// JobListPayload answers a job_list request with the player's active jobs
+type JobListPayload struct {
+	Jobs []JobInfo `json:"jobs"`
+}
+
+// RecipeItem is one input or output of a recipe
+type RecipeItem struct {
+	Item        string  `json:"item"`
+	Quantity    int     `json:"quantity"`
+	Consume     bool    `json:"consume,omitempty"`     // Inputs: false for tools that are only required
+	Probability float64 `json:"probability,omitempty"` // Outputs: chance of being produced
+}
+
+// RecipeInfo describes a recipe players can start
+type RecipeInfo struct {
+	RecipeID   string       `json:"recipe_id"`
+	Name       string       `json:"name"`
+	Category   string       `json:"category,omitempty"`
+	DurationMs int64        `json:"duration_ms"`
+	Inputs     []RecipeItem `json:"inputs"`
+	Outputs    []RecipeItem `json:"outputs"`
+}
+
+// RecipeListPayload answers a recipe_list request
+type RecipeListPayload struct {
+	Recipes []RecipeInfo `json:"recipes"`
+}
@@ -2,7 +2,11 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -35,11 +39,22 @@ type Connection struct {
 	// Player information (set after authentication)
 	player *models.Player
 
-	// Buffered channel for outbound messages
-	send chan []byte
+	// Buffered channel for outbound messages, guarded against sends after
+	// close by sendMu
+	send       chan []byte
+	sendMu     sync.RWMutex
+	sendClosed bool
 
 	// Is connection authenticated
 	authenticated bool
+
+	// Ensures Close runs once when both the read pump and shutdown close it
+	closeOnce sync.Once
+
+	// Set by the read pump when the client closed the socket deliberately,
+	// in which case its seat is not held for a reconnect. Atomic because
+	// Close may run on the shutdown goroutine.
+	cleanClose atomic.Bool
 }
 
 // NewConnection creates a new connection
@@ -91,6 +106,7 @@ func (c *Connection) readPump() {
 				log.Printf("WebSocket unexpected close error from %s: %v", playerInfo, err)
 			} else if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
 				log.Printf("WebSocket normal closure from %s: %v", playerInfo, err)
+				c.cleanClose.Store(true)
 			} else {
 				log.Printf("WebSocket read error from %s (type: %d): %v", playerInfo, messageType, err)
 			}
@@ -110,6 +126,11 @@ func (c *Connection) readPump() {
 			continue
 		}
 
+		// Any message counts as activity for presence
+		if c.player != nil {
+			c.server.session.Touch(c.player.ID)
+		}
+
 		// Handle message based on type
 		c.handleMessage(&clientMsg)
 	}
@@ -184,6 +205,30 @@ func (c *Connection) handleMessage(msg *network.ClientMessage) {
 	case network.MsgTypePing:
 		c.handlePing()
 
+	case network.MsgTypeSessionControl:
+		c.handleSessionControl(msg.Payload)
+
+	case network.MsgTypePlayerListRequest:
+		c.handlePlayerList()
+
+	case network.MsgTypePlayerProfileRequest:
+		c.handlePlayerProfile(msg.Payload)
+
+	case network.MsgTypeStartProduction:
+		c.handleStartProduction(msg.Payload, false)
+
+	case network.MsgTypeStartRepeatingProduction:
+		c.handleStartProduction(msg.Payload, true)
+
+	case network.MsgTypeCancelProduction:
+		c.handleCancelProduction(msg.Payload)
+
+	case network.MsgTypeJobListRequest:
+		c.handleJobList()
+
+	case network.MsgTypeRecipeListRequest:
+		c.handleRecipeList()
+
 	default:
 		log.Printf("Unknown message type: %s", msg.Type)
 		c.SendError("unknown_message_type", "Unknown message type")
@@ -200,11 +245,23 @@ func (c *Connection) handleJoin(payload json.RawMessage) {
 		return
 	}
 
+	if state := c.server.session.State(); !state.AcceptsJoins() {
+		c.SendError("session_closed", fmt.Sprintf("Session is %s", state))
+		return
+	}
+
+	// A player whose seat is being held picks up where they left off
+	resumed := c.server.session.IsReconnecting(c.player.ID)
+
 	// Update player connection state
 	c.player.Connected = true
 	c.player.ConnectedAt = time.Now()
+	c.player.LastSeen = c.player.ConnectedAt
 	c.player.SessionID = c.server.session.ID
 
+	// Give the player somewhere to craft into
+	c.server.session.production.EnsureInventory(c.player.ID)
+
 	// Add player to session
 	if err := c.server.session.AddPlayer(c.player, c); err != nil {
 		log.Printf("Failed to add player to session: %v", err)
@@ -216,39 +273,46 @@ func (c *Connection) handleJoin(payload json.RawMessage) {
 	welcome := network.ServerMessage{
 		Type: network.MsgTypeWelcome,
 		Payload: network.WelcomePayload{
-			PlayerID:  c.player.ID,
-			Username:  c.player.Username,
-			SessionID: c.server.session.ID,
-			SessionStatus: network.SessionStatus{
-				State:       c.server.session.status.State,
-				PlayerCount: c.server.session.status.PlayerCount,
-				MaxPlayers:  c.server.session.status.MaxPlayers,
-				ServerTick:  c.server.session.status.ServerTick,
-				Uptime:      c.server.session.status.Uptime,
-			},
+			PlayerID:      c.player.ID,
+			Username:      c.player.Username,
+			SessionID:     c.server.session.ID,
+			SessionStatus: c.server.session.statusPayload(),
 		},
 	}
 
 	c.SendMessage(&welcome)
 
-	// Broadcast player joined to all other players
-	c.server.session.BroadcastExcept(c, &network.ServerMessage{
-		Type: network.MsgTypePlayerJoined,
-		Payload: network.PlayerJoinedPayload{
-			PlayerID: c.player.ID,
-			Username: c.player.Username,
-			Email:    c.player.Email,
-		},
-	})
+	// Tell the new player who is already here
+	c.handlePlayerList()
+
+	if resumed {
+		// Others already know this player; they only see presence change
+		c.server.session.BroadcastExcept(c, &network.ServerMessage{
+			Type: network.MsgTypePresence,
+			Payload: network.PresencePayload{
+				PlayerID: c.player.ID,
+				Presence: string(models.PresenceOnline),
+			},
+		})
+	} else {
+		// Broadcast player joined to all other players
+		c.server.session.BroadcastExcept(c, &network.ServerMessage{
+			Type: network.MsgTypePlayerJoined,
+			Payload: network.PlayerJoinedPayload{
+				PublicProfile: c.player.PublicProfile(),
+			},
+		})
+	}
 
 	log.Printf("Player %s joined session %s", c.player.Username, c.server.session.ID)
+
+	// Joining may satisfy the auto-start rule
+	c.server.session.CheckTransitions()
 }
 
 // handleLeave handles player leave requests
 func (c *Connection) handleLeave() {
-	if c.player != nil {
-		c.server.session.RemovePlayer(c.player.ID)
-
+	if c.player != nil && c.server.session.Leave(c.player.ID, c) {
 		// Broadcast player left
 		c.server.session.BroadcastMessage(&network.ServerMessage{
 			Type: network.MsgTypePlayerLeft,
@@ -257,6 +321,9 @@ func (c *Connection) handleLeave() {
 				Username: c.player.Username,
 			},
 		})
+
+		// Leaving may empty the session
+		c.server.session.CheckTransitions()
 	}
 }
 
@@ -294,6 +361,154 @@ func (c *Connection) handleChat(payload json.RawMessage) {
 	log.Printf("Chat from %s: %s", c.player.Username, chatMsg.Message)
 }
 
+// handleSessionControl handles admin requests to start, pause, resume or
+// end the session
+func (c *Connection) handleSessionControl(payload json.RawMessage) {
+	if !c.authenticated || c.player == nil {
+		c.SendError("not_authenticated", "Must be authenticated to control the session")
+		return
+	}
+
+	required := c.server.config.Session.AdminPermission
+	if required == 0 || c.player.Permissions&required != required {
+		c.SendError("forbidden", "Session control requires admin permission")
+		return
+	}
+
+	var control network.SessionControlPayload
+	if err := json.Unmarshal(payload, &control); err != nil {
+		c.SendError("invalid_message", "Invalid session control payload")
+		return
+	}
+
+	if err := c.server.session.Control(control.Action); err != nil {
+		log.Printf("Session control %q from %s rejected: %v", control.Action, c.player.Username, err)
+		c.SendError("invalid_transition", err.Error())
+		return
+	}
+
+	log.Printf("Session control %q applied by %s", control.Action, c.player.Username)
+}
+
+// handlePlayerList sends a snapshot of everyone in the session
+func (c *Connection) handlePlayerList() {
+	c.SendMessage(&network.ServerMessage{
+		Type:    network.MsgTypePlayerList,
+		Payload: c.server.session.PlayerList(),
+	})
+}
+
+// handlePlayerProfile answers a query for another player's public profile
+func (c *Connection) handlePlayerProfile(payload json.RawMessage) {
+	var req network.PlayerProfileRequestPayload
+	if err := json.Unmarshal(payload, &req); err != nil || req.PlayerID == "" {
+		c.SendError("invalid_message", "Invalid player profile request")
+		return
+	}
+
+	profile, ok := c.server.session.PlayerProfile(req.PlayerID)
+	if !ok {
+		c.SendError("player_not_found", "Player is not in this session")
+		return
+	}
+
+	c.SendMessage(&network.ServerMessage{
+		Type:    network.MsgTypePlayerProfile,
+		Payload: profile,
+	})
+}
+
+// joinedPlayer reports whether the connection belongs to a player in the
+// session, sending an error if not
+func (c *Connection) joinedPlayer() bool {
+	if !c.authenticated || c.player == nil {
+		c.SendError("not_authenticated", "Connection not authenticated")
+		return false
+	}
+	if _, ok := c.server.session.GetPlayer(c.player.ID); !ok {
+		c.SendError("not_joined", "Join the session first")
+		return false
+	}
+	return true
+}
+
+// acceptsCommands reports whether the session is running, sending an error
+// if not
+func (c *Connection) acceptsCommands() bool {
+	if state := c.server.session.State(); !state.AcceptsCommands() {
+		c.SendError("session_not_running", fmt.Sprintf("Session is %s", state))
+		return false
+	}
+	return true
+}
+
+// handleStartProduction starts a job in the player's inventory. The result
+// arrives as job_started, pushed by the production event bus.
+func (c *Connection) handleStartProduction(payload json.RawMessage, repeat bool) {
+	if !c.joinedPlayer() || !c.acceptsCommands() {
+		return
+	}
+
+	var req network.StartProductionPayload
+	if err := json.Unmarshal(payload, &req); err != nil || req.RecipeID == "" {
+		c.SendError("invalid_message", "Invalid start production request")
+		return
+	}
+
+	jobID, err := c.server.session.production.Start(c.player.ID, req.RecipeID, repeat)
+	if err != nil {
+		c.SendError("production_failed", err.Error())
+		return
+	}
+
+	log.Printf("Player %s started %s (job %s, repeat %v)", c.player.Username, req.RecipeID, jobID, repeat)
+}
+
+// handleCancelProduction cancels one of the player's jobs with a refund
+func (c *Connection) handleCancelProduction(payload json.RawMessage) {
+	if !c.joinedPlayer() || !c.acceptsCommands() {
+		return
+	}
+
+	var req network.CancelProductionPayload
+	if err := json.Unmarshal(payload, &req); err != nil || req.JobID == "" {
+		c.SendError("invalid_message", "Invalid cancel production request")
+		return
+	}
+
+	if err := c.server.session.production.Cancel(c.player.ID, req.JobID); err != nil {
+		if errors.Is(err, errJobNotFound) {
+			c.SendError("job_not_found", "No such job")
+			return
+		}
+		c.SendError("cancel_failed", err.Error())
+	}
+}
+
+// handleJobList sends the player's active jobs
+func (c *Connection) handleJobList() {
+	if !c.joinedPlayer() {
+		return
+	}
+
+	c.SendMessage(&network.ServerMessage{
+		Type:    network.MsgTypeJobList,
+		Payload: c.server.session.production.Jobs(c.player.ID),
+	})
+}
+
+// handleRecipeList sends the recipes players can start
+func (c *Connection) handleRecipeList() {
+	if !c.joinedPlayer() {
+		return
+	}
+
+	c.SendMessage(&network.ServerMessage{
+		Type:    network.MsgTypeRecipeList,
+		Payload: c.server.session.production.Recipes(),
+	})
+}
+
 // handlePing handles ping requests
 func (c *Connection) handlePing() {
 	c.SendMessage(&network.ServerMessage{
@@ -310,6 +525,13 @@ func (c *Connection) SendMessage(msg *network.ServerMessage) {
 		return
 	}
 
+	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+
+	if c.sendClosed {
+		return
+	}
+
 	select {
 	case c.send <- data:
 	default:
@@ -317,6 +539,21 @@ func (c *Connection) SendMessage(msg *network.ServerMessage) {
 	}
 }
 
+// closeAfterSend ends the connection once the messages already queued have
+// been written: the write pump then sends a close frame and closes the
+// socket, and the read pump runs Close. The player leaves rather than having
+// their seat held.
+func (c *Connection) closeAfterSend() {
+	c.cleanClose.Store(true)
+
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if !c.sendClosed {
+		c.sendClosed = true
+		close(c.send)
+	}
+}
+
 // SendError sends an error message to the client
 func (c *Connection) SendError(code, message string) {
 	c.SendMessage(&network.ServerMessage{
@@ -330,14 +567,26 @@ func (c *Connection) SendError(code, message string) {
 
 // Close closes the connection
 func (c *Connection) Close() {
-	// Remove player from session if authenticated
-	if c.authenticated && c.player != nil {
-		c.handleLeave()
-	}
+	c.closeOnce.Do(func() {
+		// Remove player from session if authenticated, unless the
+		// connection dropped and their seat is held for a reconnect
+		if c.authenticated && c.player != nil {
+			grace := time.Duration(c.server.config.Session.ReconnectGraceSecs) * time.Second
+			serverClosing := c.server.ctx.Err() != nil
+			if c.cleanClose.Load() || serverClosing || grace <= 0 || !c.server.session.Disconnect(c.player.ID, c, grace) {
+				c.handleLeave()
+			}
+		}
 
-	// Close send channel
-	close(c.send)
+		// Close send channel, unless closeAfterSend already did
+		c.sendMu.Lock()
+		if !c.sendClosed {
+			c.sendClosed = true
+			close(c.send)
+		}
+		c.sendMu.Unlock()
 
-	// Close WebSocket connection
-	c.ws.Close()
+		// Close WebSocket connection
+		c.ws.Close()
+	})
 }
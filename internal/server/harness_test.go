@@ -0,0 +1,480 @@
+package server
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/gravitas-games/mmorts/internal/config"
+	"github.com/gravitas-games/mmorts/internal/network"
+)
+
+const (
+	testIssuer      = "test-login-server"
+	testBlacklist   = "jwt:blacklist:"
+	testReadTimeout = 2 * time.Second
+)
+
+// testHarness runs a Server on an ephemeral port backed by an in-memory
+// Redis stand-in and a locally signed ECDSA key
+type testHarness struct {
+	t       *testing.T
+	server  *Server
+	redis   *memRedis
+	keySrv  *httptest.Server
+	key     *ecdsa.PrivateKey
+	addr    string
+	served  chan error
+	stopped bool
+}
+
+// newTestHarness starts a fully wired server and registers cleanup. Options
+// may adjust the configuration before the server is created.
+func newTestHarness(t *testing.T, opts ...func(*config.Config)) *testHarness {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	keySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(pubPEM)
+	}))
+
+	redis := newMemRedis(t)
+
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Issuer:              testIssuer,
+			PublicKeyURL:        keySrv.URL,
+			PublicKeyRefreshHrs: 24,
+		},
+		Redis: config.RedisConfig{
+			Address:         redis.Addr(),
+			BlacklistPrefix: testBlacklist,
+		},
+		Session: config.SessionConfig{
+			MaxPlayers:       10,
+			InitialMapRadius: 1,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	srv, err := New(cfg)
+	if err != nil {
+		keySrv.Close()
+		redis.Close()
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	h := &testHarness{
+		t:      t,
+		server: srv,
+		redis:  redis,
+		keySrv: keySrv,
+		key:    key,
+		addr:   ln.Addr().String(),
+		served: make(chan error, 1),
+	}
+
+	go func() { h.served <- srv.Serve(ln) }()
+
+	t.Cleanup(func() {
+		h.Shutdown()
+		keySrv.Close()
+		redis.Close()
+	})
+	return h
+}
+
+// Shutdown stops the server and waits for Serve to return
+func (h *testHarness) Shutdown() {
+	if h.stopped {
+		return
+	}
+	h.stopped = true
+
+	if err := h.server.Shutdown(); err != nil {
+		h.t.Errorf("shutdown failed: %v", err)
+	}
+
+	select {
+	case err := <-h.served:
+		if err != nil {
+			h.t.Errorf("serve returned error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		h.t.Errorf("server did not stop serving")
+	}
+}
+
+// tokenClaims returns valid claims for a user that tests can adjust
+func (h *testHarness) tokenClaims(userID int64, username string) *Claims {
+	now := time.Now()
+	return &Claims{
+		UserID:      userID,
+		Email:       username + "@example.com",
+		Username:    username,
+		UserType:    "user",
+		AuthMethod:  "password",
+		Permissions: 1,
+		Activated:   now.UnixNano(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    testIssuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+}
+
+// sign signs claims with the harness key
+func (h *testHarness) sign(claims *Claims) string {
+	h.t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(h.key)
+	if err != nil {
+		h.t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+// token returns a valid signed token for a user
+func (h *testHarness) token(userID int64, username string) string {
+	return h.sign(h.tokenClaims(userID, username))
+}
+
+// dialToken opens a WebSocket with the given token, returning the HTTP
+// response so rejected handshakes can be inspected
+func (h *testHarness) dialToken(token string) (*testClient, *http.Response, error) {
+	dialer := websocket.Dialer{
+		HandshakeTimeout: testReadTimeout,
+		Subprotocols:     []string{"access_token", token},
+	}
+
+	ws, resp, err := dialer.Dial("ws://"+h.addr+"/ws", nil)
+	if err != nil {
+		return nil, resp, err
+	}
+	return &testClient{t: h.t, ws: ws}, resp, nil
+}
+
+// connect dials as a valid user and fails the test on error
+func (h *testHarness) connect(userID int64, username string) *testClient {
+	h.t.Helper()
+	c, _, err := h.dialToken(h.token(userID, username))
+	if err != nil {
+		h.t.Fatalf("dial failed for %s: %v", username, err)
+	}
+	h.t.Cleanup(c.Close)
+	return c
+}
+
+// join connects a user, sends join and consumes the welcome and
+// player_list messages
+func (h *testHarness) join(userID int64, username string) *testClient {
+	h.t.Helper()
+	return h.joinAs(h.tokenClaims(userID, username))
+}
+
+// joinAs joins with custom claims, e.g. extra permissions
+func (h *testHarness) joinAs(claims *Claims) *testClient {
+	h.t.Helper()
+	c, _, err := h.dialToken(h.sign(claims))
+	if err != nil {
+		h.t.Fatalf("dial failed for %s: %v", claims.Username, err)
+	}
+	h.t.Cleanup(c.Close)
+	c.Send(network.MsgTypeJoin, network.JoinPayload{})
+
+	var welcome network.WelcomePayload
+	c.Expect(network.MsgTypeWelcome, &welcome)
+	c.PlayerID = welcome.PlayerID
+	c.Expect(network.MsgTypePlayerList, &c.Players)
+	return c
+}
+
+// testClient is a WebSocket client that reads typed server messages
+type testClient struct {
+	t        *testing.T
+	ws       *websocket.Conn
+	PlayerID string
+	Players  network.PlayerListPayload // Snapshot received after welcome
+	once     sync.Once
+}
+
+// receivedMessage is a server message with its payload left undecoded
+type receivedMessage struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Send writes a client message
+func (c *testClient) Send(msgType string, payload interface{}) {
+	c.t.Helper()
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		c.t.Fatalf("failed to encode payload: %v", err)
+	}
+	if err := c.ws.WriteJSON(network.ClientMessage{Type: msgType, Payload: raw}); err != nil {
+		c.t.Fatalf("write failed: %v", err)
+	}
+}
+
+// Next reads the next server message
+func (c *testClient) Next() (receivedMessage, error) {
+	var msg receivedMessage
+	c.ws.SetReadDeadline(time.Now().Add(testReadTimeout))
+	err := c.ws.ReadJSON(&msg)
+	return msg, err
+}
+
+// Expect reads the next message, asserts its type and decodes the payload
+// into out (which may be nil)
+func (c *testClient) Expect(msgType string, out interface{}) {
+	c.t.Helper()
+	msg, err := c.Next()
+	if err != nil {
+		c.t.Fatalf("expected %q message, read failed: %v", msgType, err)
+	}
+	if msg.Type != msgType {
+		c.t.Fatalf("expected %q message, got %q: %s", msgType, msg.Type, msg.Payload)
+	}
+	if out != nil {
+		if err := json.Unmarshal(msg.Payload, out); err != nil {
+			c.t.Fatalf("failed to decode %q payload: %v", msgType, err)
+		}
+	}
+}
+
+// ExpectSequence asserts the types of the next messages in order
+func (c *testClient) ExpectSequence(types ...string) {
+	c.t.Helper()
+	for _, msgType := range types {
+		c.Expect(msgType, nil)
+	}
+}
+
+// ExpectNone asserts that no message arrives within d
+func (c *testClient) ExpectNone(d time.Duration) {
+	c.t.Helper()
+	c.ws.SetReadDeadline(time.Now().Add(d))
+	var msg receivedMessage
+	if err := c.ws.ReadJSON(&msg); err == nil {
+		c.t.Fatalf("expected no message, got %q: %s", msg.Type, msg.Payload)
+	}
+}
+
+// ExpectClosed asserts that the server closes the connection
+func (c *testClient) ExpectClosed() {
+	c.t.Helper()
+	for {
+		msg, err := c.Next()
+		if err == nil {
+			continue // Drain anything sent before the close
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			c.t.Fatalf("connection not closed, last message: %q", msg.Type)
+		}
+		return
+	}
+}
+
+// Close closes the client connection
+func (c *testClient) Close() {
+	c.once.Do(func() {
+		c.ws.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			time.Now().Add(time.Second))
+		c.ws.Close()
+	})
+}
+
+// memRedis is a minimal in-memory Redis stand-in speaking enough RESP for
+// the commands the server issues (PING, EXISTS, SET, GET, DEL)
+type memRedis struct {
+	t    *testing.T
+	ln   net.Listener
+	mu   sync.Mutex
+	data map[string]string
+	wg   sync.WaitGroup
+}
+
+func newMemRedis(t *testing.T) *memRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start redis stand-in: %v", err)
+	}
+
+	r := &memRedis{t: t, ln: ln, data: make(map[string]string)}
+	r.wg.Add(1)
+	go r.accept()
+	return r
+}
+
+// Addr returns the listen address
+func (r *memRedis) Addr() string {
+	return r.ln.Addr().String()
+}
+
+// Set stores a key directly
+func (r *memRedis) Set(key, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data[key] = value
+}
+
+// Close stops accepting connections
+func (r *memRedis) Close() {
+	r.ln.Close()
+	r.wg.Wait()
+}
+
+func (r *memRedis) accept() {
+	defer r.wg.Done()
+	for {
+		conn, err := r.ln.Accept()
+		if err != nil {
+			return
+		}
+		go r.serve(conn)
+	}
+}
+
+func (r *memRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	for {
+		args, err := readRESPArray(reader)
+		if err != nil {
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if _, err := io.WriteString(conn, r.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+// exec runs a command and returns the RESP-encoded reply
+func (r *memRedis) exec(args []string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "EXISTS":
+		n := 0
+		for _, key := range args[1:] {
+			if _, ok := r.data[key]; ok {
+				n++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "SET":
+		if len(args) < 3 {
+			return "-ERR wrong number of arguments\r\n"
+		}
+		r.data[args[1]] = args[2]
+		return "+OK\r\n"
+	case "GET":
+		if len(args) != 2 {
+			return "-ERR wrong number of arguments\r\n"
+		}
+		value, ok := r.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "DEL":
+		n := 0
+		for _, key := range args[1:] {
+			if _, ok := r.data[key]; ok {
+				delete(r.data, key)
+				n++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+// readRESPArray reads one RESP array of bulk strings
+func readRESPArray(reader *bufio.Reader) ([]string, error) {
+	line, err := readRESPLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return nil, fmt.Errorf("expected array, got %q", line)
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		header, err := readRESPLine(reader)
+		if err != nil {
+			return nil, err
+		}
+		if len(header) == 0 || header[0] != '$' {
+			return nil, fmt.Errorf("expected bulk string, got %q", header)
+		}
+		size, err := strconv.Atoi(header[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, size+2) // Payload plus trailing CRLF
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+func readRESPLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"fmt"
+)
+
+// SessionState is a stage of the session lifecycle
+type SessionState string
+
+// Session lifecycle states
+const (
+	StateWaiting SessionState = "waiting" // Lobby: players gather, no ticks
+	StateRunning SessionState = "running" // Game in progress, ticks advance
+	StatePaused  SessionState = "paused"  // Ticks frozen, players may stay connected
+	StateEnding  SessionState = "ending"  // Game over, grace period before close
+	StateClosed  SessionState = "closed"  // Terminal, no joins accepted
+)
+
+// sessionTransitions lists the states reachable from each state
+var sessionTransitions = map[SessionState][]SessionState{
+	StateWaiting: {StateRunning, StateEnding},
+	StateRunning: {StatePaused, StateEnding},
+	StatePaused:  {StateRunning, StateEnding},
+	StateEnding:  {StateClosed},
+	StateClosed:  {},
+}
+
+// CanTransition reports whether the lifecycle allows moving from one state
+// to another
+func CanTransition(from, to SessionState) bool {
+	for _, next := range sessionTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsJoins reports whether players may join in this state
+func (st SessionState) AcceptsJoins() bool {
+	return st != StateEnding && st != StateClosed
+}
+
+// AcceptsCommands reports whether game commands are processed in this state
+func (st SessionState) AcceptsCommands() bool {
+	return st == StateRunning
+}
+
+// Ticks reports whether the game loop advances in this state
+func (st SessionState) Ticks() bool {
+	return st == StateRunning
+}
+
+// Session control actions sent by admins
+const (
+	ControlStart  = "start"
+	ControlPause  = "pause"
+	ControlResume = "resume"
+	ControlEnd    = "end"
+)
+
+// controlTargets maps a session control action to the state it requests
+var controlTargets = map[string]SessionState{
+	ControlStart:  StateRunning,
+	ControlPause:  StatePaused,
+	ControlResume: StateRunning,
+	ControlEnd:    StateEnding,
+}
+
+// pauseReason records why the session was paused so rule-driven pauses
+// can be lifted automatically while admin pauses cannot
+type pauseReason int
+
+const (
+	pauseNone pauseReason = iota
+	pauseAdmin
+	pauseEmpty
+)
+
+// errInvalidTransition is returned when a transition is not allowed
+type errInvalidTransition struct {
+	from, to SessionState
+}
+
+func (e errInvalidTransition) Error() string {
+	return fmt.Sprintf("cannot transition session from %s to %s", e.from, e.to)
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitas-games/mmorts/internal/config"
+	"github.com/gravitas-games/mmorts/internal/network"
+)
+
+const testAdminPermission = 1024
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to SessionState
+		want     bool
+	}{
+		{StateWaiting, StateRunning, true},
+		{StateWaiting, StatePaused, false},
+		{StateRunning, StatePaused, true},
+		{StatePaused, StateRunning, true},
+		{StateRunning, StateEnding, true},
+		{StateEnding, StateClosed, true},
+		{StateEnding, StateRunning, false},
+		{StateClosed, StateWaiting, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestAutoStartPushesStatus(t *testing.T) {
+	h := newTestHarness(t, func(cfg *config.Config) {
+		cfg.Session.AutoStartPlayers = 2
+	})
+
+	alice := h.join(101, "alice")
+	if state := h.server.session.State(); state != StateWaiting {
+		t.Fatalf("expected waiting with one player, got %s", state)
+	}
+
+	bob := h.join(102, "bob")
+	alice.Expect(network.MsgTypePlayerJoined, nil)
+
+	for _, c := range []*testClient{alice, bob} {
+		var status network.SessionStatus
+		c.Expect(network.MsgTypeSessionStatus, &status)
+		if status.State != string(StateRunning) || status.PlayerCount != 2 {
+			t.Errorf("unexpected session_status: %+v", status)
+		}
+	}
+}
+
+func TestPauseWhenEmpty(t *testing.T) {
+	h := newTestHarness(t, func(cfg *config.Config) {
+		cfg.Session.AutoStartPlayers = 1
+		cfg.Session.PauseWhenEmpty = true
+	})
+
+	alice := h.join(101, "alice")
+	alice.Expect(network.MsgTypeSessionStatus, nil)
+
+	alice.Close()
+	waitForState(t, h.server.session, StatePaused)
+
+	// Rule-driven pauses lift when someone comes back
+	bob := h.join(102, "bob")
+	var status network.SessionStatus
+	bob.Expect(network.MsgTypeSessionStatus, &status)
+	if status.State != string(StateRunning) {
+		t.Errorf("expected running after rejoin, got %s", status.State)
+	}
+}
+
+func TestPauseWhenEmptyWhileReconnecting(t *testing.T) {
+	h := newTestHarness(t, manualPresenceSweeps, func(cfg *config.Config) {
+		cfg.Session.AutoStartPlayers = 1
+		cfg.Session.PauseWhenEmpty = true
+		cfg.Session.ReconnectGraceSecs = 30
+	})
+
+	alice := h.join(101, "alice")
+	alice.Expect(network.MsgTypeSessionStatus, nil)
+
+	// A held seat is not a live connection
+	alice.ws.UnderlyingConn().Close()
+	waitForState(t, h.server.session, StatePaused)
+	if _, ok := h.server.session.GetPlayer("101"); !ok {
+		t.Fatal("alice's seat was not held")
+	}
+
+	// Reconnecting resumes the session
+	h.join(101, "alice")
+	waitForState(t, h.server.session, StateRunning)
+}
+
+func TestSessionControl(t *testing.T) {
+	h := newTestHarness(t, func(cfg *config.Config) {
+		cfg.Session.AdminPermission = testAdminPermission
+		cfg.Session.EndingGraceSecs = 60
+	})
+
+	claims := h.tokenClaims(1, "admin")
+	claims.Permissions |= testAdminPermission
+	admin := h.joinAs(claims)
+	player := h.join(101, "alice")
+	admin.Expect(network.MsgTypePlayerJoined, nil)
+
+	// Players without the permission bit are refused
+	player.Send(network.MsgTypeSessionControl, network.SessionControlPayload{Action: ControlStart})
+	var errPayload network.ErrorPayload
+	player.Expect(network.MsgTypeError, &errPayload)
+	if errPayload.Code != "forbidden" {
+		t.Errorf("expected forbidden, got %q", errPayload.Code)
+	}
+
+	steps := []struct {
+		action string
+		want   SessionState
+	}{
+		{ControlStart, StateRunning},
+		{ControlPause, StatePaused},
+		{ControlResume, StateRunning},
+		{ControlEnd, StateEnding},
+	}
+	for _, step := range steps {
+		admin.Send(network.MsgTypeSessionControl, network.SessionControlPayload{Action: step.action})
+		for _, c := range []*testClient{admin, player} {
+			var status network.SessionStatus
+			c.Expect(network.MsgTypeSessionStatus, &status)
+			if status.State != string(step.want) {
+				t.Fatalf("%s: expected %s, got %s", step.action, step.want, status.State)
+			}
+		}
+	}
+
+	// Invalid transitions are rejected without changing state
+	admin.Send(network.MsgTypeSessionControl, network.SessionControlPayload{Action: ControlResume})
+	admin.Expect(network.MsgTypeError, &errPayload)
+	if errPayload.Code != "invalid_transition" {
+		t.Errorf("expected invalid_transition, got %q", errPayload.Code)
+	}
+
+	// Joins are refused once the session is ending
+	late := h.connect(102, "bob")
+	late.Send(network.MsgTypeJoin, network.JoinPayload{})
+	late.Expect(network.MsgTypeError, &errPayload)
+	if errPayload.Code != "session_closed" {
+		t.Errorf("expected session_closed, got %q", errPayload.Code)
+	}
+}
+
+func TestClosedSessionDisconnects(t *testing.T) {
+	h := newTestHarness(t, func(cfg *config.Config) {
+		cfg.Session.AutoStartPlayers = 1
+		cfg.Session.ReconnectGraceSecs = 30
+	})
+	alice := h.join(101, "alice")
+	alice.Expect(network.MsgTypeSessionStatus, nil)
+
+	if err := h.server.session.Transition(StateEnding); err != nil {
+		t.Fatalf("end: %v", err)
+	}
+	alice.Expect(network.MsgTypeSessionStatus, nil)
+	if err := h.server.session.Transition(StateClosed); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	// The closed status is delivered before the connection is closed
+	var status network.SessionStatus
+	alice.Expect(network.MsgTypeSessionStatus, &status)
+	if status.State != string(StateClosed) {
+		t.Errorf("expected closed, got %s", status.State)
+	}
+	alice.ExpectClosed()
+
+	// Closing is not a dropped connection: no seat is held
+	deadline := time.Now().Add(testReadTimeout)
+	for h.server.session.GetStatus().PlayerCount != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("alice is still in the closed session")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTicksOnlyAdvanceWhileRunning(t *testing.T) {
+	h := newTestHarness(t, func(cfg *config.Config) {
+		cfg.Server.TickRate = 100
+	})
+	session := h.server.session
+
+	sleepTicks(5)
+	if tick := session.GetStatus().ServerTick; tick != 0 {
+		t.Fatalf("expected no ticks while waiting, got %d", tick)
+	}
+
+	if err := session.Transition(StateRunning); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	sleepTicks(5)
+	if session.GetStatus().ServerTick == 0 {
+		t.Fatalf("expected ticks while running")
+	}
+
+	if err := session.Transition(StatePaused); err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	frozen := session.GetStatus().ServerTick
+	sleepTicks(5)
+	if tick := session.GetStatus().ServerTick; tick != frozen {
+		t.Errorf("expected tick frozen at %d while paused, got %d", frozen, tick)
+	}
+}
+
+// waitForState polls until the session reaches the wanted state
+func waitForState(t *testing.T, session *Session, want SessionState) {
+	t.Helper()
+	deadline := time.Now().Add(testReadTimeout)
+	for time.Now().Before(deadline) {
+		if session.State() == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("session did not reach %s, stuck in %s", want, session.State())
+}
+
+// sleepTicks waits for roughly n ticks at the test tick rate of 100 Hz
+func sleepTicks(n int) {
+	time.Sleep(time.Duration(n) * 10 * time.Millisecond)
+}
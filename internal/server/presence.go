@@ -0,0 +1,201 @@
+package server
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/gravitas-games/mmorts/internal/network"
+	"github.com/gravitas-games/mmorts/pkg/models"
+)
+
+// How often presence is recomputed and held seats are expired when the
+// configuration does not set it
+const defaultPresenceSweep = time.Second
+
+// Touch records activity from a player, updating LastSeen
+func (s *Session) Touch(playerID string) {
+	s.mu.Lock()
+	player, exists := s.players[playerID]
+	if exists {
+		player.LastSeen = time.Now()
+	}
+	s.mu.Unlock()
+
+	// Coming back from idle/away is reported straight away
+	if exists {
+		s.refreshPresence(playerID)
+	}
+}
+
+// Leave removes a player if conn is still their current connection, so a
+// stale connection closing after a reconnect does not evict the new one
+func (s *Session) Leave(playerID string, conn *Connection) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.connections[playerID]; !ok || current != conn {
+		return false
+	}
+	return s.removePlayerLocked(playerID)
+}
+
+// Disconnect keeps a player's seat for the grace period after their
+// connection drops. It reports false if conn is not their current
+// connection.
+func (s *Session) Disconnect(playerID string, conn *Connection, grace time.Duration) bool {
+	s.mu.Lock()
+	player, exists := s.players[playerID]
+	if !exists || s.connections[playerID] != conn {
+		s.mu.Unlock()
+		return false
+	}
+
+	player.Connected = false
+	delete(s.connections, playerID)
+	s.reconnects[playerID] = time.Now().Add(grace)
+	s.mu.Unlock()
+
+	log.Printf("Player %s (%s) disconnected, holding seat for %v", player.Username, playerID, grace)
+	s.refreshPresence(playerID)
+
+	// The last live connection dropping may empty the session
+	s.CheckTransitions()
+	return true
+}
+
+// IsReconnecting reports whether a player's seat is being held
+func (s *Session) IsReconnecting(playerID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.reconnects[playerID]
+	return ok
+}
+
+// presenceThresholds returns the configured idle and away durations
+func (s *Session) presenceThresholds() (idle, away time.Duration) {
+	return time.Duration(s.config.Session.IdleAfterSecs) * time.Second,
+		time.Duration(s.config.Session.AwayAfterSecs) * time.Second
+}
+
+// refreshPresence recomputes one player's presence and broadcasts it if it
+// changed
+func (s *Session) refreshPresence(playerID string) {
+	idle, away := s.presenceThresholds()
+
+	s.mu.Lock()
+	player, exists := s.players[playerID]
+	if !exists {
+		s.mu.Unlock()
+		return
+	}
+	presence := player.PresenceAt(time.Now(), idle, away)
+	changed := s.presence[playerID] != presence
+	s.presence[playerID] = presence
+	s.mu.Unlock()
+
+	if changed {
+		s.broadcastPresence(playerID, presence)
+	}
+}
+
+// sweepPresence expires held seats and broadcasts presence changes caused
+// by inactivity
+func (s *Session) sweepPresence(now time.Time) {
+	idle, away := s.presenceThresholds()
+
+	type update struct {
+		playerID string
+		presence models.Presence
+	}
+	var updates []update
+	var expired []*models.Player
+
+	s.mu.Lock()
+	for playerID, deadline := range s.reconnects {
+		if now.After(deadline) {
+			expired = append(expired, s.players[playerID])
+			s.removePlayerLocked(playerID)
+		}
+	}
+	for playerID, player := range s.players {
+		presence := player.PresenceAt(now, idle, away)
+		if s.presence[playerID] != presence {
+			s.presence[playerID] = presence
+			updates = append(updates, update{playerID, presence})
+		}
+	}
+	s.mu.Unlock()
+
+	for _, player := range expired {
+		log.Printf("Player %s (%s) did not reconnect in time", player.Username, player.ID)
+		s.BroadcastMessage(&network.ServerMessage{
+			Type: network.MsgTypePlayerLeft,
+			Payload: network.PlayerLeftPayload{
+				PlayerID: player.ID,
+				Username: player.Username,
+			},
+		})
+	}
+	for _, u := range updates {
+		s.broadcastPresence(u.playerID, u.presence)
+	}
+	if len(expired) > 0 {
+		s.CheckTransitions()
+	}
+}
+
+// broadcastPresence pushes a presence change to all players
+func (s *Session) broadcastPresence(playerID string, presence models.Presence) {
+	s.BroadcastMessage(&network.ServerMessage{
+		Type: network.MsgTypePresence,
+		Payload: network.PresencePayload{
+			PlayerID: playerID,
+			Presence: string(presence),
+		},
+	})
+}
+
+// playerInfoLocked builds a player list entry; the caller must hold s.mu
+func (s *Session) playerInfoLocked(player *models.Player) network.PlayerInfo {
+	presence, ok := s.presence[player.ID]
+	if !ok {
+		presence = models.PresenceOnline
+	}
+	return network.PlayerInfo{
+		PublicProfile: player.PublicProfile(),
+		Presence:      string(presence),
+	}
+}
+
+// PlayerList returns a snapshot of everyone in the session, ordered by
+// player ID
+func (s *Session) PlayerList() network.PlayerListPayload {
+	players := s.GetPlayers()
+	sort.Slice(players, func(i, j int) bool { return players[i].ID < players[j].ID })
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := network.PlayerListPayload{Players: make([]network.PlayerInfo, 0, len(players))}
+	for _, player := range players {
+		list.Players = append(list.Players, s.playerInfoLocked(player))
+	}
+	return list
+}
+
+// PlayerProfile returns the public profile of a player in the session
+func (s *Session) PlayerProfile(playerID string) (network.PlayerProfilePayload, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	player, exists := s.players[playerID]
+	if !exists {
+		return network.PlayerProfilePayload{}, false
+	}
+	return network.PlayerProfilePayload{
+		PlayerInfo:  s.playerInfoLocked(player),
+		OnlineSince: player.ConnectedAt.Unix(),
+	}, true
+}
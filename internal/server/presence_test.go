@@ -0,0 +1,138 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitas-games/mmorts/internal/config"
+	"github.com/gravitas-games/mmorts/internal/network"
+	"github.com/gravitas-games/mmorts/pkg/models"
+)
+
+func TestPlayerListAfterWelcome(t *testing.T) {
+	h := newTestHarness(t)
+	alice := h.join(101, "alice")
+	bob := h.join(102, "bob")
+
+	if len(alice.Players.Players) != 1 {
+		t.Fatalf("expected alice to see only herself, got %+v", alice.Players)
+	}
+
+	got := bob.Players.Players
+	if len(got) != 2 || got[0].PlayerID != "101" || got[1].PlayerID != "102" {
+		t.Fatalf("expected alice and bob in order, got %+v", got)
+	}
+	for _, p := range got {
+		if p.Presence != string(models.PresenceOnline) {
+			t.Errorf("expected %s online, got %s", p.Username, p.Presence)
+		}
+	}
+
+	// The list can also be requested at any time
+	alice.Expect(network.MsgTypePlayerJoined, nil)
+	alice.Send(network.MsgTypePlayerListRequest, struct{}{})
+	var list network.PlayerListPayload
+	alice.Expect(network.MsgTypePlayerList, &list)
+	if len(list.Players) != 2 {
+		t.Errorf("expected 2 players on request, got %d", len(list.Players))
+	}
+}
+
+func TestPlayerProfileQuery(t *testing.T) {
+	h := newTestHarness(t)
+	h.join(101, "alice")
+	bob := h.join(102, "bob")
+
+	bob.Send(network.MsgTypePlayerProfileRequest, network.PlayerProfileRequestPayload{PlayerID: "101"})
+	var profile network.PlayerProfilePayload
+	bob.Expect(network.MsgTypePlayerProfile, &profile)
+	if profile.PlayerID != "101" || profile.Username != "alice" || profile.OnlineSince == 0 {
+		t.Errorf("unexpected profile: %+v", profile)
+	}
+
+	bob.Send(network.MsgTypePlayerProfileRequest, network.PlayerProfileRequestPayload{PlayerID: "999"})
+	var errPayload network.ErrorPayload
+	bob.Expect(network.MsgTypeError, &errPayload)
+	if errPayload.Code != "player_not_found" {
+		t.Errorf("expected player_not_found, got %q", errPayload.Code)
+	}
+}
+
+func TestIdlePresence(t *testing.T) {
+	h := newTestHarness(t, manualPresenceSweeps, func(cfg *config.Config) {
+		cfg.Session.IdleAfterSecs = 10
+		cfg.Session.AwayAfterSecs = 60
+	})
+	alice := h.join(101, "alice")
+
+	// Pretend time has passed rather than waiting for the sweep
+	h.server.session.sweepPresence(time.Now().Add(15 * time.Second))
+
+	var presence network.PresencePayload
+	alice.Expect(network.MsgTypePresence, &presence)
+	if presence.PlayerID != "101" || presence.Presence != string(models.PresenceIdle) {
+		t.Fatalf("expected alice idle, got %+v", presence)
+	}
+
+	h.server.session.sweepPresence(time.Now().Add(90 * time.Second))
+	alice.Expect(network.MsgTypePresence, &presence)
+	if presence.Presence != string(models.PresenceAway) {
+		t.Fatalf("expected alice away, got %+v", presence)
+	}
+
+	// Any message brings the player back online
+	alice.Send(network.MsgTypePing, struct{}{})
+	alice.Expect(network.MsgTypePresence, &presence)
+	if presence.Presence != string(models.PresenceOnline) {
+		t.Errorf("expected alice online after activity, got %+v", presence)
+	}
+	alice.Expect(network.MsgTypePong, nil)
+}
+
+func TestReconnectHoldsSeat(t *testing.T) {
+	h := newTestHarness(t, manualPresenceSweeps, func(cfg *config.Config) {
+		cfg.Session.ReconnectGraceSecs = 30
+	})
+	alice := h.join(101, "alice")
+	bob := h.join(102, "bob")
+	alice.Expect(network.MsgTypePlayerJoined, nil)
+
+	// Drop the TCP connection without a close frame
+	bob.ws.UnderlyingConn().Close()
+
+	var presence network.PresencePayload
+	alice.Expect(network.MsgTypePresence, &presence)
+	if presence.PlayerID != "102" || presence.Presence != string(models.PresenceReconnecting) {
+		t.Fatalf("expected bob reconnecting, got %+v", presence)
+	}
+	if _, ok := h.server.session.GetPlayer("102"); !ok {
+		t.Fatalf("bob's seat was not held")
+	}
+
+	// Rejoining within the grace period resumes rather than re-announcing
+	bob = h.join(102, "bob")
+	alice.Expect(network.MsgTypePresence, &presence)
+	if presence.PlayerID != "102" || presence.Presence != string(models.PresenceOnline) {
+		t.Fatalf("expected bob online after reconnect, got %+v", presence)
+	}
+
+	// A second drop that outlives the grace period removes the player
+	bob.ws.UnderlyingConn().Close()
+	alice.Expect(network.MsgTypePresence, nil)
+	h.server.session.sweepPresence(time.Now().Add(time.Minute))
+
+	var left network.PlayerLeftPayload
+	alice.Expect(network.MsgTypePlayerLeft, &left)
+	if left.PlayerID != "102" {
+		t.Errorf("expected bob to leave, got %+v", left)
+	}
+	if count := h.server.session.GetStatus().PlayerCount; count != 1 {
+		t.Errorf("expected player count 1, got %d", count)
+	}
+}
+
+// manualPresenceSweeps is a harness option that stops the periodic sweep
+// from racing sweeps the test runs with a simulated clock
+func manualPresenceSweeps(cfg *config.Config) {
+	cfg.Session.PresenceSweepSecs = 3600
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gravitas-games/mmorts/internal/network"
+	"github.com/gravitas-games/mmorts/pkg/models"
+)
+
+// privateClaimKeys are JSON keys of models.Player that must never be sent
+// to another player
+var privateClaimKeys = []string{"email", "permissions", "auth_method", "user_type", "activated"}
+
+func TestPublicProfileOmitsPrivateClaims(t *testing.T) {
+	player := &models.Player{
+		ID:          "101",
+		Username:    "alice",
+		Email:       "alice@private.example",
+		UserType:    "admin",
+		Permissions: 1 << 40,
+		Activated:   1697123456789000000,
+		AuthMethod:  "oauth",
+		EmpireID:    "101",
+		Flag:        "lion",
+	}
+
+	data, err := json.Marshal(player.PublicProfile())
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	for _, key := range privateClaimKeys {
+		if _, ok := fields[key]; ok {
+			t.Errorf("public profile contains private field %q: %s", key, data)
+		}
+	}
+	if fields["username"] != "alice" || fields["flag"] != "lion" {
+		t.Errorf("public profile lost public fields: %s", data)
+	}
+}
+
+func TestOutboundPayloadsHidePrivateClaims(t *testing.T) {
+	h := newTestHarness(t, manualPresenceSweeps)
+
+	claims := h.tokenClaims(101, "alice")
+	claims.Email = "alice@private.example"
+	claims.AuthMethod = "oauth-secret-method"
+	claims.UserType = "superadmin"
+	claims.Permissions = 987654321
+
+	bob := h.join(102, "bob")
+	alice := h.joinAs(claims)
+
+	// Collect everything bob hears about alice: join, list, profile,
+	// presence and chat
+	var raw []receivedMessage
+	next := func() {
+		t.Helper()
+		msg, err := bob.Next()
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		raw = append(raw, msg)
+	}
+
+	next() // player_joined
+
+	bob.Send(network.MsgTypePlayerListRequest, struct{}{})
+	next()
+
+	bob.Send(network.MsgTypePlayerProfileRequest, network.PlayerProfileRequestPayload{PlayerID: "101"})
+	next()
+
+	alice.Send(network.MsgTypeChat, network.ChatPayload{Message: "hi"})
+	next()
+
+	alice.ws.UnderlyingConn().Close()
+	next() // player_left (no reconnect grace in the harness)
+
+	secrets := []string{claims.Email, claims.AuthMethod, claims.UserType, "987654321"}
+	for _, msg := range raw {
+		payload := string(msg.Payload)
+		for _, secret := range secrets {
+			if strings.Contains(payload, secret) {
+				t.Errorf("%s payload leaks %q: %s", msg.Type, secret, payload)
+			}
+		}
+		for _, key := range privateClaimKeys {
+			if strings.Contains(payload, `"`+key+`"`) {
+				t.Errorf("%s payload contains private key %q: %s", msg.Type, key, payload)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, msg := range raw {
+		seen[msg.Type] = true
+	}
+	for _, msgType := range []string{network.MsgTypePlayerJoined, network.MsgTypePlayerList, network.MsgTypePlayerProfile, network.MsgTypeChatBroadcast, network.MsgTypePlayerLeft} {
+		if !seen[msgType] {
+			t.Errorf("expected bob to receive %q", msgType)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitas-015/inventory"
+	"github.com/gravitas-015/production"
+	"github.com/gravitas-games/mmorts/internal/network"
+)
+
+// Inventory volume used when the configuration does not set one
+const defaultInventoryCapacity = 1000
+
+// errJobNotFound is returned for unknown jobs and other players' jobs
+var errJobNotFound = errors.New("job not found")
+
+// Production is the session's crafting system: one production manager
+// running on the session tick, the recipe registry and an inventory per
+// player. Job events are pushed to the owning player's connection.
+type Production struct {
+	registry    *production.RecipeRegistry
+	inventories *production.SimpleInventoryProvider
+	clock       *production.TickClock
+	capacity    int
+
+	// mu serializes manager calls: the manager hands out live jobs, which
+	// must not be read while a tick completes or restarts them, and
+	// sessionEventBus reads them as they are published. Never acquired while
+	// holding the session lock.
+	mu      sync.Mutex
+	manager *production.Manager
+}
+
+// NewProduction creates the session's production system and loads the
+// configured recipe files
+func NewProduction(s *Session) (*Production, error) {
+	cfg := s.config
+
+	tickRate := cfg.Server.TickRate
+	if tickRate <= 0 {
+		tickRate = 20
+	}
+	capacity := cfg.Production.InventoryCapacity
+	if capacity <= 0 {
+		capacity = defaultInventoryCapacity
+	}
+
+	p := &Production{
+		registry:    production.NewRecipeRegistry(),
+		inventories: production.NewSimpleInventoryProvider(),
+		clock:       production.NewTickClock(s.CreatedAt, time.Second/time.Duration(tickRate)),
+		capacity:    capacity,
+	}
+	p.manager = production.NewManager(s.ID, p.registry, p.inventories, &sessionEventBus{session: s}, nil)
+	p.manager.SetClock(p.clock)
+
+	if dir := cfg.Production.RecipesDir; dir != "" {
+		if err := production.NewLoader(p.registry, nil).LoadDir(dir); err != nil {
+			return nil, fmt.Errorf("failed to load recipes: %w", err)
+		}
+		log.Printf("Loaded %d recipes from %s", p.registry.Count(), dir)
+	}
+	return p, nil
+}
+
+// Tick advances production to a server tick, completing due jobs
+func (p *Production) Tick(tick int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clock.SetTick(tick)
+	p.manager.UpdateNow()
+}
+
+// inventoryID returns the ID of a player's inventory
+func inventoryID(playerID string) string {
+	return "player:" + playerID
+}
+
+// EnsureInventory creates a player's inventory on first join. It is kept
+// across reconnects and leaves. It does not take p.mu.
+func (p *Production) EnsureInventory(playerID string) {
+	id := inventoryID(playerID)
+	if _, err := p.inventories.GetInventory(id); err == nil {
+		return
+	}
+	p.inventories.AddInventory(inventory.NewVolume(id, inventory.OwnerID(playerID), p.capacity))
+}
+
+// Start starts a job for a player in their own inventory
+func (p *Production) Start(playerID, recipeID string, repeat bool) (production.JobID, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	owner := inventory.OwnerID(playerID)
+	recipe := production.RecipeID(recipeID)
+	if repeat {
+		return p.manager.StartRepeatingProduction(recipe, owner, inventoryID(playerID))
+	}
+	return p.manager.StartProduction(recipe, owner, inventoryID(playerID))
+}
+
+// Cancel cancels one of a player's jobs and refunds its inputs. Jobs of
+// other players are reported as not found.
+func (p *Production) Cancel(playerID, jobID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	job := p.manager.GetJob(production.JobID(jobID))
+	if job == nil || job.Owner != inventory.OwnerID(playerID) {
+		return errJobNotFound
+	}
+	return p.manager.CancelProductionWithRefund(job.ID)
+}
+
+// Jobs lists a player's active jobs, oldest first
+func (p *Production) Jobs(playerID string) network.JobListPayload {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	jobs := p.manager.GetActiveJobs(inventory.OwnerID(playerID))
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].StartTime.Equal(jobs[j].StartTime) {
+			return jobs[i].StartTime.Before(jobs[j].StartTime)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+
+	now := p.clock.Now()
+	payload := network.JobListPayload{Jobs: make([]network.JobInfo, 0, len(jobs))}
+	for _, job := range jobs {
+		payload.Jobs = append(payload.Jobs, jobInfo(job, now))
+	}
+	return payload
+}
+
+// Recipes lists every recipe, ordered by ID
+func (p *Production) Recipes() network.RecipeListPayload {
+	recipes := p.registry.GetAll()
+	sort.Slice(recipes, func(i, j int) bool { return recipes[i].ID < recipes[j].ID })
+
+	payload := network.RecipeListPayload{Recipes: make([]network.RecipeInfo, 0, len(recipes))}
+	for _, recipe := range recipes {
+		info := network.RecipeInfo{
+			RecipeID:   string(recipe.ID),
+			Name:       recipe.Name,
+			Category:   recipe.Category,
+			DurationMs: recipe.Duration.Milliseconds(),
+			Inputs:     make([]network.RecipeItem, 0, len(recipe.Inputs)),
+			Outputs:    make([]network.RecipeItem, 0, len(recipe.Outputs)),
+		}
+		for _, in := range recipe.Inputs {
+			info.Inputs = append(info.Inputs, network.RecipeItem{Item: string(in.Item), Quantity: in.Quantity, Consume: in.Consume})
+		}
+		for _, out := range recipe.Outputs {
+			info.Outputs = append(info.Outputs, network.RecipeItem{Item: string(out.Item), Quantity: out.Quantity, Probability: out.Probability})
+		}
+		payload.Recipes = append(payload.Recipes, info)
+	}
+	return payload
+}
+
+// jobInfo converts a job to its wire format
+func jobInfo(job *production.Job, now time.Time) network.JobInfo {
+	progress := job.CalculateProgress(now)
+	remaining := time.Duration(0)
+	if job.State == production.JobRunning && job.EndTime.After(now) {
+		remaining = job.EndTime.Sub(now)
+	}
+	return network.JobInfo{
+		JobID:       string(job.ID),
+		RecipeID:    string(job.Recipe),
+		State:       strings.ToLower(job.State.String()),
+		Progress:    progress,
+		DurationMs:  job.EffectiveDuration.Milliseconds(),
+		RemainingMs: remaining.Milliseconds(),
+		Repeat:      job.Repeat,
+		Cycles:      job.CyclesCompleted,
+	}
+}
+
+// jobMessageTypes maps job events to their own server message types
+var jobMessageTypes = map[production.EventType]string{
+	production.EventJobStarted:   network.MsgTypeJobStarted,
+	production.EventJobCompleted: network.MsgTypeJobCompleted,
+	production.EventJobFailed:    network.MsgTypeJobFailed,
+	production.EventJobCancelled: network.MsgTypeJobCancelled,
+}
+
+// jobEventNames names every forwarded event in JobEventPayload.Event. Events
+// without a typed message are sent as job_updated.
+var jobEventNames = map[production.EventType]string{
+	production.EventJobStarted:          "started",
+	production.EventJobCompleted:        "completed",
+	production.EventJobFailed:           "failed",
+	production.EventJobCancelled:        "cancelled",
+	production.EventJobBlocked:          "blocked",
+	production.EventJobUnblocked:        "unblocked",
+	production.EventJobModifiersChanged: "modifiers_changed",
+	production.EventJobStalled:          "stalled",
+	production.EventJobRecovered:        "recovered",
+}
+
+// sessionEventBus is a production.EventBus that forwards job events to the
+// owning player's connection as typed server messages. Publish reads the
+// live job; that is safe, and messages are queued on the connection in event
+// order, only because every manager call is made under Production.mu
+type sessionEventBus struct {
+	session *Session
+}
+
+// Subscribe does nothing: events are routed to players by job owner
+func (b *sessionEventBus) Subscribe(owner inventory.OwnerID, handler func(production.Event)) {}
+
+// Unsubscribe does nothing
+func (b *sessionEventBus) Unsubscribe(owner inventory.OwnerID) {}
+
+// Publish sends a job event to its owner if they are connected
+func (b *sessionEventBus) Publish(event production.Event) {
+	name, ok := jobEventNames[event.Type]
+	if !ok || event.Job == nil || event.Job.Owner == "" {
+		return
+	}
+
+	msgType, ok := jobMessageTypes[event.Type]
+	if !ok {
+		msgType = network.MsgTypeJobUpdated
+	}
+
+	payload := network.JobEventPayload{
+		JobInfo: jobInfo(event.Job, event.Timestamp),
+		Event:   name,
+	}
+	if reason, ok := event.Data["error"].(string); ok {
+		payload.Reason = reason
+	}
+
+	b.session.SendTo(string(event.Job.Owner), &network.ServerMessage{
+		Type:    msgType,
+		Payload: payload,
+	})
+}
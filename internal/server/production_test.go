@@ -0,0 +1,145 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravitas-games/mmorts/internal/config"
+	"github.com/gravitas-games/mmorts/internal/network"
+)
+
+const testRecipes = `recipes:
+  - id: gather_wood
+    name: Gather Wood
+    category: gathering
+    duration: 100ms
+    outputs:
+      - {item: wood, quantity: 5}
+
+  - id: carve_totem
+    name: Carve Totem
+    category: crafting
+    duration: 1h
+    inputs:
+      - {item: wood, quantity: 4}
+    outputs:
+      - {item: totem, quantity: 1}
+`
+
+// newProductionHarness starts a server that runs as soon as one player
+// joins, with recipes loaded from a temporary directory
+func newProductionHarness(t *testing.T) *testHarness {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "test.yaml"), []byte(testRecipes), 0o644); err != nil {
+		t.Fatalf("failed to write recipes: %v", err)
+	}
+	return newTestHarness(t, func(cfg *config.Config) {
+		cfg.Session.AutoStartPlayers = 1
+		cfg.Production.RecipesDir = dir
+	})
+}
+
+func TestRecipeList(t *testing.T) {
+	h := newProductionHarness(t)
+	alice := h.join(101, "alice")
+	alice.Expect(network.MsgTypeSessionStatus, nil)
+
+	alice.Send(network.MsgTypeRecipeList, struct{}{})
+	var list network.RecipeListPayload
+	alice.Expect(network.MsgTypeRecipeList, &list)
+	if len(list.Recipes) != 2 {
+		t.Fatalf("expected 2 recipes, got %+v", list.Recipes)
+	}
+	totem := list.Recipes[0]
+	if totem.RecipeID != "carve_totem" || len(totem.Inputs) != 1 || totem.Inputs[0].Quantity != 4 {
+		t.Errorf("unexpected recipe: %+v", totem)
+	}
+}
+
+func TestProductionJobLifecycle(t *testing.T) {
+	h := newProductionHarness(t)
+	alice := h.join(101, "alice")
+	alice.Expect(network.MsgTypeSessionStatus, nil)
+
+	alice.Send(network.MsgTypeStartProduction, network.StartProductionPayload{RecipeID: "gather_wood"})
+	var started network.JobEventPayload
+	alice.Expect(network.MsgTypeJobStarted, &started)
+	if started.RecipeID != "gather_wood" || started.State != "running" || started.Event != "started" {
+		t.Errorf("unexpected job_started: %+v", started)
+	}
+
+	var completed network.JobEventPayload
+	alice.Expect(network.MsgTypeJobCompleted, &completed)
+	if completed.JobID != started.JobID || completed.Progress != 1 {
+		t.Errorf("unexpected job_completed: %+v", completed)
+	}
+
+	// The wood pays for a long job that stays listed until cancelled
+	alice.Send(network.MsgTypeStartProduction, network.StartProductionPayload{RecipeID: "carve_totem"})
+	var carving network.JobEventPayload
+	alice.Expect(network.MsgTypeJobStarted, &carving)
+
+	alice.Send(network.MsgTypeJobList, struct{}{})
+	var list network.JobListPayload
+	alice.Expect(network.MsgTypeJobList, &list)
+	if len(list.Jobs) != 1 || list.Jobs[0].JobID != carving.JobID {
+		t.Fatalf("expected only the carving job, got %+v", list.Jobs)
+	}
+
+	// Only 1 wood is left, so a second carving fails
+	alice.Send(network.MsgTypeStartProduction, network.StartProductionPayload{RecipeID: "carve_totem"})
+	var errPayload network.ErrorPayload
+	alice.Expect(network.MsgTypeError, &errPayload)
+	if errPayload.Code != "production_failed" {
+		t.Errorf("expected production_failed, got %+v", errPayload)
+	}
+
+	alice.Send(network.MsgTypeCancelProduction, network.CancelProductionPayload{JobID: carving.JobID})
+	var cancelled network.JobEventPayload
+	alice.Expect(network.MsgTypeJobCancelled, &cancelled)
+	if cancelled.JobID != carving.JobID {
+		t.Errorf("cancelled the wrong job: %+v", cancelled)
+	}
+
+	// The refund pays for it again
+	alice.Send(network.MsgTypeStartProduction, network.StartProductionPayload{RecipeID: "carve_totem"})
+	alice.Expect(network.MsgTypeJobStarted, nil)
+}
+
+func TestCancelOtherPlayersJob(t *testing.T) {
+	h := newProductionHarness(t)
+	alice := h.join(101, "alice")
+	alice.Expect(network.MsgTypeSessionStatus, nil)
+	bob := h.join(102, "bob")
+	alice.Expect(network.MsgTypePlayerJoined, nil)
+
+	alice.Send(network.MsgTypeStartProduction, network.StartProductionPayload{RecipeID: "gather_wood"})
+	var started network.JobEventPayload
+	alice.Expect(network.MsgTypeJobStarted, &started)
+
+	bob.Send(network.MsgTypeCancelProduction, network.CancelProductionPayload{JobID: started.JobID})
+	var errPayload network.ErrorPayload
+	bob.Expect(network.MsgTypeError, &errPayload)
+	if errPayload.Code != "job_not_found" {
+		t.Errorf("expected job_not_found, got %+v", errPayload)
+	}
+
+	// Job events only reach their owner
+	alice.Expect(network.MsgTypeJobCompleted, nil)
+	bob.ExpectNone(100 * time.Millisecond)
+}
+
+func TestProductionRequiresRunningSession(t *testing.T) {
+	h := newTestHarness(t)
+	alice := h.join(101, "alice")
+
+	alice.Send(network.MsgTypeStartProduction, network.StartProductionPayload{RecipeID: "gather_wood"})
+	var errPayload network.ErrorPayload
+	alice.Expect(network.MsgTypeError, &errPayload)
+	if errPayload.Code != "session_not_running" {
+		t.Errorf("expected session_not_running, got %+v", errPayload)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"sync"
 	"time"
@@ -84,6 +85,7 @@ func New(cfg *config.Config) (*Server, error) {
 		return nil, err
 	}
 	srv.session = session
+	go session.Run(ctx)
 
 	log.Println("Server initialized successfully")
 	return srv, nil
@@ -93,6 +95,18 @@ func New(cfg *config.Config) (*Server, error) {
 func (s *Server) Start(addr string) error {
 	log.Printf("Starting WebSocket server on %s", addr)
 
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ln)
+}
+
+// Serve accepts connections on an existing listener
+func (s *Server) Serve(ln net.Listener) error {
+	addr := ln.Addr().String()
+
 	// Set up HTTP routes
 	mux := http.NewServeMux()
 	mux.HandleFunc("/ws", s.handleWebSocket)
@@ -111,7 +125,7 @@ func (s *Server) Start(addr string) error {
 	log.Printf("WebSocket endpoint: ws://%s/ws", addr)
 	log.Printf("Health endpoint: http://%s/health", addr)
 
-	if err := s.httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if err := s.httpSrv.Serve(ln); err != nil && err != http.ErrServerClosed {
 		return err
 	}
 
@@ -149,7 +163,7 @@ func (s *Server) Shutdown() error {
 		}
 	}
 
-	// TODO: Stop session gracefully
+	// The session tick loop stops with the server context
 
 	log.Println("Server shutdown complete")
 	return nil
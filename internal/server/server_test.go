@@ -0,0 +1,185 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gravitas-games/mmorts/internal/network"
+)
+
+func TestJoinWelcome(t *testing.T) {
+	h := newTestHarness(t)
+	alice := h.connect(101, "alice")
+
+	alice.Send(network.MsgTypeJoin, network.JoinPayload{})
+
+	var welcome network.WelcomePayload
+	alice.Expect(network.MsgTypeWelcome, &welcome)
+	alice.Expect(network.MsgTypePlayerList, nil)
+
+	if welcome.PlayerID != "101" || welcome.Username != "alice" {
+		t.Errorf("unexpected identity in welcome: %+v", welcome)
+	}
+	if welcome.SessionID != "main" {
+		t.Errorf("expected session main, got %q", welcome.SessionID)
+	}
+	if welcome.SessionStatus.PlayerCount != 1 || welcome.SessionStatus.MaxPlayers != 10 {
+		t.Errorf("unexpected session status: %+v", welcome.SessionStatus)
+	}
+}
+
+func TestPlayerJoinedFanOut(t *testing.T) {
+	h := newTestHarness(t)
+	alice := h.join(101, "alice")
+	bob := h.join(102, "bob")
+
+	var joined network.PlayerJoinedPayload
+	alice.Expect(network.MsgTypePlayerJoined, &joined)
+	if joined.PlayerID != "102" || joined.Username != "bob" {
+		t.Errorf("unexpected player_joined payload: %+v", joined)
+	}
+
+	// The joining player is not told about themselves
+	bob.ExpectNone(200 * time.Millisecond)
+}
+
+func TestChatBroadcast(t *testing.T) {
+	h := newTestHarness(t)
+	alice := h.join(101, "alice")
+	bob := h.join(102, "bob")
+	alice.Expect(network.MsgTypePlayerJoined, nil)
+
+	alice.Send(network.MsgTypeChat, network.ChatPayload{Message: "hello"})
+
+	for _, c := range []*testClient{alice, bob} {
+		var chat network.ChatBroadcastPayload
+		c.Expect(network.MsgTypeChatBroadcast, &chat)
+		if chat.PlayerID != "101" || chat.Username != "alice" || chat.Message != "hello" {
+			t.Errorf("unexpected chat payload: %+v", chat)
+		}
+	}
+}
+
+func TestPingPong(t *testing.T) {
+	h := newTestHarness(t)
+	alice := h.join(101, "alice")
+
+	alice.Send(network.MsgTypePing, struct{}{})
+	alice.Expect(network.MsgTypePong, nil)
+}
+
+func TestUnknownMessageType(t *testing.T) {
+	h := newTestHarness(t)
+	alice := h.join(101, "alice")
+
+	alice.Send("dance", struct{}{})
+
+	var errPayload network.ErrorPayload
+	alice.Expect(network.MsgTypeError, &errPayload)
+	if errPayload.Code != "unknown_message_type" {
+		t.Errorf("expected unknown_message_type, got %q", errPayload.Code)
+	}
+}
+
+func TestLeaveOnDisconnect(t *testing.T) {
+	h := newTestHarness(t)
+	alice := h.join(101, "alice")
+	bob := h.join(102, "bob")
+	alice.Expect(network.MsgTypePlayerJoined, nil)
+
+	bob.Close()
+
+	var left network.PlayerLeftPayload
+	alice.Expect(network.MsgTypePlayerLeft, &left)
+	if left.PlayerID != "102" || left.Username != "bob" {
+		t.Errorf("unexpected player_left payload: %+v", left)
+	}
+
+	if _, ok := h.server.session.GetPlayer("102"); ok {
+		t.Errorf("bob still in session after disconnect")
+	}
+	if count := h.server.session.GetStatus().PlayerCount; count != 1 {
+		t.Errorf("expected player count 1, got %d", count)
+	}
+}
+
+func TestRejectedTokens(t *testing.T) {
+	h := newTestHarness(t)
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	h.redis.Set(testBlacklist+"666", "1")
+
+	tests := []struct {
+		name  string
+		token func() string
+	}{
+		{"missing", func() string { return "" }},
+		{"garbage", func() string { return "not-a-jwt" }},
+		{"wrong key", func() string {
+			token, _ := jwt.NewWithClaims(jwt.SigningMethodES256, h.tokenClaims(101, "alice")).SignedString(otherKey)
+			return token
+		}},
+		{"wrong issuer", func() string {
+			claims := h.tokenClaims(101, "alice")
+			claims.Issuer = "someone-else"
+			return h.sign(claims)
+		}},
+		{"expired", func() string {
+			claims := h.tokenClaims(101, "alice")
+			claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+			return h.sign(claims)
+		}},
+		{"not activated", func() string {
+			claims := h.tokenClaims(101, "alice")
+			claims.Activated = 0
+			return h.sign(claims)
+		}},
+		{"banned", func() string {
+			claims := h.tokenClaims(101, "alice")
+			claims.Activated = -1
+			return h.sign(claims)
+		}},
+		{"blacklisted", func() string { return h.token(666, "mallory") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, resp, err := h.dialToken(tt.token())
+			if err == nil {
+				c.Close()
+				t.Fatalf("expected handshake to be rejected")
+			}
+			if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+				t.Fatalf("expected 401 response, got %v (err %v)", resp, err)
+			}
+		})
+	}
+
+	// A valid token still works after the rejections
+	h.join(101, "alice")
+}
+
+func TestShutdown(t *testing.T) {
+	h := newTestHarness(t)
+	alice := h.join(101, "alice")
+	bob := h.join(102, "bob")
+	alice.Expect(network.MsgTypePlayerJoined, nil)
+
+	h.Shutdown()
+
+	alice.ExpectClosed()
+	bob.ExpectClosed()
+
+	if _, _, err := h.dialToken(h.token(103, "carol")); err == nil {
+		t.Errorf("expected dial to fail after shutdown")
+	}
+}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"sync"
 	"time"
@@ -22,8 +24,18 @@ type Session struct {
 	mu          sync.RWMutex
 
 	// Game state (minimal for Phase 1)
-	gameMap *gamemap.GameMap
-	status  SessionStatus
+	gameMap    *gamemap.GameMap
+	production *Production
+	status     SessionStatus
+
+	// Lifecycle
+	pause    pauseReason
+	endingAt time.Time
+
+	// Presence
+	presence      map[string]models.Presence // playerID -> last broadcast presence
+	reconnects    map[string]time.Time       // playerID -> seat expiry while reconnecting
+	presenceSweep time.Duration              // Fixed before Run starts
 
 	// Broadcasting
 	broadcast chan []byte
@@ -34,11 +46,11 @@ type Session struct {
 
 // SessionStatus represents the current state of the session
 type SessionStatus struct {
-	State       string `json:"state"`        // "waiting", "running", "paused"
-	PlayerCount int    `json:"player_count"`
-	MaxPlayers  int    `json:"max_players"`
-	ServerTick  int64  `json:"server_tick"`
-	Uptime      int64  `json:"uptime"` // seconds
+	State       SessionState `json:"state"` // "waiting", "running", "paused", "ending", "closed"
+	PlayerCount int          `json:"player_count"`
+	MaxPlayers  int          `json:"max_players"`
+	ServerTick  int64        `json:"server_tick"`
+	Uptime      int64        `json:"uptime"` // seconds
 }
 
 // NewSession creates a new game session
@@ -52,19 +64,32 @@ func NewSession(id string, cfg *config.Config) (*Session, error) {
 	}
 
 	session := &Session{
-		ID:          id,
-		CreatedAt:   time.Now(),
-		players:     make(map[string]*models.Player),
-		connections: make(map[string]*Connection),
-		gameMap:     gameMap,
-		broadcast:   make(chan []byte, 256),
-		config:      cfg,
+		ID:            id,
+		CreatedAt:     time.Now(),
+		players:       make(map[string]*models.Player),
+		connections:   make(map[string]*Connection),
+		presence:      make(map[string]models.Presence),
+		reconnects:    make(map[string]time.Time),
+		presenceSweep: defaultPresenceSweep,
+		gameMap:       gameMap,
+		broadcast:     make(chan []byte, 256),
+		config:        cfg,
 		status: SessionStatus{
-			State:      "waiting",
+			State:      StateWaiting,
 			MaxPlayers: cfg.Session.MaxPlayers,
 		},
 	}
 
+	if secs := cfg.Session.PresenceSweepSecs; secs > 0 {
+		session.presenceSweep = time.Duration(secs) * time.Second
+	}
+
+	production, err := NewProduction(session)
+	if err != nil {
+		return nil, err
+	}
+	session.production = production
+
 	log.Printf("Session %s created with map radius %d", id, cfg.Session.InitialMapRadius)
 	return session, nil
 }
@@ -76,23 +101,37 @@ func (s *Session) AddPlayer(player *models.Player, conn *Connection) error {
 
 	s.players[player.ID] = player
 	s.connections[player.ID] = conn
+	s.presence[player.ID] = models.PresenceOnline
+	delete(s.reconnects, player.ID)
 	s.status.PlayerCount = len(s.players)
 
 	log.Printf("Player %s (%s) joined session %s", player.Username, player.ID, s.ID)
 	return nil
 }
 
-// RemovePlayer removes a player from the session
-func (s *Session) RemovePlayer(playerID string) {
+// RemovePlayer removes a player from the session, reporting whether they
+// were in it
+func (s *Session) RemovePlayer(playerID string) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if player, exists := s.players[playerID]; exists {
-		log.Printf("Player %s (%s) left session %s", player.Username, playerID, s.ID)
-		delete(s.players, playerID)
-		delete(s.connections, playerID)
-		s.status.PlayerCount = len(s.players)
+	return s.removePlayerLocked(playerID)
+}
+
+// removePlayerLocked removes a player; the caller must hold s.mu
+func (s *Session) removePlayerLocked(playerID string) bool {
+	player, exists := s.players[playerID]
+	if !exists {
+		return false
 	}
+
+	log.Printf("Player %s (%s) left session %s", player.Username, playerID, s.ID)
+	delete(s.players, playerID)
+	delete(s.connections, playerID)
+	delete(s.presence, playerID)
+	delete(s.reconnects, playerID)
+	s.status.PlayerCount = len(s.players)
+	return true
 }
 
 // GetPlayer retrieves a player by ID
@@ -140,6 +179,16 @@ func (s *Session) BroadcastExcept(exclude *Connection, msg *network.ServerMessag
 	}
 }
 
+// SendTo sends a message to one player if they are connected
+func (s *Session) SendTo(playerID string, msg *network.ServerMessage) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if conn, ok := s.connections[playerID]; ok {
+		conn.SendMessage(msg)
+	}
+}
+
 // GetStatus returns the current session status
 func (s *Session) GetStatus() SessionStatus {
 	s.mu.RLock()
@@ -149,3 +198,189 @@ func (s *Session) GetStatus() SessionStatus {
 	status.Uptime = int64(time.Since(s.CreatedAt).Seconds())
 	return status
 }
+
+// statusPayload converts the current status to its wire format
+func (s *Session) statusPayload() network.SessionStatus {
+	status := s.GetStatus()
+	return network.SessionStatus{
+		State:       string(status.State),
+		PlayerCount: status.PlayerCount,
+		MaxPlayers:  status.MaxPlayers,
+		ServerTick:  status.ServerTick,
+		Uptime:      status.Uptime,
+	}
+}
+
+// State returns the current lifecycle state
+func (s *Session) State() SessionState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status.State
+}
+
+// Transition moves the session to a new lifecycle state and pushes
+// session_status to every client
+func (s *Session) Transition(to SessionState) error {
+	return s.transition(to, adminPauseReason(to))
+}
+
+// adminPauseReason is the pause reason recorded for an admin-driven change
+func adminPauseReason(to SessionState) pauseReason {
+	if to == StatePaused {
+		return pauseAdmin
+	}
+	return pauseNone
+}
+
+// transition applies a state change, recording why the session paused
+func (s *Session) transition(to SessionState, reason pauseReason) error {
+	return s.transitionFrom(nil, to, reason)
+}
+
+// transitionFrom is transition that also requires allowed(from). allowed
+// runs under s.mu, the same lock as the change, so the state cannot move in
+// between. A session that reaches closed disconnects its remaining clients.
+func (s *Session) transitionFrom(allowed func(from SessionState) bool, to SessionState, reason pauseReason) error {
+	s.mu.Lock()
+	from := s.status.State
+	if !CanTransition(from, to) || (allowed != nil && !allowed(from)) {
+		s.mu.Unlock()
+		return errInvalidTransition{from: from, to: to}
+	}
+
+	s.status.State = to
+	s.pause = reason
+	if to == StateEnding {
+		s.endingAt = time.Now()
+	}
+	s.mu.Unlock()
+
+	log.Printf("Session %s: %s -> %s", s.ID, from, to)
+	s.BroadcastStatus()
+	if to == StateClosed {
+		s.closeConnections()
+	}
+	return nil
+}
+
+// closeConnections disconnects every client after the messages queued for
+// it, including the closed session_status, have been sent
+func (s *Session) closeConnections() {
+	s.mu.RLock()
+	conns := make([]*Connection, 0, len(s.connections))
+	for _, conn := range s.connections {
+		conns = append(conns, conn)
+	}
+	s.mu.RUnlock()
+
+	for _, conn := range conns {
+		conn.closeAfterSend()
+	}
+}
+
+// Control applies an admin session_control action
+func (s *Session) Control(action string) error {
+	to, ok := controlTargets[action]
+	if !ok {
+		return fmt.Errorf("unknown session control action %q", action)
+	}
+
+	// "start" is only valid from the lobby and "resume" only from a pause,
+	// even though both lead to running
+	allowed := func(from SessionState) bool {
+		return !(action == ControlStart && from != StateWaiting) && !(action == ControlResume && from != StatePaused)
+	}
+	return s.transitionFrom(allowed, to, adminPauseReason(to))
+}
+
+// CheckTransitions applies the configured lifecycle rules after the player
+// count changes. Auto-start counts every seated player; pausing when empty
+// counts live connections, so seats held for a reconnect do not keep the
+// session running
+func (s *Session) CheckTransitions() {
+	s.mu.RLock()
+	state := s.status.State
+	count := len(s.players)
+	live := len(s.connections)
+	pause := s.pause
+	s.mu.RUnlock()
+
+	// Only act if nothing changed the state (or why it paused) since
+	unchanged := func(from SessionState) bool { return from == state && s.pause == pause }
+
+	rules := s.config.Session
+	switch {
+	case state == StateWaiting && rules.AutoStartPlayers > 0 && count >= rules.AutoStartPlayers:
+		s.transitionFrom(unchanged, StateRunning, pauseNone)
+
+	case state == StateRunning && rules.PauseWhenEmpty && live == 0:
+		s.transitionFrom(unchanged, StatePaused, pauseEmpty)
+
+	case state == StatePaused && pause == pauseEmpty && live > 0:
+		s.transitionFrom(unchanged, StateRunning, pauseNone)
+	}
+}
+
+// BroadcastStatus pushes the current session_status to all players
+func (s *Session) BroadcastStatus() {
+	s.BroadcastMessage(&network.ServerMessage{
+		Type:    network.MsgTypeSessionStatus,
+		Payload: s.statusPayload(),
+	})
+}
+
+// Run drives the tick loop until the context is cancelled or the session
+// closes. Ticks only advance while the session is running.
+func (s *Session) Run(ctx context.Context) {
+	tickRate := s.config.Server.TickRate
+	if tickRate <= 0 {
+		tickRate = 20
+	}
+	interval := time.Second / time.Duration(tickRate)
+	grace := time.Duration(s.config.Session.EndingGraceSecs) * time.Second
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	presenceTicker := time.NewTicker(s.presenceSweep)
+	defer presenceTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-presenceTicker.C:
+			s.sweepPresence(time.Now())
+			continue
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+
+		s.mu.Lock()
+		state := s.status.State
+		if state.Ticks() {
+			s.status.ServerTick++
+		}
+		tick := s.status.ServerTick
+		endingAt := s.endingAt
+		s.mu.Unlock()
+
+		// Production runs on game time, so it freezes while paused
+		if state.Ticks() {
+			s.production.Tick(tick)
+		}
+
+		if state == StateEnding && time.Since(endingAt) >= grace {
+			s.transition(StateClosed, pauseNone)
+		}
+		if state == StateClosed {
+			log.Printf("Session %s closed, stopping tick loop", s.ID)
+			return
+		}
+
+		if elapsed := time.Since(start); elapsed > interval {
+			log.Printf("Session %s tick overran: %v (budget %v)", s.ID, elapsed, interval)
+		}
+	}
+}
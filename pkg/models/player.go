@@ -23,7 +23,41 @@ type Player struct {
 
 	// Game-specific (not from JWT)
 	// Empire ID will be assigned by game server or loaded from database
-	EmpireID string `json:"empire_id,omitempty"`
+	EmpireID string      `json:"empire_id,omitempty"`
+	Avatar   string      `json:"avatar,omitempty"` // Avatar image ID chosen by the player
+	Flag     string      `json:"flag,omitempty"`   // Empire flag ID
+	Stats    PlayerStats `json:"stats"`
+}
+
+// PlayerStats holds the statistics other players may see
+type PlayerStats struct {
+	GamesPlayed int   `json:"games_played"`
+	Wins        int   `json:"wins"`
+	Score       int64 `json:"score"`
+}
+
+// PublicProfile is the projection of a Player that may be shown to other
+// players. It deliberately leaves out every private JWT claim (email,
+// permissions, user type, activation, auth method).
+type PublicProfile struct {
+	PlayerID string      `json:"player_id"`
+	Username string      `json:"username"` // Display name
+	EmpireID string      `json:"empire_id,omitempty"`
+	Avatar   string      `json:"avatar,omitempty"`
+	Flag     string      `json:"flag,omitempty"`
+	Stats    PlayerStats `json:"stats"`
+}
+
+// PublicProfile returns the player's public projection
+func (p *Player) PublicProfile() PublicProfile {
+	return PublicProfile{
+		PlayerID: p.ID,
+		Username: p.Username,
+		EmpireID: p.EmpireID,
+		Avatar:   p.Avatar,
+		Flag:     p.Flag,
+		Stats:    p.Stats,
+	}
 }
 
 // IsActive checks if the player account is activated and not banned
@@ -43,3 +77,32 @@ func (p *Player) IsBanned() bool {
 func (p *Player) IsConnected() bool {
 	return p.Connected
 }
+
+// Presence describes how active a player currently is
+type Presence string
+
+// Presence states
+const (
+	PresenceOnline       Presence = "online"       // Connected and recently active
+	PresenceIdle         Presence = "idle"         // Connected, no messages for a while
+	PresenceAway         Presence = "away"         // Connected, no messages for a long time
+	PresenceReconnecting Presence = "reconnecting" // Connection dropped, seat held for reconnect
+)
+
+// PresenceAt derives the player's presence from LastSeen. A zero threshold
+// disables that state.
+func (p *Player) PresenceAt(now time.Time, idleAfter, awayAfter time.Duration) Presence {
+	if !p.Connected {
+		return PresenceReconnecting
+	}
+
+	inactive := now.Sub(p.LastSeen)
+	switch {
+	case awayAfter > 0 && inactive >= awayAfter:
+		return PresenceAway
+	case idleAfter > 0 && inactive >= idleAfter:
+		return PresenceIdle
+	default:
+		return PresenceOnline
+	}
+}